@@ -1,13 +1,103 @@
 package auth0
 
 import (
+	"time"
+
 	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
 	"github.com/greencoda/confiq"
 )
 
+const DefaultIssuerName = "default"
+
+// Session stores the backend for frontends can keep its sessions in.
+const (
+	SessionStoreCookie = "cookie"
+	SessionStoreMemory = "memory"
+)
+
+// IssuerConfig describes a single token issuer the gateway can validate tokens from.
+type IssuerConfig struct {
+	Name      string `cfg:"name,default=default"`
+	Audience  string `cfg:"audience,default=https://your-auth0-api.yourdomain.io"`
+	Domain    string `cfg:"domain,default=your-auth0-tenant.eu.auth0.com"`
+	IssuerURL string `cfg:"issuerUrl"`
+
+	// Algorithms restricts the signature algorithms accepted from the issuer. HMAC algorithms
+	// (HS256, HS384, HS512) verify tokens with the secret read from SecretFile or SecretEnv.
+	Algorithms config_util.List[string] `cfg:"algorithms"`
+	SecretFile string                   `cfg:"secretFile"`
+	SecretEnv  string                   `cfg:"secretEnv"`
+
+	// Introspection, if set, makes the issuer validate opaque tokens at its
+	// introspection endpoint instead of verifying them as JWTs.
+	Introspection *IntrospectionConfig `cfg:"introspection"`
+
+	// JWKS tunes how the signing keys of the issuer are loaded and cached.
+	JWKS *JWKSConfig `cfg:"jwks"`
+}
+
+// JWKSConfig describes where the signing keys of an issuer come from and how long they are cached.
+type JWKSConfig struct {
+	File               string        `cfg:"file"`
+	CacheTTL           time.Duration `cfg:"cacheTtl,default=5m"`
+	MinRefreshInterval time.Duration `cfg:"minRefreshInterval,default=30s"`
+	Prefetch           bool          `cfg:"prefetch,default=false"`
+}
+
+// IntrospectionConfig describes an OAuth 2.0 token introspection endpoint (RFC 7662). Issuer, if set,
+// is the only issuer accepted in introspection responses, and defaults to the IssuerURL of the issuer.
+type IntrospectionConfig struct {
+	Endpoint         string        `cfg:"endpoint"`
+	Issuer           string        `cfg:"issuer"`
+	ClientID         string        `cfg:"clientId"`
+	ClientSecretFile string        `cfg:"clientSecretFile"`
+	ClientSecretEnv  string        `cfg:"clientSecretEnv"`
+	CacheTTL         time.Duration `cfg:"cacheTtl,default=1m"`
+	Timeout          time.Duration `cfg:"timeout,default=5s"`
+}
+
+// RevocationConfig describes the denylist of revoked tokens. The denylist file is polled for changes,
+// and can be edited through the admin endpoint if an admin path and token are configured.
+type RevocationConfig struct {
+	File           string        `cfg:"file"`
+	PollInterval   time.Duration `cfg:"pollInterval,default=10s"`
+	AdminPath      string        `cfg:"adminPath"`
+	AdminTokenFile string        `cfg:"adminTokenFile"`
+	AdminTokenEnv  string        `cfg:"adminTokenEnv"`
+}
+
+// BFFConfig makes the gateway a backend for frontends, keeping the tokens of users in server-side sessions.
+type BFFConfig struct {
+	ClientID              string                   `cfg:"clientId"`
+	ClientSecretFile      string                   `cfg:"clientSecretFile"`
+	ClientSecretEnv       string                   `cfg:"clientSecretEnv"`
+	RedirectURL           string                   `cfg:"redirectUrl"`
+	Scopes                config_util.List[string] `cfg:"scopes"`
+	AuthorizationEndpoint string                   `cfg:"authorizationEndpoint"`
+	TokenEndpoint         string                   `cfg:"tokenEndpoint"`
+	LogoutEndpoint        string                   `cfg:"logoutEndpoint"`
+	LoginPath             string                   `cfg:"loginPath,default=/login"`
+	CallbackPath          string                   `cfg:"callbackPath,default=/callback"`
+	LogoutPath            string                   `cfg:"logoutPath,default=/logout"`
+	PostLogoutRedirectURL string                   `cfg:"postLogoutRedirectUrl"`
+
+	// SessionKeyFile or SessionKeyEnv holds the base64 encoded 32 byte key encrypting the session cookies.
+	SessionStore   string        `cfg:"sessionStore,default=cookie"`
+	SessionKeyFile string        `cfg:"sessionKeyFile"`
+	SessionKeyEnv  string        `cfg:"sessionKeyEnv"`
+	SessionTTL     time.Duration `cfg:"sessionTtl,default=24h"`
+	RefreshBefore  time.Duration `cfg:"refreshBefore,default=1m"`
+	CookieName     string        `cfg:"cookieName,default=gateway_session"`
+	CookieSecure   bool          `cfg:"cookieSecure,default=true"`
+}
+
+// Config holds the default issuer, plus any number of additional named issuers
+// which subrouters can opt into trusting.
 type Config struct {
-	Audience string `cfg:"audience,default=https://your-auth0-api.yourdomain.io"`
-	Domain   string `cfg:"domain,default=your-auth0-tenant.eu.auth0.com"`
+	IssuerConfig
+	Issuers    config_util.List[IssuerConfig] `cfg:"issuers"`
+	Revocation *RevocationConfig              `cfg:"revocation"`
+	BFF        *BFFConfig                     `cfg:"bff"`
 }
 
 func NewConfig(configSet *confiq.ConfigSet) (*Config, error) {
@@ -2,8 +2,10 @@ package auth0_test
 
 import (
 	"testing"
+	"time"
 
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
 	"github.com/greencoda/confiq"
 	yaml_loader "github.com/greencoda/confiq/loaders/yaml"
 	. "github.com/smartystreets/goconvey/convey"
@@ -15,8 +17,11 @@ func Test_NewConfig(t *testing.T) {
 			var (
 				configSet      = confiq.New()
 				expectedConfig = auth0_config.Config{
-					Audience: "https://test-api.example.com",
-					Domain:   "test-tenant.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:     "default",
+						Audience: "https://test-api.example.com",
+						Domain:   "test-tenant.auth0.com",
+					},
 				}
 			)
 
@@ -31,12 +36,167 @@ func Test_NewConfig(t *testing.T) {
 			So(*config, ShouldResemble, expectedConfig)
 		})
 
+		Convey("With OIDC issuer URL config set", func() {
+			var (
+				configSet      = confiq.New()
+				expectedConfig = auth0_config.Config{
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:      "default",
+						Audience:  "https://test-api.example.com",
+						Domain:    "your-auth0-tenant.eu.auth0.com",
+						IssuerURL: "https://keycloak.example.com/realms/test",
+						JWKS: &auth0_config.JWKSConfig{
+							CacheTTL:           10 * time.Minute,
+							MinRefreshInterval: 30 * time.Second,
+							Prefetch:           true,
+						},
+					},
+				}
+			)
+
+			err := configSet.Load(
+				yaml_loader.Load().FromFile("testdata/oidc_config.yaml"),
+			)
+			So(err, ShouldBeNil)
+
+			config, err := auth0_config.NewConfig(configSet)
+			So(err, ShouldBeNil)
+			So(config, ShouldNotBeNil)
+			So(*config, ShouldResemble, expectedConfig)
+		})
+
+		Convey("With multiple issuers config set", func() {
+			var (
+				configSet      = confiq.New()
+				expectedConfig = auth0_config.Config{
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:     "default",
+						Audience: "https://test-api.example.com",
+						Domain:   "test-tenant.auth0.com",
+					},
+					Issuers: config_util.List[auth0_config.IssuerConfig]{
+						{
+							Name:     "secondary",
+							Audience: "https://test-api.example.com",
+							Domain:   "secondary-tenant.auth0.com",
+						},
+						{
+							Name:      "keycloak",
+							Audience:  "gateway",
+							Domain:    "your-auth0-tenant.eu.auth0.com",
+							IssuerURL: "https://keycloak.example.com/realms/test",
+						},
+						{
+							Name:       "internal",
+							Audience:   "internal-api",
+							Domain:     "auth.internal.example.com",
+							Algorithms: config_util.List[string]{"ES256", "HS256"},
+							SecretEnv:  "INTERNAL_JWT_SECRET",
+						},
+						{
+							Name:     "opaque",
+							Audience: "opaque-api",
+							Domain:   "your-auth0-tenant.eu.auth0.com",
+							Introspection: &auth0_config.IntrospectionConfig{
+								Endpoint:        "https://auth.example.com/oauth2/introspect",
+								ClientID:        "gateway",
+								ClientSecretEnv: "INTROSPECTION_CLIENT_SECRET",
+								CacheTTL:        30 * time.Second,
+								Timeout:         5 * time.Second,
+							},
+						},
+					},
+				}
+			)
+
+			err := configSet.Load(
+				yaml_loader.Load().FromFile("testdata/multi_issuer_config.yaml"),
+			)
+			So(err, ShouldBeNil)
+
+			config, err := auth0_config.NewConfig(configSet)
+			So(err, ShouldBeNil)
+			So(config, ShouldNotBeNil)
+			So(*config, ShouldResemble, expectedConfig)
+		})
+
+		Convey("With revocation config set", func() {
+			var (
+				configSet      = confiq.New()
+				expectedConfig = auth0_config.Config{
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:     "default",
+						Audience: "https://test-api.example.com",
+						Domain:   "test-tenant.auth0.com",
+					},
+					Revocation: &auth0_config.RevocationConfig{
+						File:          "/var/lib/gateway/revocations.json",
+						PollInterval:  10 * time.Second,
+						AdminPath:     "/admin/revocations",
+						AdminTokenEnv: "REVOCATION_ADMIN_TOKEN",
+					},
+				}
+			)
+
+			err := configSet.Load(
+				yaml_loader.Load().FromFile("testdata/revocation_config.yaml"),
+			)
+			So(err, ShouldBeNil)
+
+			config, err := auth0_config.NewConfig(configSet)
+			So(err, ShouldBeNil)
+			So(config, ShouldNotBeNil)
+			So(*config, ShouldResemble, expectedConfig)
+		})
+
+		Convey("With BFF config set", func() {
+			var (
+				configSet      = confiq.New()
+				expectedConfig = auth0_config.Config{
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:     "default",
+						Audience: "https://test-api.example.com",
+						Domain:   "test-tenant.auth0.com",
+					},
+					BFF: &auth0_config.BFFConfig{
+						ClientID:              "test-client",
+						ClientSecretEnv:       "BFF_CLIENT_SECRET",
+						RedirectURL:           "https://gateway.example.com/callback",
+						Scopes:                []string{"openid", "profile", "offline_access"},
+						LoginPath:             "/login",
+						CallbackPath:          "/callback",
+						LogoutPath:            "/logout",
+						PostLogoutRedirectURL: "https://gateway.example.com/",
+						SessionStore:          auth0_config.SessionStoreCookie,
+						SessionKeyEnv:         "BFF_SESSION_KEY",
+						SessionTTL:            24 * time.Hour,
+						RefreshBefore:         time.Minute,
+						CookieName:            "gateway_session",
+						CookieSecure:          true,
+					},
+				}
+			)
+
+			err := configSet.Load(
+				yaml_loader.Load().FromFile("testdata/bff_config.yaml"),
+			)
+			So(err, ShouldBeNil)
+
+			config, err := auth0_config.NewConfig(configSet)
+			So(err, ShouldBeNil)
+			So(config, ShouldNotBeNil)
+			So(*config, ShouldResemble, expectedConfig)
+		})
+
 		Convey("With empty config, using default values", func() {
 			var (
 				configSet      = confiq.New()
 				expectedConfig = auth0_config.Config{
-					Audience: "https://your-auth0-api.yourdomain.io",
-					Domain:   "your-auth0-tenant.eu.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:     "default",
+						Audience: "https://your-auth0-api.yourdomain.io",
+						Domain:   "your-auth0-tenant.eu.auth0.com",
+					},
 				}
 			)
 
@@ -61,8 +221,11 @@ func Test_NewConfig(t *testing.T) {
 			var (
 				configSet      = confiq.New()
 				expectedConfig = auth0_config.Config{
-					Audience: "https://your-auth0-api.yourdomain.io",
-					Domain:   "your-auth0-tenant.eu.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Name:     "default",
+						Audience: "https://your-auth0-api.yourdomain.io",
+						Domain:   "your-auth0-tenant.eu.auth0.com",
+					},
 				}
 			)
 
@@ -16,6 +16,26 @@ type Config struct {
 	ReleaseStage   string        `cfg:"releaseStage,default=local"`
 	LogRequests    bool          `cfg:"logRequests,default=false"`
 	LogLevel       string        `cfg:"logLevel,default=info"`
+	TLS            *TLSConfig    `cfg:"tls"`
+
+	// VerboseErrorStages lists the release stages whose error responses explain why requests were rejected.
+	VerboseErrorStages config_util.List[string] `cfg:"verboseErrorStages"`
+
+	// StatsPath serves the hit and miss counts of the claims caches.
+	StatsPath string `cfg:"statsPath"`
+
+	// TrustedProxies lists the proxies in front of the gateway, as CIDR ranges or single addresses. Subrouters
+	// trusting forwarded headers only read the client IP from them on requests sent by one of these proxies.
+	TrustedProxies config_util.List[string] `cfg:"trustedProxies"`
+}
+
+// TLSConfig enables TLS on the listener. With a client CA bundle, client certificates are verified against it
+// whenever presented, and required on every connection if RequireClientCert is set.
+type TLSConfig struct {
+	CertFile          string `cfg:"certFile"`
+	KeyFile           string `cfg:"keyFile"`
+	ClientCAFile      string `cfg:"clientCaFile"`
+	RequireClientCert bool   `cfg:"requireClientCert,default=false"`
 }
 
 func NewConfig(configSet *confiq.ConfigSet) (*Config, error) {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
 	"github.com/greencoda/confiq"
 	yaml_loader "github.com/greencoda/confiq/loaders/yaml"
 	. "github.com/smartystreets/goconvey/convey"
@@ -24,6 +25,14 @@ func Test_NewConfig(t *testing.T) {
 					ReleaseStage:   "production",
 					LogRequests:    true,
 					LogLevel:       "debug",
+					TLS: &server_config.TLSConfig{
+						CertFile:     "/etc/gateway/tls/server.crt",
+						KeyFile:      "/etc/gateway/tls/server.key",
+						ClientCAFile: "/etc/gateway/tls/clients-ca.pem",
+					},
+					VerboseErrorStages: config_util.List[string]{"local", "staging"},
+					StatsPath:          "/debug/vars",
+					TrustedProxies:     config_util.List[string]{"172.16.0.0/12"},
 				}
 			)
 
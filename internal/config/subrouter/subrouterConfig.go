@@ -7,14 +7,134 @@ import (
 	"github.com/greencoda/confiq"
 )
 
+// Credential types a subrouter can accept.
+const (
+	CredentialsJWT    = "jwt"
+	CredentialsAPIKey = "apiKey"
+	CredentialsAny    = "any"
+)
+
+// Effects of policy rules.
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+)
+
+// Hash algorithms and encodings of webhook signatures.
+const (
+	SignatureAlgorithmSHA1   = "sha1"
+	SignatureAlgorithmSHA256 = "sha256"
+	SignatureAlgorithmSHA512 = "sha512"
+	SignatureEncodingHex     = "hex"
+	SignatureEncodingBase64  = "base64"
+)
+
+// Places a subrouter can read bearer tokens from.
+const (
+	TokenSourceHeader = "header"
+	TokenSourceCookie = "cookie"
+	TokenSourceQuery  = "query"
+)
+
 type AuthorizationConfig struct {
-	RequiredScopes []string `cfg:"requiredScopes"`
+	Issuers             config_util.List[string]          `cfg:"issuers"`
+	Audiences           config_util.List[string]          `cfg:"audiences"`
+	RequiredScopes      config_util.List[string]          `cfg:"requiredScopes"`
+	ScopeExpression     *ScopeExpressionConfig            `cfg:"scopeExpression"`
+	RequiredPermissions config_util.List[string]          `cfg:"requiredPermissions"`
+	ClaimRules          config_util.List[ClaimRuleConfig] `cfg:"claimRules"`
+	Rules               config_util.List[RuleConfig]      `cfg:"rules"`
+
+	// CredentialsOptional lets requests without a token through anonymously,
+	// while requests with a token are still rejected if it is invalid.
+	CredentialsOptional bool `cfg:"credentialsOptional,default=false"`
+
+	// Credentials selects whether requests authenticate with a JWT, an API key, or any of the two.
+	Credentials string        `cfg:"credentials,default=jwt"`
+	APIKey      *APIKeyConfig `cfg:"apiKey"`
+
+	// TokenSources lists where tokens are read from, in order. Without any, only the Authorization header is used.
+	TokenSources config_util.List[TokenSourceConfig] `cfg:"tokenSources"`
+
+	// Organization requires tokens issued for an Auth0 Organization.
+	Organization *OrganizationConfig `cfg:"organization"`
+
+	// DPoP accepts sender-constrained tokens presented with DPoP proofs.
+	DPoP *DPoPConfig `cfg:"dpop"`
+
+	// ClaimsCache keeps the claims of validated JWTs, so that tokens seen before aren't verified again.
+	ClaimsCache *ClaimsCacheConfig `cfg:"claimsCache"`
+}
+
+// ClaimsCacheConfig caches the claims of up to Size validated tokens, keyed by the hash of the token,
+// for up to TTL but never beyond the expiry of the token.
+type ClaimsCacheConfig struct {
+	Size int           `cfg:"size,default=10000"`
+	TTL  time.Duration `cfg:"ttl,default=5m"`
+}
+
+// DPoPConfig accepts DPoP-bound tokens (RFC 9449) along with the proof of possession of their key.
+type DPoPConfig struct {
+	Required        bool          `cfg:"required,default=false"`
+	MaxProofAge     time.Duration `cfg:"maxProofAge,default=1m"`
+	BaseURL         string        `cfg:"baseUrl"`
+	ReplayCacheSize int           `cfg:"replayCacheSize,default=100000"`
+}
+
+// OrganizationConfig requires tokens to carry the org_id claim of an Auth0 Organization.
+type OrganizationConfig struct {
+	AllowedIDs   config_util.List[string] `cfg:"allowedIds"`
+	AllowedNames config_util.List[string] `cfg:"allowedNames"`
+	Path         string                   `cfg:"path"`
+	Header       string                   `cfg:"header"`
+}
+
+// TokenSourceConfig names a place to read bearer tokens from: the Authorization header,
+// or the cookie or query parameter of the given name.
+type TokenSourceConfig struct {
+	Type string `cfg:"type,default=header"`
+	Name string `cfg:"name"`
+}
+
+// APIKeyConfig tells where requests carry their API key, and the file holding the hashes of the accepted keys.
+type APIKeyConfig struct {
+	Header         string `cfg:"header,default=X-API-Key"`
+	QueryParameter string `cfg:"queryParameter"`
+	KeysFile       string `cfg:"keysFile"`
+}
+
+// RuleConfig replaces the scope requirements of the subrouter for the requests matching its methods and
+// path pattern. Rules are tried in order, and requests matching none of them fall back to the subrouter's.
+type RuleConfig struct {
+	Methods         config_util.List[string] `cfg:"methods"`
+	Path            string                   `cfg:"path"`
+	RequiredScopes  config_util.List[string] `cfg:"requiredScopes"`
+	ScopeExpression *ScopeExpressionConfig   `cfg:"scopeExpression"`
+}
+
+// ScopeExpressionConfig is a boolean expression over the scopes of the token. Each node sets exactly one
+// of its fields: a single scope, a list of expressions that must all or any hold, or a negated expression.
+type ScopeExpressionConfig struct {
+	Scope string                                  `cfg:"scope"`
+	AllOf config_util.List[ScopeExpressionConfig] `cfg:"allOf"`
+	AnyOf config_util.List[ScopeExpressionConfig] `cfg:"anyOf"`
+	Not   *ScopeExpressionConfig                  `cfg:"not"`
+}
+
+// ClaimRuleConfig requires a claim of the token to exist, to equal a value,
+// or to contain a value when the claim is an array or a space-delimited string.
+type ClaimRuleConfig struct {
+	Claim    string `cfg:"claim"`
+	Operator string `cfg:"operator,default=equals"`
+	Value    string `cfg:"value"`
 }
 
 type RateLimitConfig struct {
 	Limit              int64         `cfg:"maxRequests"`
 	Period             time.Duration `cfg:"expiration"`
 	TrustForwardHeader bool          `cfg:"trustForwardHeader,default=false"`
+	// AnonymousLimit, if set, additionally limits the requests which weren't authenticated by any means.
+	AnonymousLimit int64 `cfg:"anonymousMaxRequests,default=0"`
 }
 
 type CORSConfig struct {
@@ -28,6 +148,72 @@ type CORSConfig struct {
 	Debug              bool     `cfg:"debug"`
 }
 
+// ClaimHeadersConfig lists the request headers set from the validated token claims before proxying.
+type ClaimHeadersConfig struct {
+	Headers config_util.List[ClaimHeaderConfig] `cfg:"headers"`
+}
+
+type ClaimHeaderConfig struct {
+	Name  string `cfg:"name"`
+	Claim string `cfg:"claim"`
+}
+
+// ClientCertConfig requires requests to present a client certificate verified by the listener.
+type ClientCertConfig struct {
+	AllowedCommonNames config_util.List[string] `cfg:"allowedCommonNames"`
+	AllowedSANs        config_util.List[string] `cfg:"allowedSans"`
+	CommonNameHeader   string                   `cfg:"commonNameHeader,default=X-Client-Cert-CN"`
+	SANsHeader         string                   `cfg:"sansHeader,default=X-Client-Cert-SANs"`
+	FingerprintHeader  string                   `cfg:"fingerprintHeader,default=X-Client-Cert-Fingerprint"`
+}
+
+// PolicyConfig authorizes requests with CEL rules, the first matching one of which decides the request.
+type PolicyConfig struct {
+	Rules              config_util.List[PolicyRuleConfig] `cfg:"rules"`
+	Default            string                             `cfg:"default,default=deny"`
+	TrustForwardHeader bool                               `cfg:"trustForwardHeader,default=false"`
+}
+
+type PolicyRuleConfig struct {
+	Name      string `cfg:"name"`
+	Condition string `cfg:"condition"`
+	Effect    string `cfg:"effect,default=allow"`
+}
+
+// ExtAuthzConfig asks an external authorization service to approve every request.
+type ExtAuthzConfig struct {
+	URL             string                   `cfg:"url"`
+	Timeout         time.Duration            `cfg:"timeout,default=2s"`
+	FailOpen        bool                     `cfg:"failOpen,default=false"`
+	Headers         config_util.List[string] `cfg:"headers"`
+	UpstreamHeaders config_util.List[string] `cfg:"upstreamHeaders"`
+	CacheTTL        time.Duration            `cfg:"cacheTtl,default=0s"`
+	CacheSize       int                      `cfg:"cacheSize,default=1000"`
+}
+
+// WebhookSignatureConfig verifies the HMAC signatures of webhook requests.
+type WebhookSignatureConfig struct {
+	Header          string        `cfg:"header"`
+	Prefix          string        `cfg:"prefix"`
+	SignatureKey    string        `cfg:"signatureKey"`
+	Algorithm       string        `cfg:"algorithm,default=sha256"`
+	Encoding        string        `cfg:"encoding,default=hex"`
+	SecretFile      string        `cfg:"secretFile"`
+	SecretEnv       string        `cfg:"secretEnv"`
+	PayloadTemplate string        `cfg:"payloadTemplate"`
+	TimestampHeader string        `cfg:"timestampHeader"`
+	TimestampKey    string        `cfg:"timestampKey"`
+	Tolerance       time.Duration `cfg:"tolerance,default=5m"`
+	MaxBodySize     int           `cfg:"maxBodySize,default=1048576"`
+}
+
+// IPFilterConfig restricts the clients of a subrouter by their IP address, with lists of CIDR ranges or addresses.
+type IPFilterConfig struct {
+	Allow              config_util.List[string] `cfg:"allow"`
+	Deny               config_util.List[string] `cfg:"deny"`
+	TrustForwardHeader bool                     `cfg:"trustForwardHeader,default=false"`
+}
+
 type SubrouterConfig struct {
 	Name                string               `cfg:"name"`
 	TargetURL           string               `cfg:"targetUrl"`
@@ -37,6 +223,17 @@ type SubrouterConfig struct {
 	RateLimitConfig     *RateLimitConfig     `cfg:"rateLimit"`
 	GZip                bool                 `cfg:"gzip,default=false"`
 	CORSConfig          *CORSConfig          `cfg:"corsConfig"`
+	ClaimHeadersConfig  *ClaimHeadersConfig  `cfg:"claimHeaders"`
+	ClientCertConfig    *ClientCertConfig    `cfg:"clientCert"`
+	PolicyConfig        *PolicyConfig        `cfg:"policy"`
+	ExtAuthzConfig      *ExtAuthzConfig      `cfg:"extAuthz"`
+	BFF                 bool                 `cfg:"bff,default=false"`
+
+	// WebhookSignatureConfig verifies webhook requests signed by third parties, which don't send tokens.
+	WebhookSignatureConfig *WebhookSignatureConfig `cfg:"webhookSignature"`
+
+	// IPFilterConfig rejects clients by their IP address before any other middleware of the subrouter runs.
+	IPFilterConfig *IPFilterConfig `cfg:"ipFilter"`
 }
 
 type Config []SubrouterConfig
@@ -2,8 +2,10 @@ package subrouter_test
 
 import (
 	"testing"
+	"time"
 
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
 	"github.com/greencoda/confiq"
 	yaml_loader "github.com/greencoda/confiq/loaders/yaml"
 	. "github.com/smartystreets/goconvey/convey"
@@ -27,7 +29,120 @@ func Test_NewConfig(t *testing.T) {
 						TargetURL:   "http://localhost:9090",
 						Prefix:      "/api/v2",
 						StripPrefix: false,
-						GZip:        false,
+						AuthorizationConfig: &subrouter_config.AuthorizationConfig{
+							Issuers:             config_util.List[string]{"default", "secondary"},
+							Audiences:           config_util.List[string]{"https://orders-api.example.com", "https://legacy-api.example.com"},
+							RequiredPermissions: config_util.List[string]{"read:orders"},
+							ScopeExpression: &subrouter_config.ScopeExpressionConfig{
+								AnyOf: config_util.List[subrouter_config.ScopeExpressionConfig]{
+									{
+										AllOf: config_util.List[subrouter_config.ScopeExpressionConfig]{
+											{Scope: "read:a"},
+											{Scope: "read:b"},
+										},
+									},
+									{Scope: "superuser"},
+									{Not: &subrouter_config.ScopeExpressionConfig{Scope: "blocked"}},
+								},
+							},
+							Rules: config_util.List[subrouter_config.RuleConfig]{
+								{
+									Path:           "/api/v2/admin/**",
+									RequiredScopes: config_util.List[string]{"admin:orders"},
+								},
+								{
+									Methods:        config_util.List[string]{"POST", "DELETE"},
+									RequiredScopes: config_util.List[string]{"write:orders"},
+								},
+							},
+							ClaimRules: config_util.List[subrouter_config.ClaimRuleConfig]{
+								{Claim: "org_id", Operator: "equals", Value: "acme"},
+								{Claim: "https://example.com/roles", Operator: "contains", Value: "admin"},
+							},
+							CredentialsOptional: true,
+							Credentials:         "any",
+							APIKey: &subrouter_config.APIKeyConfig{
+								Header:         "X-API-Key",
+								QueryParameter: "api_key",
+								KeysFile:       "/etc/gateway/api_keys.yaml",
+							},
+							TokenSources: config_util.List[subrouter_config.TokenSourceConfig]{
+								{Type: "header"},
+								{Type: "cookie", Name: "access_token"},
+								{Type: "query", Name: "access_token"},
+							},
+							DPoP: &subrouter_config.DPoPConfig{
+								Required:        true,
+								MaxProofAge:     30 * time.Second,
+								BaseURL:         "https://api.example.com",
+								ReplayCacheSize: 100000,
+							},
+							ClaimsCache: &subrouter_config.ClaimsCacheConfig{
+								Size: 10000,
+								TTL:  2 * time.Minute,
+							},
+							Organization: &subrouter_config.OrganizationConfig{
+								AllowedIDs:   config_util.List[string]{"org_acme"},
+								AllowedNames: config_util.List[string]{"globex"},
+								Path:         "/api/v2/orgs/{orgId}/**",
+								Header:       "X-Org-Id",
+							},
+						},
+						RateLimitConfig: &subrouter_config.RateLimitConfig{
+							Limit:          100,
+							Period:         time.Minute,
+							AnonymousLimit: 10,
+						},
+						PolicyConfig: &subrouter_config.PolicyConfig{
+							Rules: config_util.List[subrouter_config.PolicyRuleConfig]{
+								{Name: "admins", Condition: `"admin" in claims.roles`, Effect: "allow"},
+								{Name: "internal-network", Condition: `request.clientIp.startsWith("10.")`, Effect: "deny"},
+							},
+							Default: "deny",
+						},
+						ExtAuthzConfig: &subrouter_config.ExtAuthzConfig{
+							URL:             "http://entitlements.local/check",
+							Timeout:         500 * time.Millisecond,
+							Headers:         config_util.List[string]{"X-Tenant"},
+							UpstreamHeaders: config_util.List[string]{"X-Entitlements"},
+							CacheTTL:        30 * time.Second,
+							CacheSize:       1000,
+						},
+						ClaimHeadersConfig: &subrouter_config.ClaimHeadersConfig{
+							Headers: config_util.List[subrouter_config.ClaimHeaderConfig]{
+								{Name: "X-User-Id", Claim: "sub"},
+								{Name: "X-Org-Id", Claim: "org_id"},
+							},
+						},
+						ClientCertConfig: &subrouter_config.ClientCertConfig{
+							AllowedCommonNames: config_util.List[string]{"billing-service"},
+							AllowedSANs:        config_util.List[string]{"spiffe://example.com/billing"},
+							CommonNameHeader:   "X-Service-Name",
+							SANsHeader:         "X-Client-Cert-SANs",
+							FingerprintHeader:  "X-Client-Cert-Fingerprint",
+						},
+						GZip: false,
+					},
+					{
+						Name:      "Webhooks",
+						TargetURL: "http://localhost:9191",
+						Prefix:    "/webhooks/billing",
+						WebhookSignatureConfig: &subrouter_config.WebhookSignatureConfig{
+							Header:          "Stripe-Signature",
+							SignatureKey:    "v1",
+							Algorithm:       subrouter_config.SignatureAlgorithmSHA256,
+							Encoding:        subrouter_config.SignatureEncodingHex,
+							SecretEnv:       "BILLING_WEBHOOK_SECRET",
+							PayloadTemplate: "{{.Timestamp}}.{{.Body}}",
+							TimestampKey:    "t",
+							Tolerance:       3 * time.Minute,
+							MaxBodySize:     1048576,
+						},
+						IPFilterConfig: &subrouter_config.IPFilterConfig{
+							Allow:              config_util.List[string]{"3.18.12.63", "54.187.174.169/32"},
+							Deny:               config_util.List[string]{"10.0.0.0/8"},
+							TrustForwardHeader: true,
+						},
 					},
 				}
 			)
@@ -40,7 +155,7 @@ func Test_NewConfig(t *testing.T) {
 			config, err := subrouter_config.NewConfig(configSet)
 			So(err, ShouldBeNil)
 			So(config, ShouldNotBeNil)
-			So(*config, ShouldHaveLength, 2)
+			So(*config, ShouldHaveLength, 3)
 			So(*config, ShouldResemble, expectedConfig)
 		})
 
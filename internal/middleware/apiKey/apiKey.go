@@ -0,0 +1,97 @@
+package apiKey
+
+import (
+	"context"
+	"net/http"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+// IAPIKey interface defines the methods of the API key authentication middleware.
+type IAPIKey interface {
+	Handler() mux.MiddlewareFunc
+	HasAPIKey(req *http.Request) bool
+}
+
+// APIKey implements the IAPIKey interface and provides the API key authentication middleware.
+type APIKey struct {
+	middlewareFunc mux.MiddlewareFunc
+	config         subrouter_config.APIKeyConfig
+}
+
+// Handler returns the API key authentication middleware function.
+func (a *APIKey) Handler() mux.MiddlewareFunc {
+	return a.middlewareFunc
+}
+
+// HasAPIKey tells whether the request carries an API key, valid or not.
+func (a *APIKey) HasAPIKey(req *http.Request) bool {
+	return extractAPIKey(req, a.config) != ""
+}
+
+// buildAPIKeyMiddlewareFunc builds the middleware authenticating requests by their API key, storing the client
+// of the key in the request context as validated claims.
+func buildAPIKeyMiddlewareFunc(config subrouter_config.APIKeyConfig, apiKeyClients map[string]*validator.ValidatedClaims) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			apiKey := extractAPIKey(req, config)
+			if apiKey == "" {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusUnauthorized,
+					Code:       errorResponse_util.CodeMissingAPIKey,
+					Message:    "Missing API key.",
+				})
+
+				return
+			}
+
+			apiKeyClientClaims, isKnown := apiKeyClients[hashAPIKey(apiKey)]
+			if !isKnown {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusUnauthorized,
+					Code:       errorResponse_util.CodeInvalidAPIKey,
+					Message:    "Invalid API key.",
+				})
+
+				return
+			}
+
+			req = scrubAPIKey(req.Clone(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, apiKeyClientClaims)), config)
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// extractAPIKey reads the API key from the configured header, falling back to the query parameter if one is configured.
+func extractAPIKey(req *http.Request, config subrouter_config.APIKeyConfig) string {
+	if apiKey := req.Header.Get(config.Header); apiKey != "" {
+		return apiKey
+	}
+
+	if config.QueryParameter != "" {
+		return req.URL.Query().Get(config.QueryParameter)
+	}
+
+	return ""
+}
+
+// scrubAPIKey removes the API key from the request, so that it doesn't reach the upstream.
+func scrubAPIKey(req *http.Request, config subrouter_config.APIKeyConfig) *http.Request {
+	req.Header.Del(config.Header)
+
+	if config.QueryParameter != "" {
+		query := req.URL.Query()
+		if query.Has(config.QueryParameter) {
+			query.Del(config.QueryParameter)
+			req.URL.RawQuery = query.Encode()
+			req.RequestURI = req.URL.RequestURI()
+		}
+	}
+
+	return req
+}
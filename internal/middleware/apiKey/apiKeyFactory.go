@@ -0,0 +1,121 @@
+package apiKey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+)
+
+const apiKeysPrefix = "apiKeys"
+
+var (
+	ErrMissingKeysFile     = errors.New("an API keys file is required")
+	ErrMissingClientName   = errors.New("API key has no client name")
+	ErrInvalidAPIKeyHash   = errors.New("API key hash is not a hex encoded SHA-256 digest")
+	ErrDuplicateAPIKeyHash = errors.New("duplicate API key hash")
+)
+
+// apiKeyEntry is an accepted API key in the keys file, stored as the hex encoded SHA-256 digest of the key.
+type apiKeyEntry struct {
+	Client string                   `cfg:"client"`
+	Hash   string                   `cfg:"hash"`
+	Scopes config_util.List[string] `cfg:"scopes"`
+}
+
+type apiKeyEntries []apiKeyEntry
+
+// Factory interface for creating API key authentication middleware for subrouters.
+type IAPIKeyFactory interface {
+	NewAPIKey(config subrouter_config.APIKeyConfig) (IAPIKey, error)
+}
+
+// APIKeyFactory implements the IAPIKeyFactory interface to create API key authentication middleware.
+type APIKeyFactory struct{}
+
+// NewAPIKeyFactory creates a new API key middleware factory.
+func NewAPIKeyFactory() IAPIKeyFactory {
+	return &APIKeyFactory{}
+}
+
+// NewAPIKey creates a new API key authentication middleware accepting the keys listed in the configured keys file.
+func (a *APIKeyFactory) NewAPIKey(config subrouter_config.APIKeyConfig) (IAPIKey, error) {
+	apiKeyClients, err := loadAPIKeyClients(config.KeysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIKey{
+		middlewareFunc: buildAPIKeyMiddlewareFunc(config, apiKeyClients),
+		config:         config,
+	}, nil
+}
+
+// loadAPIKeyClients reads the keys file, mapping the hash of each key to the claims of its client.
+func loadAPIKeyClients(keysFile string) (map[string]*validator.ValidatedClaims, error) {
+	if keysFile == "" {
+		return nil, ErrMissingKeysFile
+	}
+
+	configSet, err := config_util.LoadConfigYAML(config_util.ConfigFilename(keysFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the API keys file: %w", err)
+	}
+
+	entries, err := config_util.LoadConfigFromSetWithPrefix[apiKeyEntries](configSet, apiKeysPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the API keys file: %w", err)
+	}
+
+	apiKeyClients := make(map[string]*validator.ValidatedClaims, len(*entries))
+
+	for _, entry := range *entries {
+		if entry.Client == "" {
+			return nil, ErrMissingClientName
+		}
+
+		hash := strings.ToLower(entry.Hash)
+
+		if digest, err := hex.DecodeString(hash); err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("%w: key of client '%s'", ErrInvalidAPIKeyHash, entry.Client)
+		}
+
+		if _, isDuplicate := apiKeyClients[hash]; isDuplicate {
+			return nil, fmt.Errorf("%w: key of client '%s'", ErrDuplicateAPIKeyHash, entry.Client)
+		}
+
+		apiKeyClients[hash] = newAPIKeyClientClaims(entry)
+	}
+
+	return apiKeyClients, nil
+}
+
+// newAPIKeyClientClaims describes the client of a key by the same claims as a token, with the client name as the subject.
+func newAPIKeyClientClaims(entry apiKeyEntry) *validator.ValidatedClaims {
+	scope := strings.Join(entry.Scopes, " ")
+
+	return &validator.ValidatedClaims{
+		RegisteredClaims: validator.RegisteredClaims{
+			Subject: entry.Client,
+		},
+		CustomClaims: &auth0_middleware.CustomAuth0Claims{
+			Scope: scope,
+			Claims: map[string]any{
+				"sub":   entry.Client,
+				"scope": scope,
+			},
+		},
+	}
+}
+
+func hashAPIKey(apiKey string) string {
+	digest := sha256.Sum256([]byte(apiKey))
+
+	return hex.EncodeToString(digest[:])
+}
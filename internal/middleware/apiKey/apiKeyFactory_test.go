@@ -0,0 +1,77 @@
+package apiKey_test
+
+import (
+	"testing"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/apiKey"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NewAPIKeyFactory(t *testing.T) {
+	Convey("When creating a new API key factory", t, func() {
+		factory := middleware.NewAPIKeyFactory()
+		So(factory, ShouldNotBeNil)
+		So(factory, ShouldImplement, (*middleware.IAPIKeyFactory)(nil))
+	})
+}
+
+func Test_APIKeyFactory_NewAPIKey(t *testing.T) {
+	Convey("When creating a new API key middleware", t, func() {
+		factory := middleware.NewAPIKeyFactory()
+
+		Convey("With a valid keys file", func() {
+			apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+				Header:   "X-API-Key",
+				KeysFile: "testdata/api_keys.yaml",
+			})
+			So(err, ShouldBeNil)
+			So(apiKey, ShouldImplement, (*middleware.IAPIKey)(nil))
+			So(apiKey.Handler(), ShouldNotBeNil)
+		})
+
+		Convey("Without a keys file", func() {
+			apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+				Header: "X-API-Key",
+			})
+			So(err, ShouldEqual, middleware.ErrMissingKeysFile)
+			So(apiKey, ShouldBeNil)
+		})
+
+		Convey("With a missing keys file", func() {
+			apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+				Header:   "X-API-Key",
+				KeysFile: "testdata/missing.yaml",
+			})
+			So(err, ShouldNotBeNil)
+			So(apiKey, ShouldBeNil)
+		})
+
+		Convey("With an invalid key hash", func() {
+			apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+				Header:   "X-API-Key",
+				KeysFile: "testdata/invalid_hash_keys.yaml",
+			})
+			So(err, ShouldWrap, middleware.ErrInvalidAPIKeyHash)
+			So(apiKey, ShouldBeNil)
+		})
+
+		Convey("With a duplicate key hash", func() {
+			apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+				Header:   "X-API-Key",
+				KeysFile: "testdata/duplicate_keys.yaml",
+			})
+			So(err, ShouldWrap, middleware.ErrDuplicateAPIKeyHash)
+			So(apiKey, ShouldBeNil)
+		})
+
+		Convey("With a key without a client name", func() {
+			apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+				Header:   "X-API-Key",
+				KeysFile: "testdata/nameless_keys.yaml",
+			})
+			So(err, ShouldEqual, middleware.ErrMissingClientName)
+			So(apiKey, ShouldBeNil)
+		})
+	})
+}
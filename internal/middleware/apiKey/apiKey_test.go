@@ -0,0 +1,124 @@
+package apiKey_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/apiKey"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_APIKey_Handler(t *testing.T) {
+	Convey("When using the API key handler", t, func() {
+		factory := middleware.NewAPIKeyFactory()
+
+		apiKey, err := factory.NewAPIKey(subrouter_config.APIKeyConfig{
+			Header:         "X-API-Key",
+			QueryParameter: "api_key",
+			KeysFile:       "testdata/api_keys.yaml",
+		})
+		So(err, ShouldBeNil)
+
+		var forwardedRequest *http.Request
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			forwardedRequest = req
+
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		wrappedHandler := apiKey.Handler()(testHandler)
+
+		Convey("Should accept a valid key in the header and expose its client", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-API-Key", "billing-key")
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+			So(forwardedRequest.Header.Get("X-API-Key"), ShouldBeEmpty)
+			So(req.Header.Get("X-API-Key"), ShouldEqual, "billing-key")
+
+			validatedClaims, isValidatedClaims := forwardedRequest.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+			So(isValidatedClaims, ShouldBeTrue)
+			So(validatedClaims.RegisteredClaims.Subject, ShouldEqual, "billing-service")
+
+			customClaims, isCustomAuth0Claims := validatedClaims.CustomClaims.(*auth0_middleware.CustomAuth0Claims)
+			So(isCustomAuth0Claims, ShouldBeTrue)
+			So(customClaims.HasAllScopes([]string{"read:invoices", "write:invoices"}), ShouldBeTrue)
+		})
+
+		Convey("Should accept a valid key in the query parameter and remove it", func() {
+			req := httptest.NewRequest("GET", "/test?api_key=reporting-key&page=2", nil)
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(forwardedRequest.URL.RawQuery, ShouldEqual, "page=2")
+			So(forwardedRequest.RequestURI, ShouldEqual, "/test?page=2")
+		})
+
+		Convey("Should reject a request without a key", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
+			So(recorder.Body.String(), ShouldEqual, `{"error":"missing_api_key","message":"Missing API key."}`)
+		})
+
+		Convey("Should reject an unknown key", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-API-Key", "unknown-key")
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_api_key","message":"Invalid API key."}`)
+		})
+
+		Convey("Should tell whether a request carries a key", func() {
+			withHeader := httptest.NewRequest("GET", "/test", nil)
+			withHeader.Header.Set("X-API-Key", "unknown-key")
+
+			So(apiKey.HasAPIKey(withHeader), ShouldBeTrue)
+			So(apiKey.HasAPIKey(httptest.NewRequest("GET", "/test?api_key=x", nil)), ShouldBeTrue)
+			So(apiKey.HasAPIKey(httptest.NewRequest("GET", "/test", nil)), ShouldBeFalse)
+		})
+
+		Convey("Should work with the scope validator", func() {
+			scopeValidator, err := auth0_middleware.NewAuth0ValidatorFactory().NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				RequiredScopes: []string{"write:invoices"},
+			})
+			So(err, ShouldBeNil)
+
+			scopedHandler := apiKey.Handler()(scopeValidator.Handler()(testHandler))
+
+			billingReq := httptest.NewRequest("GET", "/test", nil)
+			billingReq.Header.Set("X-API-Key", "billing-key")
+
+			billingRecorder := httptest.NewRecorder()
+			scopedHandler.ServeHTTP(billingRecorder, billingReq)
+			So(billingRecorder.Code, ShouldEqual, http.StatusOK)
+
+			reportingReq := httptest.NewRequest("GET", "/test", nil)
+			reportingReq.Header.Set("X-API-Key", "reporting-key")
+
+			reportingRecorder := httptest.NewRecorder()
+			scopedHandler.ServeHTTP(reportingRecorder, reportingReq)
+			So(reportingRecorder.Code, ShouldEqual, http.StatusForbidden)
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package auth0
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+// pathWildcardSegment matches any number of trailing path segments when it ends a rule's path pattern.
+const pathWildcardSegment = "**"
+
+// scopeRequirement combines the required scopes with the optional scope expression of a subrouter or a rule.
+type scopeRequirement struct {
+	requiredScopes []string
+	scopeEvaluator *scopeEvaluator
+}
+
+func newScopeRequirement(requiredScopes []string, scopeExpression *subrouter_config.ScopeExpressionConfig) (scopeRequirement, error) {
+	requirement := scopeRequirement{
+		requiredScopes: requiredScopes,
+	}
+
+	if scopeExpression != nil {
+		scopeEvaluator, err := newScopeEvaluator(*scopeExpression)
+		if err != nil {
+			return requirement, err
+		}
+
+		requirement.scopeEvaluator = scopeEvaluator
+	}
+
+	return requirement, nil
+}
+
+func (r scopeRequirement) isSatisfiedBy(claims *CustomAuth0Claims) bool {
+	return claims.HasAllScopes(r.requiredScopes) &&
+		(r.scopeEvaluator == nil || r.scopeEvaluator.evaluate(claims.Scope))
+}
+
+// hintedScopes lists the scopes and permissions which can satisfy the requirement, to be hinted to clients
+// lacking them. Scopes which are only required to be absent aren't hinted.
+func (r scopeRequirement) hintedScopes(requiredPermissions []string) []string {
+	candidates := slices.Clone(r.requiredScopes)
+
+	if r.scopeEvaluator != nil {
+		candidates = append(candidates, r.scopeEvaluator.expression.scopes()...)
+	}
+
+	hintedScopes := make([]string, 0, len(candidates)+len(requiredPermissions))
+
+	for _, scope := range append(candidates, requiredPermissions...) {
+		if !slices.Contains(hintedScopes, scope) {
+			hintedScopes = append(hintedScopes, scope)
+		}
+	}
+
+	return hintedScopes
+}
+
+type authorizationRule struct {
+	methods      []string
+	pathSegments []string
+	scopeRequirement
+}
+
+func newAuthorizationRules(configs []subrouter_config.RuleConfig) ([]authorizationRule, error) {
+	authorizationRules := make([]authorizationRule, 0, len(configs))
+
+	for _, config := range configs {
+		requirement, err := newScopeRequirement(config.RequiredScopes, config.ScopeExpression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule for path '%s': %w", config.Path, err)
+		}
+
+		var pathSegments []string
+
+		if config.Path != "" {
+			pathSegments = strings.Split(config.Path, "/")
+
+			for _, pathSegment := range pathSegments {
+				if _, err := path.Match(pathSegment, ""); err != nil {
+					return nil, fmt.Errorf("invalid rule for path '%s': %w", config.Path, err)
+				}
+			}
+		}
+
+		methods := make([]string, 0, len(config.Methods))
+		for _, method := range config.Methods {
+			methods = append(methods, strings.ToUpper(method))
+		}
+
+		authorizationRules = append(authorizationRules, authorizationRule{
+			methods:          methods,
+			pathSegments:     pathSegments,
+			scopeRequirement: requirement,
+		})
+	}
+
+	return authorizationRules, nil
+}
+
+func (r authorizationRule) matches(req *http.Request) bool {
+	if len(r.methods) > 0 && !slices.Contains(r.methods, req.Method) {
+		return false
+	}
+
+	if r.pathSegments == nil {
+		return true
+	}
+
+	requestPathSegments := strings.Split(req.URL.Path, "/")
+
+	for i, pathSegment := range r.pathSegments {
+		if pathSegment == pathWildcardSegment && i == len(r.pathSegments)-1 {
+			return true
+		}
+
+		if i >= len(requestPathSegments) {
+			return false
+		}
+
+		if isMatch, _ := path.Match(pathSegment, requestPathSegments[i]); !isMatch {
+			return false
+		}
+	}
+
+	return len(requestPathSegments) == len(r.pathSegments)
+}
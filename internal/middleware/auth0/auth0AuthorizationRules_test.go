@@ -0,0 +1,114 @@
+package auth0_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Auth0ScopeValidator_Rules(t *testing.T) {
+	Convey("When testing Auth0 scope validator with authorization rules", t, func() {
+		auth0ValidatorFactory := middleware.NewAuth0ValidatorFactory()
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+			RequiredScopes: []string{"read:x"},
+			Rules: []subrouter_config.RuleConfig{
+				{
+					Path:           "/api/admin/**",
+					RequiredScopes: []string{"admin:x"},
+				},
+				{
+					Methods:        []string{"post", "PUT", "DELETE"},
+					RequiredScopes: []string{"write:x"},
+				},
+				{
+					Methods: []string{"GET"},
+					Path:    "/api/items/*/history",
+					ScopeExpression: &subrouter_config.ScopeExpressionConfig{
+						AnyOf: []subrouter_config.ScopeExpressionConfig{
+							{Scope: "read:history"},
+							{Scope: "admin:x"},
+						},
+					},
+				},
+			},
+		})
+		So(err, ShouldBeNil)
+		So(validator, ShouldNotBeNil)
+
+		serve := func(method, target, scope string) int {
+			req := httptest.NewRequest(method, target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: &middleware.CustomAuth0Claims{
+					Scope: scope,
+				},
+			}))
+
+			recorder := httptest.NewRecorder()
+			validator.Handler()(testHandler).ServeHTTP(recorder, req)
+
+			return recorder.Code
+		}
+
+		Convey("Should fall back to the subrouter scopes when no rule matches", func() {
+			So(serve("GET", "/api/items", "read:x"), ShouldEqual, http.StatusOK)
+			So(serve("GET", "/api/items", "write:x"), ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("Should apply the scopes of a method rule", func() {
+			So(serve("POST", "/api/items", "write:x"), ShouldEqual, http.StatusOK)
+			So(serve("DELETE", "/api/items/1", "write:x"), ShouldEqual, http.StatusOK)
+			So(serve("POST", "/api/items", "read:x"), ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("Should apply the first matching rule only", func() {
+			So(serve("POST", "/api/admin/users", "admin:x"), ShouldEqual, http.StatusOK)
+			So(serve("POST", "/api/admin/users", "write:x"), ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("Should match any number of segments with a trailing wildcard", func() {
+			So(serve("GET", "/api/admin", "admin:x"), ShouldEqual, http.StatusOK)
+			So(serve("GET", "/api/admin/users/1/roles", "admin:x"), ShouldEqual, http.StatusOK)
+			So(serve("GET", "/api/admin/users/1/roles", "read:x"), ShouldEqual, http.StatusForbidden)
+			So(serve("GET", "/api/administrators", "read:x"), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should match a single segment with a wildcard", func() {
+			So(serve("GET", "/api/items/1/history", "read:history"), ShouldEqual, http.StatusOK)
+			So(serve("GET", "/api/items/1/history", "read:x"), ShouldEqual, http.StatusForbidden)
+			So(serve("GET", "/api/items/1/2/history", "read:x"), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should not create a validator with an invalid path pattern", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				Rules: []subrouter_config.RuleConfig{
+					{Path: "/api/[items", RequiredScopes: []string{"read:x"}},
+				},
+			})
+			So(validator, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should not create a validator with an invalid rule scope expression", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				Rules: []subrouter_config.RuleConfig{
+					{Path: "/api/items", ScopeExpression: &subrouter_config.ScopeExpressionConfig{}},
+				},
+			})
+			So(validator, ShouldBeNil)
+			So(err, ShouldWrap, middleware.ErrInvalidScopeExpression)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+const (
+	ClaimRuleOperatorEquals   = "equals"
+	ClaimRuleOperatorContains = "contains"
+	ClaimRuleOperatorExists   = "exists"
+)
+
+var (
+	ErrUnknownClaimRuleOperator = errors.New("unknown claim rule operator")
+	ErrMissingClaimRuleClaim    = errors.New("claim rule does not name a claim")
+)
+
+type claimRule subrouter_config.ClaimRuleConfig
+
+func newClaimRules(configs []subrouter_config.ClaimRuleConfig) ([]claimRule, error) {
+	claimRules := make([]claimRule, 0, len(configs))
+
+	for _, config := range configs {
+		if config.Claim == "" {
+			return nil, ErrMissingClaimRuleClaim
+		}
+
+		switch config.Operator {
+		case ClaimRuleOperatorEquals, ClaimRuleOperatorContains, ClaimRuleOperatorExists:
+			claimRules = append(claimRules, claimRule(config))
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownClaimRuleOperator, config.Operator)
+		}
+	}
+
+	return claimRules, nil
+}
+
+// check reports whether the claims satisfy the rule, and if not, the reason why.
+func (r claimRule) check(claims map[string]any) (string, bool) {
+	claimValue, isPresent := claims[r.Claim]
+	if !isPresent || claimValue == nil {
+		return fmt.Sprintf("claim '%s' is missing", r.Claim), false
+	}
+
+	switch r.Operator {
+	case ClaimRuleOperatorEquals:
+		if stringValue, isScalar := claimValueToString(claimValue); !isScalar || stringValue != r.Value {
+			return fmt.Sprintf("claim '%s' must equal '%s'", r.Claim, r.Value), false
+		}
+	case ClaimRuleOperatorContains:
+		if !claimValueContains(claimValue, r.Value) {
+			return fmt.Sprintf("claim '%s' must contain '%s'", r.Claim, r.Value), false
+		}
+	}
+
+	return "", true
+}
+
+func claimValueToString(claimValue any) (string, bool) {
+	switch typedValue := claimValue.(type) {
+	case string:
+		return typedValue, true
+	case bool:
+		return strconv.FormatBool(typedValue), true
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+func claimValueContains(claimValue any, expectedValue string) bool {
+	switch typedValue := claimValue.(type) {
+	case string:
+		return slices.Contains(strings.Fields(typedValue), expectedValue)
+	case []any:
+		return slices.ContainsFunc(typedValue, func(element any) bool {
+			stringValue, isScalar := claimValueToString(element)
+
+			return isScalar && stringValue == expectedValue
+		})
+	default:
+		return false
+	}
+}
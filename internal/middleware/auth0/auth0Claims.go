@@ -2,16 +2,64 @@ package auth0
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
 )
 
 type ICustomAuth0Claims interface {
 	Validate(context.Context) error
 	HasAllScopes([]string) bool
+	HasAllPermissions([]string) bool
 }
 
 type CustomAuth0Claims struct {
-	Scope string `json:"scope"`
+	Scope       string   `json:"scope"`
+	Permissions []string `json:"permissions"`
+
+	// Confirmation binds the token to a key, like the DPoP proof key of sender-constrained tokens.
+	Confirmation *Confirmation `json:"cnf"`
+
+	// Claims holds every claim of the token, including the ones decoded into the fields above.
+	Claims map[string]any `json:"-"`
+}
+
+// Confirmation is the confirmation claim of a token (RFC 7800).
+type Confirmation struct {
+	// JWKThumbprint is the SHA-256 thumbprint of the DPoP proof key the token is bound to (RFC 9449).
+	JWKThumbprint string `json:"jkt"`
+}
+
+func (c *CustomAuth0Claims) UnmarshalJSON(data []byte) error {
+	type customAuth0Claims CustomAuth0Claims
+
+	if err := json.Unmarshal(data, (*customAuth0Claims)(c)); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &c.Claims)
+}
+
+// CollectClaims merges the registered claims with every claim decoded into the custom Auth0 claims.
+func CollectClaims(validatedClaims *validator.ValidatedClaims) map[string]any {
+	claims := map[string]any{
+		"iss": validatedClaims.RegisteredClaims.Issuer,
+		"sub": validatedClaims.RegisteredClaims.Subject,
+		"jti": validatedClaims.RegisteredClaims.ID,
+	}
+
+	if customAuth0Claims, isCustomAuth0Claims := validatedClaims.CustomClaims.(*CustomAuth0Claims); isCustomAuth0Claims {
+		for name, value := range customAuth0Claims.Claims {
+			claims[name] = value
+		}
+
+		if _, isPresent := claims["scope"]; !isPresent && customAuth0Claims.Scope != "" {
+			claims["scope"] = customAuth0Claims.Scope
+		}
+	}
+
+	return claims
 }
 
 func (c CustomAuth0Claims) Validate(ctx context.Context) error {
@@ -29,6 +77,16 @@ func (c CustomAuth0Claims) HasAllScopes(expectedScopes []string) bool {
 	return true
 }
 
+func (c CustomAuth0Claims) HasAllPermissions(expectedPermissions []string) bool {
+	for _, expectedPermission := range expectedPermissions {
+		if !scopeInSlice(expectedPermission, c.Permissions) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func scopeInSlice(expectedScope string, scopes []string) bool {
 	for _, scope := range scopes {
 		if scope == expectedScope {
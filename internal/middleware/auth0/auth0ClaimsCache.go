@@ -0,0 +1,88 @@
+package auth0
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	cache_util "github.com/greencoda/auth0-api-gateway/internal/util/cache"
+)
+
+// claimsCacheStats counts the hits and misses of the claims caches of every subrouter. It isn't published among the
+// expvar variables, which would expose them along with the command line and memory stats of the gateway.
+var claimsCacheStats = new(expvar.Map).Init()
+
+// ClaimsCacheStatsHandler serves the hit and miss counts of the claims caches as JSON, under the claimsCache key.
+func ClaimsCacheStatsHandler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, _ *http.Request) {
+		responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(responseWriter, `{"claimsCache": %s}`, claimsCacheStats.String())
+	})
+}
+
+// claimsCache keeps the claims of validated tokens, keyed by the hash of the token, so that the signature of a token
+// is only verified the first time it is seen. Only successful validations are cached.
+type claimsCache struct {
+	entries *cache_util.LRU[string, *validator.ValidatedClaims]
+	ttl     time.Duration
+}
+
+func newClaimsCache(config *subrouter_config.ClaimsCacheConfig) *claimsCache {
+	if config == nil {
+		return nil
+	}
+
+	return &claimsCache{
+		entries: cache_util.NewLRU[string, *validator.ValidatedClaims](config.Size),
+		ttl:     config.TTL,
+	}
+}
+
+// wrap returns the token validation function looking tokens up in the cache before validating them.
+// Without a cache, the validation function is returned as it is.
+func (c *claimsCache) wrap(validateToken jwtmiddleware.ValidateToken) jwtmiddleware.ValidateToken {
+	if c == nil {
+		return validateToken
+	}
+
+	return func(ctx context.Context, token string) (interface{}, error) {
+		cacheKey := hashToken(token)
+
+		if validatedClaims, isCached := c.entries.Get(cacheKey); isCached {
+			claimsCacheStats.Add("hits", 1)
+
+			return validatedClaims, nil
+		}
+
+		claimsCacheStats.Add("misses", 1)
+
+		result, err := validateToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		if validatedClaims, isValidatedClaims := result.(*validator.ValidatedClaims); isValidatedClaims {
+			c.entries.Add(cacheKey, validatedClaims, c.expiresAt(validatedClaims))
+		}
+
+		return result, nil
+	}
+}
+
+// expiresAt returns when the cached claims expire: after the TTL of the cache, or when the token does if earlier.
+func (c *claimsCache) expiresAt(validatedClaims *validator.ValidatedClaims) time.Time {
+	expiresAt := time.Now().Add(c.ttl)
+
+	if validatedClaims.RegisteredClaims.Expiry != 0 {
+		if tokenExpiresAt := time.Unix(validatedClaims.RegisteredClaims.Expiry, 0); tokenExpiresAt.Before(expiresAt) {
+			return tokenExpiresAt
+		}
+	}
+
+	return expiresAt
+}
@@ -0,0 +1,111 @@
+package auth0_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	. "github.com/smartystreets/goconvey/convey"
+	jose "gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+func claimsCacheStat(name string) int64 {
+	recorder := httptest.NewRecorder()
+	middleware.ClaimsCacheStatsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	var stats map[string]map[string]int64
+	So(json.Unmarshal(recorder.Body.Bytes(), &stats), ShouldBeNil)
+
+	return stats["claimsCache"][name]
+}
+
+func Test_Auth0TokenValidator_ClaimsCache(t *testing.T) {
+	Convey("When creating a token validator caching claims", t, func() {
+		const testSecret = "test-signing-secret-of-sufficient-length"
+
+		t.Setenv("TEST_JWT_SECRET", testSecret)
+
+		signToken := func(secret string, expiry time.Time) string {
+			signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, nil)
+			So(err, ShouldBeNil)
+
+			token, err := jwt.Signed(signer).Claims(jwt.Claims{
+				Issuer:   "https://test-auth0.local/",
+				Audience: jwt.Audience{"https://test-api.local/"},
+				Subject:  "1234567890",
+				Expiry:   jwt.NewNumericDate(expiry),
+			}).CompactSerialize()
+			So(err, ShouldBeNil)
+
+			return token
+		}
+
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience:   "https://test-api.local/",
+				Domain:     "test-auth0.local",
+				Algorithms: []string{"HS256"},
+				SecretEnv:  "TEST_JWT_SECRET",
+			},
+		}
+
+		validator, err := middleware.NewAuth0ValidatorFactory().NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+			ClaimsCache: &subrouter_config.ClaimsCacheConfig{Size: 10, TTL: time.Minute},
+		})
+		So(err, ShouldBeNil)
+
+		handler := validator.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+		}))
+
+		serve := func(token string) int {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			return recorder.Code
+		}
+
+		hits, misses := claimsCacheStat("hits"), claimsCacheStat("misses")
+
+		Convey("Should validate a token once and serve its claims from the cache afterwards", func() {
+			token := signToken(testSecret, time.Now().Add(time.Hour))
+
+			So(serve(token), ShouldEqual, http.StatusOK)
+			So(serve(token), ShouldEqual, http.StatusOK)
+			So(serve(token), ShouldEqual, http.StatusOK)
+
+			So(claimsCacheStat("misses")-misses, ShouldEqual, 1)
+			So(claimsCacheStat("hits")-hits, ShouldEqual, 2)
+		})
+
+		Convey("Should not cache tokens failing validation", func() {
+			token := signToken("another-signing-secret-of-sufficient-length", time.Now().Add(time.Hour))
+
+			So(serve(token), ShouldEqual, http.StatusUnauthorized)
+			So(serve(token), ShouldEqual, http.StatusUnauthorized)
+
+			So(claimsCacheStat("misses")-misses, ShouldEqual, 2)
+			So(claimsCacheStat("hits")-hits, ShouldEqual, 0)
+		})
+
+		Convey("Should not cache claims beyond the expiry of the token", func() {
+			// The token has expired, but is still within the allowed clock skew of the validator.
+			token := signToken(testSecret, time.Now().Add(-10*time.Second))
+
+			So(serve(token), ShouldEqual, http.StatusOK)
+			So(serve(token), ShouldEqual, http.StatusOK)
+
+			So(claimsCacheStat("misses")-misses, ShouldEqual, 2)
+			So(claimsCacheStat("hits")-hits, ShouldEqual, 0)
+		})
+	})
+}
@@ -2,6 +2,7 @@ package auth0_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
@@ -229,3 +230,70 @@ func Test_CustomAuth0Claims_HasAllScopes(t *testing.T) {
 		})
 	})
 }
+
+func Test_CustomAuth0Claims_UnmarshalJSON(t *testing.T) {
+	Convey("When decoding custom Auth0 claims", t, func() {
+		var claims middleware.CustomAuth0Claims
+
+		err := json.Unmarshal([]byte(`{"scope":"read:all","permissions":["read:orders"],"org_id":"acme","email_verified":true,"https://example.com/roles":["admin"]}`), &claims)
+		So(err, ShouldBeNil)
+
+		Convey("Should decode the scope", func() {
+			So(claims.Scope, ShouldEqual, "read:all")
+		})
+
+		Convey("Should decode the permissions", func() {
+			So(claims.Permissions, ShouldResemble, []string{"read:orders"})
+		})
+
+		Convey("Should decode the confirmation", func() {
+			So(claims.Confirmation, ShouldBeNil)
+
+			err := json.Unmarshal([]byte(`{"cnf":{"jkt":"0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"}}`), &claims)
+			So(err, ShouldBeNil)
+			So(claims.Confirmation, ShouldResemble, &middleware.Confirmation{JWKThumbprint: "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"})
+		})
+
+		Convey("Should keep every claim", func() {
+			So(claims.Claims, ShouldResemble, map[string]any{
+				"scope":                     "read:all",
+				"permissions":               []any{"read:orders"},
+				"org_id":                    "acme",
+				"email_verified":            true,
+				"https://example.com/roles": []any{"admin"},
+			})
+		})
+
+		Convey("Should fail on invalid claims", func() {
+			err := json.Unmarshal([]byte(`{"scope":42}`), &claims)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func Test_CustomAuth0Claims_HasAllPermissions(t *testing.T) {
+	Convey("When checking if claims have all required permissions", t, func() {
+		claims := middleware.CustomAuth0Claims{
+			Scope:       "openid profile",
+			Permissions: []string{"read:orders", "write:orders"},
+		}
+
+		Convey("Should return true for existing permissions", func() {
+			So(claims.HasAllPermissions([]string{"read:orders"}), ShouldBeTrue)
+			So(claims.HasAllPermissions([]string{"read:orders", "write:orders"}), ShouldBeTrue)
+		})
+
+		Convey("Should return false for a non-existing permission", func() {
+			So(claims.HasAllPermissions([]string{"read:orders", "delete:orders"}), ShouldBeFalse)
+		})
+
+		Convey("Should not look at the scope claim", func() {
+			So(claims.HasAllPermissions([]string{"openid"}), ShouldBeFalse)
+		})
+
+		Convey("Should return true for an empty permission list", func() {
+			So(claims.HasAllPermissions(nil), ShouldBeTrue)
+			So(middleware.CustomAuth0Claims{}.HasAllPermissions([]string{}), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,221 @@
+package auth0
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	cache_util "github.com/greencoda/auth0-api-gateway/internal/util/cache"
+	jose "gopkg.in/go-jose/go-jose.v2"
+)
+
+const (
+	dpopScheme      = "DPoP"
+	dpopProofHeader = "DPoP"
+	dpopProofType   = "dpop+jwt"
+
+	// dpopClockSkew is how far in the future proofs may be issued, to allow for clients with clocks running ahead.
+	dpopClockSkew = time.Duration(5 * time.Second)
+)
+
+var (
+	ErrInvalidDPoPProof     = errors.New("invalid DPoP proof")
+	ErrDPoPBindingRequired  = errors.New("token must be DPoP-bound")
+	ErrDPoPSchemeRequired   = errors.New("DPoP-bound token must be presented with the DPoP scheme")
+	ErrUnboundDPoPToken     = errors.New("token presented with the DPoP scheme is not DPoP-bound")
+	ErrInvalidAuthorization = errors.New("authorization header format must be Bearer {token} or DPoP {token}")
+)
+
+// dpopProofAlgorithms are the signature algorithms accepted for DPoP proofs, which must be asymmetric.
+var dpopProofAlgorithms = []string{
+	string(jose.RS256), string(jose.RS384), string(jose.RS512),
+	string(jose.PS256), string(jose.PS384), string(jose.PS512),
+	string(jose.ES256), string(jose.ES384), string(jose.ES512),
+	string(jose.EdDSA),
+}
+
+type dpopProofClaims struct {
+	ID              string `json:"jti"`
+	Method          string `json:"htm"`
+	URL             string `json:"htu"`
+	IssuedAt        int64  `json:"iat"`
+	AccessTokenHash string `json:"ath"`
+}
+
+// dpopVerifier checks that DPoP-bound tokens are presented with a valid proof of possession of their key,
+// remembering the IDs of the proofs it accepted until they expire, so that they can't be replayed.
+type dpopVerifier struct {
+	config   subrouter_config.DPoPConfig
+	baseURL  *url.URL
+	proofIDs *cache_util.LRU[string, struct{}]
+}
+
+func newDPoPVerifier(config subrouter_config.DPoPConfig) (*dpopVerifier, error) {
+	verifier := &dpopVerifier{
+		config:   config,
+		proofIDs: cache_util.NewLRU[string, struct{}](config.ReplayCacheSize),
+	}
+
+	if config.BaseURL != "" {
+		baseURL, err := url.Parse(config.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the DPoP base URL: %w", err)
+		}
+
+		verifier.baseURL = baseURL
+	}
+
+	return verifier, nil
+}
+
+// dpopAuthHeaderTokenExtractor extracts tokens from the Authorization header with either the Bearer or the DPoP scheme.
+func dpopAuthHeaderTokenExtractor(req *http.Request) (string, error) {
+	authorizationHeader := req.Header.Get("Authorization")
+	if authorizationHeader == "" {
+		return "", nil
+	}
+
+	scheme, token, isSplit := strings.Cut(authorizationHeader, " ")
+	if !isSplit || (!strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, dpopScheme)) {
+		return "", ErrInvalidAuthorization
+	}
+
+	return token, nil
+}
+
+// verify checks the DPoP binding of the validated token of the request. Tokens presented with the DPoP scheme must be
+// DPoP-bound and come with a valid proof, while DPoP-bound tokens can't be presented in any other way.
+func (d *dpopVerifier) verify(req *http.Request, token string) error {
+	validatedClaims, isValidatedClaim := req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	if !isValidatedClaim {
+		return nil
+	}
+
+	var keyThumbprint string
+	if customAuth0Claims, isCustomAuth0Claims := validatedClaims.CustomClaims.(*CustomAuth0Claims); isCustomAuth0Claims && customAuth0Claims.Confirmation != nil {
+		keyThumbprint = customAuth0Claims.Confirmation.JWKThumbprint
+	}
+
+	scheme, headerToken, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	isDPoPScheme := strings.EqualFold(scheme, dpopScheme) && headerToken == token
+
+	switch {
+	case isDPoPScheme && keyThumbprint == "":
+		return ErrUnboundDPoPToken
+	case isDPoPScheme:
+		return d.verifyProof(req, token, keyThumbprint)
+	case keyThumbprint != "":
+		return ErrDPoPSchemeRequired
+	case d.config.Required:
+		return ErrDPoPBindingRequired
+	default:
+		return nil
+	}
+}
+
+// verifyProof checks the DPoP proof of the request: its signature by the key the token is bound to, the request
+// method and URL it names, its freshness, the hash of the token, and that it hasn't been used before.
+func (d *dpopVerifier) verifyProof(req *http.Request, token, keyThumbprint string) error {
+	proofs := req.Header.Values(dpopProofHeader)
+	if len(proofs) != 1 {
+		return fmt.Errorf("%w: exactly one proof is required", ErrInvalidDPoPProof)
+	}
+
+	proof, err := jose.ParseSigned(proofs[0])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDPoPProof, err)
+	}
+
+	if len(proof.Signatures) != 1 {
+		return fmt.Errorf("%w: exactly one signature is required", ErrInvalidDPoPProof)
+	}
+
+	header := proof.Signatures[0].Header
+
+	if proofType, _ := header.ExtraHeaders[jose.HeaderType].(string); proofType != dpopProofType {
+		return fmt.Errorf("%w: type must be %s", ErrInvalidDPoPProof, dpopProofType)
+	}
+
+	if !slices.Contains(dpopProofAlgorithms, header.Algorithm) {
+		return fmt.Errorf("%w: signature algorithm %s is not allowed", ErrInvalidDPoPProof, header.Algorithm)
+	}
+
+	if header.JSONWebKey == nil || !header.JSONWebKey.IsPublic() {
+		return fmt.Errorf("%w: a public key is required", ErrInvalidDPoPProof)
+	}
+
+	payload, err := proof.Verify(header.JSONWebKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDPoPProof, err)
+	}
+
+	thumbprint, err := header.JSONWebKey.Thumbprint(crypto.SHA256)
+	if err != nil || base64.RawURLEncoding.EncodeToString(thumbprint) != keyThumbprint {
+		return fmt.Errorf("%w: token is not bound to the proof key", ErrInvalidDPoPProof)
+	}
+
+	var claims dpopProofClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDPoPProof, err)
+	}
+
+	if claims.Method != req.Method {
+		return fmt.Errorf("%w: htm must be %s", ErrInvalidDPoPProof, req.Method)
+	}
+
+	if !d.matchesRequestURL(claims.URL, req) {
+		return fmt.Errorf("%w: htu must be the request URL", ErrInvalidDPoPProof)
+	}
+
+	tokenHash := sha256.Sum256([]byte(token))
+	if claims.AccessTokenHash != base64.RawURLEncoding.EncodeToString(tokenHash[:]) {
+		return fmt.Errorf("%w: ath must be the hash of the token", ErrInvalidDPoPProof)
+	}
+
+	issuedAt, now := time.Unix(claims.IssuedAt, 0), time.Now()
+	if issuedAt.Before(now.Add(-d.config.MaxProofAge)) || issuedAt.After(now.Add(dpopClockSkew)) {
+		return fmt.Errorf("%w: iat is not recent", ErrInvalidDPoPProof)
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("%w: jti is missing", ErrInvalidDPoPProof)
+	}
+
+	if !d.proofIDs.AddIfAbsent(keyThumbprint+":"+claims.ID, struct{}{}, issuedAt.Add(d.config.MaxProofAge+dpopClockSkew)) {
+		return fmt.Errorf("%w: proof has been used before", ErrInvalidDPoPProof)
+	}
+
+	return nil
+}
+
+// matchesRequestURL tells whether the htu claim of a proof is the URL of the request, ignoring its query and fragment.
+func (d *dpopVerifier) matchesRequestURL(htu string, req *http.Request) bool {
+	proofURL, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+
+	requestURL := &url.URL{Scheme: "http", Host: req.Host, Path: req.URL.Path}
+	if req.TLS != nil {
+		requestURL.Scheme = "https"
+	}
+
+	if d.baseURL != nil {
+		requestURL = d.baseURL.JoinPath(req.URL.Path)
+	}
+
+	return strings.EqualFold(proofURL.Scheme, requestURL.Scheme) &&
+		strings.EqualFold(proofURL.Host, requestURL.Host) &&
+		proofURL.EscapedPath() == requestURL.EscapedPath()
+}
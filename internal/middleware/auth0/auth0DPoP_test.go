@@ -0,0 +1,213 @@
+package auth0_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	. "github.com/smartystreets/goconvey/convey"
+	jose "gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+type dpopProof struct {
+	ID              string `json:"jti,omitempty"`
+	Method          string `json:"htm"`
+	URL             string `json:"htu"`
+	IssuedAt        int64  `json:"iat"`
+	AccessTokenHash string `json:"ath,omitempty"`
+}
+
+func Test_Auth0TokenValidator_DPoP(t *testing.T) {
+	Convey("When creating a token validator accepting DPoP-bound tokens", t, func() {
+		const testSecret = "test-signing-secret-of-sufficient-length"
+
+		t.Setenv("TEST_JWT_SECRET", testSecret)
+
+		proofKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		So(err, ShouldBeNil)
+
+		publicKey := jose.JSONWebKey{Key: &proofKey.PublicKey}
+		thumbprint, err := publicKey.Thumbprint(crypto.SHA256)
+		So(err, ShouldBeNil)
+
+		keyThumbprint := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+		signToken := func(jkt string) string {
+			signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(testSecret)}, nil)
+			So(err, ShouldBeNil)
+
+			builder := jwt.Signed(signer).Claims(jwt.Claims{
+				Issuer:   "https://test-auth0.local/",
+				Audience: jwt.Audience{"https://test-api.local/"},
+				Subject:  "1234567890",
+				Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			})
+
+			if jkt != "" {
+				builder = builder.Claims(map[string]any{"cnf": map[string]string{"jkt": jkt}})
+			}
+
+			token, err := builder.CompactSerialize()
+			So(err, ShouldBeNil)
+
+			return token
+		}
+
+		tokenHash := func(token string) string {
+			hash := sha256.Sum256([]byte(token))
+
+			return base64.RawURLEncoding.EncodeToString(hash[:])
+		}
+
+		signProof := func(key *ecdsa.PrivateKey, proofType string, proof dpopProof) string {
+			signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, &jose.SignerOptions{
+				EmbedJWK:     true,
+				ExtraHeaders: map[jose.HeaderKey]any{jose.HeaderType: proofType},
+			})
+			So(err, ShouldBeNil)
+
+			signedProof, err := jwt.Signed(signer).Claims(proof).CompactSerialize()
+			So(err, ShouldBeNil)
+
+			return signedProof
+		}
+
+		dpopConfig := subrouter_config.DPoPConfig{
+			MaxProofAge:     time.Minute,
+			ReplayCacheSize: 100,
+		}
+
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience:   "https://test-api.local/",
+				Domain:     "test-auth0.local",
+				Algorithms: []string{"HS256"},
+				SecretEnv:  "TEST_JWT_SECRET",
+			},
+		}
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		newHandler := func() http.Handler {
+			validator, err := middleware.NewAuth0ValidatorFactory().NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{DPoP: &dpopConfig})
+			So(err, ShouldBeNil)
+
+			return errorResponse_util.WithVerboseReasons(true)(validator.Handler()(testHandler))
+		}
+
+		serve := func(handler http.Handler, scheme, token, proof string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "https://api.example.com/orders?page=2", nil)
+			req.Header.Set("Authorization", scheme+" "+token)
+
+			if proof != "" {
+				req.Header.Set("DPoP", proof)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		boundToken := signToken(keyThumbprint)
+		validProof := func() dpopProof {
+			return dpopProof{
+				ID:              time.Now().Format(time.RFC3339Nano),
+				Method:          "POST",
+				URL:             "https://api.example.com/orders",
+				IssuedAt:        time.Now().Unix(),
+				AccessTokenHash: tokenHash(boundToken),
+			}
+		}
+
+		Convey("Should accept a bound token with a valid proof", func() {
+			recorder := serve(newHandler(), "DPoP", boundToken, signProof(proofKey, "dpop+jwt", validProof()))
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+		})
+
+		Convey("Should reject a replayed proof", func() {
+			handler := newHandler()
+			proof := signProof(proofKey, "dpop+jwt", validProof())
+
+			So(serve(handler, "DPoP", boundToken, proof).Code, ShouldEqual, http.StatusOK)
+
+			recorder := serve(handler, "DPoP", boundToken, proof)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `DPoP error="invalid_dpop_proof"`)
+			So(recorder.Body.String(), ShouldContainSubstring, "proof has been used before")
+		})
+
+		Convey("Should reject invalid proofs", func() {
+			otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			So(err, ShouldBeNil)
+
+			for reason, proof := range map[string]string{
+				"htm must be POST":                    signProof(proofKey, "dpop+jwt", func() dpopProof { p := validProof(); p.Method = "GET"; return p }()),
+				"htu must be the request URL":         signProof(proofKey, "dpop+jwt", func() dpopProof { p := validProof(); p.URL = "https://api.example.com/users"; return p }()),
+				"iat is not recent":                   signProof(proofKey, "dpop+jwt", func() dpopProof { p := validProof(); p.IssuedAt -= 120; return p }()),
+				"jti is missing":                      signProof(proofKey, "dpop+jwt", func() dpopProof { p := validProof(); p.ID = ""; return p }()),
+				"ath must be the hash of the token":   signProof(proofKey, "dpop+jwt", func() dpopProof { p := validProof(); p.AccessTokenHash = tokenHash("other"); return p }()),
+				"type must be dpop+jwt":               signProof(proofKey, "JWT", validProof()),
+				"token is not bound to the proof key": signProof(otherKey, "dpop+jwt", validProof()),
+			} {
+				recorder := serve(newHandler(), "DPoP", boundToken, proof)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, reason)
+			}
+		})
+
+		Convey("Should reject a bound token without a proof", func() {
+			recorder := serve(newHandler(), "DPoP", boundToken, "")
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldContainSubstring, "exactly one proof is required")
+		})
+
+		Convey("Should reject a bound token presented as a bearer token", func() {
+			recorder := serve(newHandler(), "Bearer", boundToken, "")
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrDPoPSchemeRequired.Error())
+		})
+
+		Convey("Should reject an unbound token presented with the DPoP scheme", func() {
+			recorder := serve(newHandler(), "DPoP", signToken(""), signProof(proofKey, "dpop+jwt", validProof()))
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrUnboundDPoPToken.Error())
+		})
+
+		Convey("Should accept unbound bearer tokens unless DPoP is required", func() {
+			So(serve(newHandler(), "Bearer", signToken(""), "").Code, ShouldEqual, http.StatusOK)
+
+			dpopConfig.Required = true
+
+			recorder := serve(newHandler(), "Bearer", signToken(""), "")
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrDPoPBindingRequired.Error())
+		})
+
+		Convey("Should match the proof URL against the base URL", func() {
+			dpopConfig.BaseURL = "https://gateway.example.com/api"
+
+			proof := validProof()
+			proof.URL = "https://gateway.example.com/api/orders"
+
+			recorder := serve(newHandler(), "DPoP", boundToken, signProof(proofKey, "dpop+jwt", proof))
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
@@ -0,0 +1,68 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	openIDConfigurationPath = ".well-known/openid-configuration"
+	discoveryTimeout        = time.Duration(10 * time.Second)
+)
+
+var (
+	ErrDiscoveryRequestFailed  = errors.New("openid configuration request failed")
+	ErrDiscoveryIncomplete     = errors.New("openid configuration is missing the issuer or jwks_uri")
+	ErrDiscoveryIssuerMismatch = errors.New("openid configuration names another issuer")
+)
+
+// OpenIDConfiguration holds the parts of the OpenID configuration of an issuer used by the gateway.
+type OpenIDConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	EndSessionEndpoint               string   `json:"end_session_endpoint"`
+}
+
+// FetchOpenIDConfiguration fetches the OpenID configuration of the issuer, which must name the issuer URL
+// it was fetched for exactly, as required by OpenID Connect Discovery (section 4.3).
+func FetchOpenIDConfiguration(ctx context.Context, httpClient *http.Client, issuerURL *url.URL) (*OpenIDConfiguration, error) {
+	discoveryURL := issuerURL.JoinPath(openIDConfigurationPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the openid configuration request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscoveryRequestFailed, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned HTTP %d", ErrDiscoveryRequestFailed, discoveryURL, res.StatusCode)
+	}
+
+	var configuration OpenIDConfiguration
+	if err := json.NewDecoder(res.Body).Decode(&configuration); err != nil {
+		return nil, fmt.Errorf("failed to decode the openid configuration: %w", err)
+	}
+
+	if configuration.Issuer == "" {
+		return nil, ErrDiscoveryIncomplete
+	}
+
+	if configuration.Issuer != issuerURL.String() {
+		return nil, fmt.Errorf("%w: expected '%s', got '%s'", ErrDiscoveryIssuerMismatch, issuerURL, configuration.Issuer)
+	}
+
+	return &configuration, nil
+}
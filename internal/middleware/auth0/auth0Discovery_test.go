@@ -0,0 +1,140 @@
+package auth0_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	"github.com/h2non/gock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Auth0TokenValidator_Discovery(t *testing.T) {
+	Convey("When creating a token validator for a generic OIDC issuer", t, func() {
+		defer gock.Off()
+
+		factory := middleware.NewAuth0ValidatorFactory()
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience:  "https://test-api.local/",
+				IssuerURL: "https://test-auth0.local/",
+			},
+		}
+
+		Convey("With a valid discovery document", func() {
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(200).
+				JSON(discoveryOpenIdConfig)
+
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/jwks.json").
+				Reply(200).
+				JSON(jwks)
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				responseWriter.WriteHeader(http.StatusOK)
+				_, _ = responseWriter.Write([]byte("success"))
+			})
+
+			wrappedHandler := validator.Handler()(testHandler)
+
+			Convey("Should accept a JWT signed by the discovered keys", func() {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+				recorder := httptest.NewRecorder()
+
+				wrappedHandler.ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(recorder.Body.String(), ShouldEqual, "success")
+			})
+
+			Convey("Should reject an invalid JWT", func() {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+invalidJWTToken)
+
+				recorder := httptest.NewRecorder()
+
+				wrappedHandler.ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+		})
+
+		Convey("When the discovery endpoint returns an error", func() {
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(404)
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrDiscoveryRequestFailed)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("When the discovery document is not valid JSON", func() {
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(200).
+				BodyString("not-json")
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldNotBeNil)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("When the discovery document has no jwks_uri", func() {
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(200).
+				JSON(map[string]any{"issuer": "https://test-auth0.local/"})
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrDiscoveryIncomplete)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("When the discovery document names another issuer", func() {
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(200).
+				JSON(map[string]any{
+					"issuer":   "https://attacker.local/",
+					"jwks_uri": "https://attacker.local/.well-known/jwks.json",
+				})
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrDiscoveryIssuerMismatch)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("When the issuer only advertises unsupported algorithms", func() {
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(200).
+				JSON(map[string]any{
+					"issuer":                                "https://test-auth0.local/",
+					"jwks_uri":                              "https://test-auth0.local/.well-known/jwks.json",
+					"id_token_signing_alg_values_supported": []string{"HS256"},
+				})
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrNoSupportedSignatureAlgorithm)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("With an unparseable issuer URL", func() {
+			config.IssuerURL = "https://invalid issuer with spaces"
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldNotBeNil)
+			So(validator, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,223 @@
+package auth0
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	cache_util "github.com/greencoda/auth0-api-gateway/internal/util/cache"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+const (
+	introspectionTokenTypeHint    = "access_token"
+	maxIntrospectionResponseBytes = 1 << 20
+	maxIntrospectionCacheEntries  = 10000
+	defaultIntrospectionTimeout   = time.Duration(5 * time.Second)
+)
+
+var (
+	ErrMissingIntrospectionEndpoint = errors.New("an introspection endpoint is required")
+	ErrMissingClientSecret          = errors.New("a client secret is required when a client id is set")
+	ErrIntrospectionRequestFailed   = errors.New("token introspection request failed")
+	ErrInactiveToken                = errors.New("token is not active")
+	ErrAudienceNotAccepted          = errors.New("token audience is not accepted")
+	ErrIssuerNotAccepted            = errors.New("token issuer is not accepted")
+	ErrTokenNotYetValid             = errors.New("token is not valid yet")
+)
+
+// introspectionResponse holds the registered claims of an RFC 7662 introspection response.
+type introspectionResponse struct {
+	Active    bool            `json:"active"`
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  jwt.Audience    `json:"aud"`
+	Expiry    jwt.NumericDate `json:"exp"`
+	NotBefore jwt.NumericDate `json:"nbf"`
+	IssuedAt  jwt.NumericDate `json:"iat"`
+	ID        string          `json:"jti"`
+}
+
+// tokenIntrospector validates opaque tokens at an introspection endpoint (RFC 7662), caching the results.
+// Inactive tokens are cached with nil claims.
+type tokenIntrospector struct {
+	endpoint     string
+	issuer       string
+	clientID     string
+	clientSecret string
+	cacheTTL     time.Duration
+	httpClient   *http.Client
+	cache        *cache_util.LRU[string, *validator.ValidatedClaims]
+}
+
+// newTokenIntrospector sets up the introspection of the tokens of an issuer. Unless the issuer is empty,
+// active tokens naming another issuer are rejected.
+func newTokenIntrospector(config auth0_config.IntrospectionConfig, issuer string) (*tokenIntrospector, error) {
+	if config.Endpoint == "" {
+		return nil, ErrMissingIntrospectionEndpoint
+	}
+
+	endpointURL, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the introspection endpoint: %w", err)
+	}
+
+	tokenIntrospector := &tokenIntrospector{
+		endpoint:   endpointURL.String(),
+		issuer:     issuer,
+		clientID:   config.ClientID,
+		cacheTTL:   config.CacheTTL,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		cache:      cache_util.NewLRU[string, *validator.ValidatedClaims](maxIntrospectionCacheEntries),
+	}
+
+	if config.Timeout <= 0 {
+		tokenIntrospector.httpClient.Timeout = defaultIntrospectionTimeout
+	}
+
+	if config.ClientID != "" {
+		clientSecret, err := config_util.ReadSecret(config.ClientSecretFile, config.ClientSecretEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(clientSecret) == 0 {
+			return nil, ErrMissingClientSecret
+		}
+
+		tokenIntrospector.clientSecret = string(clientSecret)
+	}
+
+	return tokenIntrospector, nil
+}
+
+// introspect returns the claims of an active token, or ErrInactiveToken.
+func (t *tokenIntrospector) introspect(ctx context.Context, token string) (*validator.ValidatedClaims, error) {
+	var (
+		cacheKey = hashToken(token)
+		now      = time.Now()
+	)
+
+	if claims, isCached := t.cache.Get(cacheKey); isCached {
+		if claims == nil {
+			return nil, ErrInactiveToken
+		}
+
+		return claims, nil
+	}
+
+	claims, err := t.requestIntrospection(ctx, token)
+	if err != nil && !errors.Is(err, ErrInactiveToken) {
+		return nil, err
+	}
+
+	if claims != nil && claims.RegisteredClaims.Expiry != 0 && !now.Before(time.Unix(claims.RegisteredClaims.Expiry, 0)) {
+		claims, err = nil, ErrInactiveToken
+	}
+
+	t.storeResult(cacheKey, claims, now)
+
+	return claims, err
+}
+
+func (t *tokenIntrospector) requestIntrospection(ctx context.Context, token string) (*validator.ValidatedClaims, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {introspectionTokenTypeHint},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the introspection request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if t.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(t.clientID), url.QueryEscape(t.clientSecret))
+	}
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIntrospectionRequestFailed, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: endpoint returned HTTP %d", ErrIntrospectionRequestFailed, res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxIntrospectionResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIntrospectionRequestFailed, err)
+	}
+
+	var response introspectionResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode the introspection response: %w", err)
+	}
+
+	if !response.Active {
+		return nil, ErrInactiveToken
+	}
+
+	if t.issuer != "" && response.Issuer != "" && response.Issuer != t.issuer {
+		return nil, fmt.Errorf("%w: %s", ErrIssuerNotAccepted, response.Issuer)
+	}
+
+	if response.NotBefore != 0 && time.Now().Add(allowedClockSkew).Before(response.NotBefore.Time()) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	var customClaims CustomAuth0Claims
+	if err := json.Unmarshal(body, &customClaims); err != nil {
+		return nil, fmt.Errorf("failed to decode the introspection response: %w", err)
+	}
+
+	return &validator.ValidatedClaims{
+		RegisteredClaims: validator.RegisteredClaims{
+			Issuer:    response.Issuer,
+			Subject:   response.Subject,
+			Audience:  response.Audience,
+			Expiry:    int64(response.Expiry),
+			NotBefore: int64(response.NotBefore),
+			IssuedAt:  int64(response.IssuedAt),
+			ID:        response.ID,
+		},
+		CustomClaims: &customClaims,
+	}, nil
+}
+
+func (t *tokenIntrospector) storeResult(cacheKey string, claims *validator.ValidatedClaims, now time.Time) {
+	if t.cacheTTL <= 0 {
+		return
+	}
+
+	expiresAt := now.Add(t.cacheTTL)
+	if claims != nil && claims.RegisteredClaims.Expiry != 0 {
+		if tokenExpiry := time.Unix(claims.RegisteredClaims.Expiry, 0); tokenExpiry.Before(expiresAt) {
+			expiresAt = tokenExpiry
+		}
+	}
+
+	t.cache.Add(cacheKey, claims, expiresAt)
+}
+
+// hashToken keys the cache by a digest of the token, so that the tokens themselves aren't kept in memory.
+func hashToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(digest[:])
+}
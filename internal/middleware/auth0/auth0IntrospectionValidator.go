@@ -0,0 +1,61 @@
+package auth0
+
+import (
+	"context"
+	"slices"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+// Auth0IntrospectionValidator is the IAuth0TokenValidator of issuers handing out opaque tokens,
+// which are validated at the issuer's introspection endpoint instead of being verified as JWTs.
+type Auth0IntrospectionValidator struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+func (a *Auth0IntrospectionValidator) Handler() mux.MiddlewareFunc {
+	return a.middlewareFunc
+}
+
+// buildIntrospectionMiddlewareFunc builds the middleware validating tokens by introspection.
+func buildIntrospectionMiddlewareFunc(tokenIssuer *tokenIssuer, authorizationConfig subrouter_config.AuthorizationConfig) (mux.MiddlewareFunc, error) {
+	audiences := []string(authorizationConfig.Audiences)
+
+	if len(audiences) == 0 && tokenIssuer.audience != "" {
+		audiences = []string{tokenIssuer.audience}
+	}
+
+	tokenSources, err := newTokenSources(authorizationConfig.TokenSources, authorizationConfig.DPoP)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenSources.wrap(jwtmiddleware.New(
+		buildIntrospectTokenFunc(tokenIssuer.introspector, audiences),
+		jwtmiddleware.WithErrorHandler(newTokenErrorHandler("Failed to introspect token.")),
+		jwtmiddleware.WithCredentialsOptional(authorizationConfig.CredentialsOptional),
+		jwtmiddleware.WithTokenExtractor(tokenSources.tokenExtractor),
+	).CheckJWT), nil
+}
+
+// buildIntrospectTokenFunc validates tokens at the introspection endpoint. Since the audience is optional in
+// introspection responses, it is only checked against the accepted audiences if the response includes one.
+func buildIntrospectTokenFunc(introspector *tokenIntrospector, audiences []string) jwtmiddleware.ValidateToken {
+	return func(ctx context.Context, token string) (interface{}, error) {
+		validatedClaims, err := introspector.introspect(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		tokenAudiences := validatedClaims.RegisteredClaims.Audience
+		if len(tokenAudiences) > 0 && !slices.ContainsFunc(tokenAudiences, func(tokenAudience string) bool {
+			return slices.Contains(audiences, tokenAudience)
+		}) {
+			return nil, ErrAudienceNotAccepted
+		}
+
+		return validatedClaims, nil
+	}
+}
@@ -0,0 +1,266 @@
+package auth0_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Auth0IntrospectionValidator(t *testing.T) {
+	Convey("When creating a token validator for an issuer using token introspection", t, func() {
+		var requestCount atomic.Int32
+
+		introspectionResponses := map[string]map[string]any{
+			"active-token": {
+				"active": true,
+				"iss":    "https://test-auth0.local/",
+				"sub":    "user-1",
+				"aud":    "https://test-api.local/",
+				"scope":  "read:all write:all",
+				"exp":    time.Now().Add(time.Hour).Unix(),
+				"org_id": "acme",
+			},
+			"expired-token": {
+				"active": true,
+				"sub":    "user-1",
+				"exp":    time.Now().Add(-time.Minute).Unix(),
+			},
+			"foreign-token": {
+				"active": true,
+				"sub":    "user-1",
+				"aud":    []string{"https://other-api.local/"},
+			},
+			"impostor-token": {
+				"active": true,
+				"iss":    "https://other-auth0.local/",
+				"sub":    "user-1",
+			},
+			"premature-token": {
+				"active": true,
+				"sub":    "user-1",
+				"nbf":    time.Now().Add(time.Hour).Unix(),
+			},
+		}
+
+		introspectionServer := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			requestCount.Add(1)
+
+			clientID, clientSecret, hasBasicAuth := req.BasicAuth()
+			if !hasBasicAuth || clientID != "gateway" || clientSecret != "s3cr3t" {
+				responseWriter.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			if req.Method != http.MethodPost || req.PostFormValue("token_type_hint") != "access_token" {
+				responseWriter.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			if req.PostFormValue("token") == "failing-token" {
+				responseWriter.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			response, isKnown := introspectionResponses[req.PostFormValue("token")]
+			if !isKnown {
+				response = map[string]any{"active": false}
+			}
+
+			responseWriter.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(responseWriter).Encode(response)
+		}))
+		defer introspectionServer.Close()
+
+		t.Setenv("TEST_INTROSPECTION_CLIENT_SECRET", "s3cr3t")
+
+		factory := middleware.NewAuth0ValidatorFactory()
+		introspectionConfig := &auth0_config.IntrospectionConfig{
+			Endpoint:        introspectionServer.URL,
+			ClientID:        "gateway",
+			ClientSecretEnv: "TEST_INTROSPECTION_CLIENT_SECRET",
+			CacheTTL:        time.Minute,
+			Timeout:         time.Second,
+		}
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Name:          "default",
+				Audience:      "https://test-api.local/",
+				Domain:        "your-auth0-tenant.eu.auth0.com",
+				IssuerURL:     "https://test-auth0.local/",
+				Introspection: introspectionConfig,
+			},
+		}
+
+		var validatedClaims *validator.ValidatedClaims
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			validatedClaims, _ = req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		serve := func(tokenValidator middleware.IAuth0TokenValidator, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			recorder := httptest.NewRecorder()
+			errorResponse_util.WithVerboseReasons(true)(tokenValidator.Handler()(testHandler)).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("With a valid introspection config", func() {
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+			So(tokenValidator, ShouldHaveSameTypeAs, &middleware.Auth0IntrospectionValidator{})
+
+			Convey("Should accept an active token and expose its claims", func() {
+				recorder := serve(tokenValidator, "active-token")
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(recorder.Body.String(), ShouldEqual, "success")
+				So(validatedClaims, ShouldNotBeNil)
+				So(validatedClaims.RegisteredClaims.Subject, ShouldEqual, "user-1")
+
+				customClaims, isCustomAuth0Claims := validatedClaims.CustomClaims.(*middleware.CustomAuth0Claims)
+				So(isCustomAuth0Claims, ShouldBeTrue)
+				So(customClaims.HasAllScopes([]string{"read:all", "write:all"}), ShouldBeTrue)
+				So(customClaims.Claims["org_id"], ShouldEqual, "acme")
+			})
+
+			Convey("Should cache introspection results", func() {
+				So(serve(tokenValidator, "active-token").Code, ShouldEqual, http.StatusOK)
+				So(serve(tokenValidator, "active-token").Code, ShouldEqual, http.StatusOK)
+				So(serve(tokenValidator, "unknown-token").Code, ShouldEqual, http.StatusUnauthorized)
+				So(serve(tokenValidator, "unknown-token").Code, ShouldEqual, http.StatusUnauthorized)
+				So(requestCount.Load(), ShouldEqual, 2)
+			})
+
+			Convey("Should not cache failed introspection requests", func() {
+				So(serve(tokenValidator, "failing-token").Code, ShouldEqual, http.StatusUnauthorized)
+				So(serve(tokenValidator, "failing-token").Code, ShouldEqual, http.StatusUnauthorized)
+				So(requestCount.Load(), ShouldEqual, 2)
+			})
+
+			Convey("Should reject an inactive token", func() {
+				recorder := serve(tokenValidator, "unknown-token")
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrInactiveToken.Error())
+			})
+
+			Convey("Should reject an expired token", func() {
+				recorder := serve(tokenValidator, "expired-token")
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrInactiveToken.Error())
+			})
+
+			Convey("Should reject a token issued for another audience", func() {
+				recorder := serve(tokenValidator, "foreign-token")
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrAudienceNotAccepted.Error())
+			})
+
+			Convey("Should reject a token of another issuer", func() {
+				recorder := serve(tokenValidator, "impostor-token")
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrIssuerNotAccepted.Error())
+			})
+
+			Convey("Should reject a token which isn't valid yet", func() {
+				recorder := serve(tokenValidator, "premature-token")
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrTokenNotYetValid.Error())
+			})
+		})
+
+		Convey("With an explicit introspection issuer", func() {
+			introspectionConfig.Issuer = "https://other-auth0.local/"
+
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			So(serve(tokenValidator, "impostor-token").Code, ShouldEqual, http.StatusOK)
+			So(serve(tokenValidator, "active-token").Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("Without an issuer url, as in the README example", func() {
+			config.IssuerURL = ""
+
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			Convey("Should accept active tokens of any issuer instead of checking them against the default domain", func() {
+				So(serve(tokenValidator, "active-token").Code, ShouldEqual, http.StatusOK)
+				So(serve(tokenValidator, "impostor-token").Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("With subrouter audiences", func() {
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+				Audiences: config_util.List[string]{"https://other-api.local/"},
+			})
+			So(err, ShouldBeNil)
+
+			So(serve(tokenValidator, "foreign-token").Code, ShouldEqual, http.StatusOK)
+			So(serve(tokenValidator, "active-token").Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("With wrong client credentials", func() {
+			t.Setenv("TEST_INTROSPECTION_CLIENT_SECRET", "wrong")
+
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			recorder := serve(tokenValidator, "active-token")
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrIntrospectionRequestFailed.Error())
+		})
+
+		Convey("Without an introspection endpoint", func() {
+			introspectionConfig.Endpoint = ""
+
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrMissingIntrospectionEndpoint)
+			So(tokenValidator, ShouldBeNil)
+		})
+
+		Convey("Without a client secret", func() {
+			introspectionConfig.ClientSecretEnv = "TEST_INTROSPECTION_MISSING_SECRET"
+
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrMissingClientSecret)
+			So(tokenValidator, ShouldBeNil)
+		})
+
+		Convey("When trusted alongside another issuer", func() {
+			config.Issuers = config_util.List[auth0_config.IssuerConfig]{
+				{
+					Name:          "other",
+					Audience:      "https://test-api.local/",
+					Introspection: introspectionConfig,
+				},
+			}
+
+			tokenValidator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+				Issuers: config_util.List[string]{"default", "other"},
+			})
+			So(err, ShouldWrap, middleware.ErrIntrospectionIssuerNotExclusive)
+			So(tokenValidator, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,309 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/auth0/go-jwt-middleware/v2/jwks"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+)
+
+var (
+	ErrNoSupportedSignatureAlgorithm = errors.New("issuer does not advertise a supported signature algorithm")
+	ErrUnsupportedSignatureAlgorithm = errors.New("unsupported signature algorithm")
+	ErrMissingSigningSecret          = errors.New("a signing secret is required for HMAC signature algorithms")
+	ErrUnknownIssuer                 = errors.New("unknown issuer")
+	ErrDuplicateIssuer               = errors.New("duplicate issuer name")
+)
+
+// discoverableSignatureAlgorithms lists the algorithms that can be verified with
+// an issuer's JWKS, in order of preference.
+var discoverableSignatureAlgorithms = []validator.SignatureAlgorithm{
+	validator.RS256,
+	validator.RS384,
+	validator.RS512,
+	validator.PS256,
+	validator.PS384,
+	validator.PS512,
+	validator.ES256,
+	validator.ES384,
+	validator.ES512,
+	validator.EdDSA,
+}
+
+// symmetricSignatureAlgorithms lists the algorithms that are verified with a shared secret
+// instead of the issuer's JWKS.
+var symmetricSignatureAlgorithms = []validator.SignatureAlgorithm{
+	validator.HS256,
+	validator.HS384,
+	validator.HS512,
+}
+
+type tokenIssuer struct {
+	name                string
+	audience            string
+	issuer              string
+	signatureAlgorithms []validator.SignatureAlgorithm
+	keyFunc             func(context.Context) (interface{}, error)
+	secret              []byte
+	introspector        *tokenIntrospector
+}
+
+func newTokenIssuer(config auth0_config.IssuerConfig) (*tokenIssuer, error) {
+	signatureAlgorithms, err := parseSignatureAlgorithms(config.Algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("invalid algorithms of issuer '%s': %w", config.Name, err)
+	}
+
+	if config.Introspection != nil {
+		// The domain isn't used, as its default would reject every token of other authorization servers.
+		issuer := config.Introspection.Issuer
+		if issuer == "" {
+			issuer = config.IssuerURL
+		}
+
+		introspector, err := newTokenIntrospector(*config.Introspection, issuer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid introspection config of issuer '%s': %w", config.Name, err)
+		}
+
+		return &tokenIssuer{
+			name:         config.Name,
+			audience:     config.Audience,
+			introspector: introspector,
+		}, nil
+	}
+
+	// Issuers with a static JWKS file aren't discovered, so that they work without network access.
+	if config.IssuerURL != "" && (config.JWKS == nil || config.JWKS.File == "") {
+		return discoverTokenIssuer(config, signatureAlgorithms)
+	}
+
+	if len(signatureAlgorithms) == 0 {
+		signatureAlgorithms = []validator.SignatureAlgorithm{validator.RS256}
+	}
+
+	rawIssuerURL := config.IssuerURL
+	if rawIssuerURL == "" {
+		rawIssuerURL = "https://" + config.Domain + "/"
+	}
+
+	issuerURL, err := url.Parse(rawIssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the url of issuer '%s': %w", config.Name, err)
+	}
+
+	tokenIssuer := &tokenIssuer{
+		name:                config.Name,
+		audience:            config.Audience,
+		issuer:              issuerURL.String(),
+		signatureAlgorithms: signatureAlgorithms,
+	}
+
+	if err := tokenIssuer.loadKeys(config, issuerURL); err != nil {
+		return nil, err
+	}
+
+	return tokenIssuer, nil
+}
+
+func discoverTokenIssuer(config auth0_config.IssuerConfig, signatureAlgorithms []validator.SignatureAlgorithm) (*tokenIssuer, error) {
+	issuerURL, err := url.Parse(config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the url of issuer '%s': %w", config.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	configuration, err := FetchOpenIDConfiguration(ctx, &http.Client{Timeout: discoveryTimeout}, issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover issuer '%s': %w", config.Name, err)
+	}
+
+	if configuration.JWKSURI == "" {
+		return nil, fmt.Errorf("failed to discover issuer '%s': %w", config.Name, ErrDiscoveryIncomplete)
+	}
+
+	jwksURI, err := url.Parse(configuration.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the jwks uri of issuer '%s': %w", config.Name, err)
+	}
+
+	if len(signatureAlgorithms) == 0 {
+		signatureAlgorithm, err := selectSignatureAlgorithm(configuration.IDTokenSigningAlgValuesSupported)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select a signature algorithm for issuer '%s': %w", config.Name, err)
+		}
+
+		signatureAlgorithms = []validator.SignatureAlgorithm{signatureAlgorithm}
+	}
+
+	tokenIssuer := &tokenIssuer{
+		name:                config.Name,
+		audience:            config.Audience,
+		issuer:              configuration.Issuer,
+		signatureAlgorithms: signatureAlgorithms,
+	}
+
+	if err := tokenIssuer.loadKeys(config, issuerURL, jwks.WithCustomJWKSURI(jwksURI)); err != nil {
+		return nil, err
+	}
+
+	return tokenIssuer, nil
+}
+
+// loadKeys sets up the JWKS provider for the asymmetric algorithms and loads the shared
+// secret for the HMAC algorithms of the issuer, only fetching what is actually needed.
+func (t *tokenIssuer) loadKeys(config auth0_config.IssuerConfig, issuerURL *url.URL, providerOptions ...jwks.ProviderOption) error {
+	if slices.ContainsFunc(t.signatureAlgorithms, isSymmetricSignatureAlgorithm) {
+		secret, err := loadSigningSecret(config)
+		if err != nil {
+			return fmt.Errorf("failed to load the signing secret of issuer '%s': %w", config.Name, err)
+		}
+
+		t.secret = secret
+	}
+
+	if slices.ContainsFunc(t.signatureAlgorithms, isAsymmetricSignatureAlgorithm) {
+		keyFunc, err := newKeyFunc(config, issuerURL, providerOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to load the jwks of issuer '%s': %w", config.Name, err)
+		}
+
+		t.keyFunc = keyFunc
+	}
+
+	return nil
+}
+
+// newKeyFunc sets up the function providing the JWKS of the issuer, either from a static file or from
+// the issuer through a key set cache, which is filled right away if the keys are to be prefetched.
+func newKeyFunc(config auth0_config.IssuerConfig, issuerURL *url.URL, providerOptions ...jwks.ProviderOption) (func(context.Context) (interface{}, error), error) {
+	jwksConfig := auth0_config.JWKSConfig{
+		CacheTTL:           jwtCacheTTL,
+		MinRefreshInterval: jwksMinRefreshInterval,
+	}
+
+	if config.JWKS != nil {
+		jwksConfig = *config.JWKS
+	}
+
+	if jwksConfig.File != "" {
+		keySet, err := readKeySetFile(jwksConfig.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(context.Context) (interface{}, error) {
+			return keySet, nil
+		}, nil
+	}
+
+	providerOptions = append(providerOptions, jwks.WithCustomClient(&http.Client{Timeout: discoveryTimeout}))
+
+	cache := newKeySetCache(jwks.NewProvider(issuerURL, providerOptions...).KeyFunc, jwksConfig.CacheTTL, jwksConfig.MinRefreshInterval)
+
+	if jwksConfig.Prefetch {
+		if err := cache.prefetch(); err != nil {
+			return nil, err
+		}
+	}
+
+	return cache.KeyFunc, nil
+}
+
+// keyFuncFor returns the function providing the verification key for the given algorithm.
+func (t *tokenIssuer) keyFuncFor(signatureAlgorithm validator.SignatureAlgorithm) func(context.Context) (interface{}, error) {
+	if isSymmetricSignatureAlgorithm(signatureAlgorithm) {
+		return func(context.Context) (interface{}, error) {
+			return t.secret, nil
+		}
+	}
+
+	return t.keyFunc
+}
+
+func selectSignatureAlgorithm(supportedAlgorithms []string) (validator.SignatureAlgorithm, error) {
+	if len(supportedAlgorithms) == 0 {
+		return validator.RS256, nil
+	}
+
+	for _, signatureAlgorithm := range discoverableSignatureAlgorithms {
+		if slices.Contains(supportedAlgorithms, string(signatureAlgorithm)) {
+			return signatureAlgorithm, nil
+		}
+	}
+
+	return "", ErrNoSupportedSignatureAlgorithm
+}
+
+func parseSignatureAlgorithms(algorithms []string) ([]validator.SignatureAlgorithm, error) {
+	signatureAlgorithms := make([]validator.SignatureAlgorithm, 0, len(algorithms))
+
+	for _, algorithm := range algorithms {
+		signatureAlgorithm := validator.SignatureAlgorithm(algorithm)
+
+		if !isSymmetricSignatureAlgorithm(signatureAlgorithm) && !isAsymmetricSignatureAlgorithm(signatureAlgorithm) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedSignatureAlgorithm, algorithm)
+		}
+
+		if !slices.Contains(signatureAlgorithms, signatureAlgorithm) {
+			signatureAlgorithms = append(signatureAlgorithms, signatureAlgorithm)
+		}
+	}
+
+	return signatureAlgorithms, nil
+}
+
+func isSymmetricSignatureAlgorithm(signatureAlgorithm validator.SignatureAlgorithm) bool {
+	return slices.Contains(symmetricSignatureAlgorithms, signatureAlgorithm)
+}
+
+func isAsymmetricSignatureAlgorithm(signatureAlgorithm validator.SignatureAlgorithm) bool {
+	return slices.Contains(discoverableSignatureAlgorithms, signatureAlgorithm)
+}
+
+// loadSigningSecret reads the HMAC secret of an issuer from a file or an environment variable.
+func loadSigningSecret(config auth0_config.IssuerConfig) ([]byte, error) {
+	secret, err := config_util.ReadSecret(config.SecretFile, config.SecretEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secret) == 0 {
+		return nil, ErrMissingSigningSecret
+	}
+
+	return secret, nil
+}
+
+// findIssuerConfig looks up an issuer by name among the default and the additional issuers.
+func findIssuerConfig(config auth0_config.Config, name string) (auth0_config.IssuerConfig, error) {
+	var (
+		issuerConfig auth0_config.IssuerConfig
+		matchCount   int
+	)
+
+	for _, candidate := range append([]auth0_config.IssuerConfig{config.IssuerConfig}, config.Issuers...) {
+		if candidate.Name == name {
+			issuerConfig = candidate
+			matchCount++
+		}
+	}
+
+	switch matchCount {
+	case 0:
+		return issuerConfig, fmt.Errorf("%w: %s", ErrUnknownIssuer, name)
+	case 1:
+		return issuerConfig, nil
+	default:
+		return issuerConfig, fmt.Errorf("%w: %s", ErrDuplicateIssuer, name)
+	}
+}
@@ -0,0 +1,165 @@
+package auth0
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/go-jose/go-jose.v2"
+)
+
+const (
+	jwksMinRefreshInterval = time.Duration(30 * time.Second)
+	jwksPrefetchTimeout    = time.Duration(10 * time.Second)
+	jwksFetchTimeout       = time.Duration(10 * time.Second)
+)
+
+var (
+	ErrEmptyJWKS       = errors.New("jwks contains no keys")
+	ErrJWKSUnavailable = errors.New("jwks is unavailable")
+)
+
+// keyIDContextKey is the context key of the kid header of the token being validated,
+// which tells the key set cache whether the cached keys can verify it.
+type keyIDContextKey struct{}
+
+// keySetCache caches the JWKS of an issuer, refetching it when a token is signed with an unknown key.
+// Concurrent requests share a single fetch.
+type keySetCache struct {
+	fetchKeySet        func(context.Context) (interface{}, error)
+	cacheTTL           time.Duration
+	minRefreshInterval time.Duration
+	fetches            singleflight.Group
+
+	mutex       sync.Mutex
+	keySet      *jose.JSONWebKeySet
+	expiresAt   time.Time
+	lastFetchAt time.Time
+	lastErr     error
+}
+
+func newKeySetCache(fetchKeySet func(context.Context) (interface{}, error), cacheTTL, minRefreshInterval time.Duration) *keySetCache {
+	return &keySetCache{
+		fetchKeySet:        fetchKeySet,
+		cacheTTL:           cacheTTL,
+		minRefreshInterval: minRefreshInterval,
+	}
+}
+
+// KeyFunc provides the cached key set to the validator, refetching it when needed.
+func (k *keySetCache) KeyFunc(ctx context.Context) (interface{}, error) {
+	keyID, _ := ctx.Value(keyIDContextKey{}).(string)
+
+	if needsRefresh, decidedAt := k.needsRefresh(keyID); needsRefresh {
+		select {
+		case <-k.fetches.DoChan("", func() (interface{}, error) {
+			k.refresh(decidedAt)
+
+			return nil, nil
+		}):
+		case <-ctx.Done():
+		}
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.keySet == nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWKSUnavailable, cmp.Or(k.lastErr, ctx.Err()))
+	}
+
+	return k.keySet, nil
+}
+
+// needsRefresh tells whether the key set has to be fetched to verify a token signed with the key,
+// and when that was decided.
+func (k *keySetCache) needsRefresh(keyID string) (bool, time.Time) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	now := time.Now()
+
+	switch {
+	case k.keySet == nil || now.After(k.expiresAt):
+		return k.lastErr == nil || k.canRefetch(now), now
+	case keyID != "" && len(k.keySet.Key(keyID)) == 0:
+		return k.canRefetch(now), now
+	default:
+		return false, now
+	}
+}
+
+// canRefetch tells whether enough time has passed since the last fetch to fetch the key set again.
+func (k *keySetCache) canRefetch(now time.Time) bool {
+	return now.Sub(k.lastFetchAt) >= k.minRefreshInterval
+}
+
+// refresh fetches the key set, keeping the cached one if the fetch fails. The fetch is skipped if another one
+// started after the refresh was decided, as its result is just as recent.
+func (k *keySetCache) refresh(decidedAt time.Time) {
+	k.mutex.Lock()
+	if k.lastFetchAt.After(decidedAt) {
+		k.mutex.Unlock()
+
+		return
+	}
+
+	fetchedAt := time.Now()
+	k.lastFetchAt = fetchedAt
+	k.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+
+	keySet, err := k.fetchKeySet(ctx)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if err == nil {
+		if jsonWebKeySet, ok := keySet.(*jose.JSONWebKeySet); ok && len(jsonWebKeySet.Keys) > 0 {
+			k.keySet, k.expiresAt, k.lastErr = jsonWebKeySet, fetchedAt.Add(k.cacheTTL), nil
+
+			return
+		}
+
+		err = ErrEmptyJWKS
+	}
+
+	k.lastErr = err
+}
+
+// prefetch loads the key set ahead of the first request, failing if no keys could be loaded.
+func (k *keySetCache) prefetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksPrefetchTimeout)
+	defer cancel()
+
+	_, err := k.KeyFunc(ctx)
+
+	return err
+}
+
+// readKeySetFile reads a static JWKS from a file.
+func readKeySetFile(path string) (*jose.JSONWebKeySet, error) {
+	fileContents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the jwks file: %w", err)
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(fileContents, &keySet); err != nil {
+		return nil, fmt.Errorf("failed to decode the jwks file: %w", err)
+	}
+
+	if len(keySet.Keys) == 0 {
+		return nil, ErrEmptyJWKS
+	}
+
+	return &keySet, nil
+}
@@ -0,0 +1,226 @@
+package auth0_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	"github.com/h2non/gock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func mockJWKSEndpoints() {
+	gock.New("https://test-auth0.local").
+		Get("/.well-known/openid-configuration").
+		Reply(200).
+		JSON(openIdConfig)
+
+	gock.New("https://test-auth0.local").
+		Get("/.well-known/jwks.json").
+		Reply(200).
+		JSON(jwks)
+}
+
+func serveToken(validator middleware.IAuth0TokenValidator, token string) int {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	validator.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		responseWriter.WriteHeader(http.StatusOK)
+	})).ServeHTTP(recorder, req)
+
+	return recorder.Code
+}
+
+func Test_Auth0TokenValidator_JWKS(t *testing.T) {
+	Convey("When creating a token validator with a JWKS config", t, func() {
+		defer gock.Off()
+
+		factory := middleware.NewAuth0ValidatorFactory()
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience: "https://test-api.local/",
+				Domain:   "test-auth0.local",
+			},
+		}
+
+		Convey("With a static JWKS file", func() {
+			gock.New("https://test-auth0.local").Reply(500)
+
+			config.JWKS = &auth0_config.JWKSConfig{File: "testdata/mock_jwks.json"}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			Convey("Should verify tokens without contacting the issuer", func() {
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+				So(serveToken(validator, invalidJWTToken), ShouldEqual, http.StatusUnauthorized)
+				So(gock.IsPending(), ShouldBeTrue)
+			})
+		})
+
+		Convey("With a static JWKS file and an issuer URL", func() {
+			config.IssuerURL = "https://test-auth0.local/"
+			config.JWKS = &auth0_config.JWKSConfig{File: "testdata/mock_jwks.json"}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			Convey("Should skip the discovery", func() {
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("With a missing JWKS file", func() {
+			config.JWKS = &auth0_config.JWKSConfig{File: "testdata/missing_jwks.json"}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldNotBeNil)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("With a JWKS file without keys", func() {
+			config.JWKS = &auth0_config.JWKSConfig{File: "testdata/empty_jwks.json"}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrEmptyJWKS)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("With prefetching enabled", func() {
+			config.JWKS = &auth0_config.JWKSConfig{CacheTTL: time.Hour, MinRefreshInterval: time.Hour, Prefetch: true}
+
+			Convey("Should load the keys at startup", func() {
+				mockJWKSEndpoints()
+
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+				So(err, ShouldBeNil)
+				So(gock.IsDone(), ShouldBeTrue)
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should fail if the keys can't be loaded", func() {
+				gock.New("https://test-auth0.local").
+					Get("/.well-known/openid-configuration").
+					Reply(503)
+
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+				So(err, ShouldWrap, middleware.ErrJWKSUnavailable)
+				So(validator, ShouldBeNil)
+			})
+		})
+
+		Convey("With a token signed by an unknown key", func() {
+			Convey("Should refetch the keys once the minimum refresh interval has passed", func() {
+				config.JWKS = &auth0_config.JWKSConfig{CacheTTL: time.Hour, MinRefreshInterval: 0}
+
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+				So(err, ShouldBeNil)
+
+				mockJWKSEndpoints()
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+
+				mockJWKSEndpoints()
+				So(serveToken(validator, invalidJWTToken), ShouldEqual, http.StatusUnauthorized)
+				So(gock.IsDone(), ShouldBeTrue)
+			})
+
+			Convey("Should not refetch the keys within the minimum refresh interval", func() {
+				config.JWKS = &auth0_config.JWKSConfig{CacheTTL: time.Hour, MinRefreshInterval: time.Hour}
+
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+				So(err, ShouldBeNil)
+
+				mockJWKSEndpoints()
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+
+				mockJWKSEndpoints()
+				So(serveToken(validator, invalidJWTToken), ShouldEqual, http.StatusUnauthorized)
+				So(gock.IsPending(), ShouldBeTrue)
+			})
+		})
+
+		Convey("With the keys being fetched slowly", func() {
+			config.JWKS = &auth0_config.JWKSConfig{CacheTTL: time.Hour, MinRefreshInterval: 0}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(200).
+				Delay(100 * time.Millisecond).
+				JSON(openIdConfig)
+
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/jwks.json").
+				Reply(200).
+				JSON(jwks)
+
+			Convey("Should share a single fetch between concurrent requests", func() {
+				var (
+					waitGroup sync.WaitGroup
+					codes     = make([]int, 5)
+				)
+
+				for i := range codes {
+					waitGroup.Add(1)
+
+					go func() {
+						defer waitGroup.Done()
+
+						codes[i] = serveToken(validator, validJWTToken)
+					}()
+				}
+
+				waitGroup.Wait()
+
+				So(codes, ShouldResemble, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK})
+				So(gock.IsDone(), ShouldBeTrue)
+			})
+
+			Convey("Should finish a fetch the request has stopped waiting for", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+
+				req := httptest.NewRequest("GET", "/test", nil).WithContext(ctx)
+				req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+				recorder := httptest.NewRecorder()
+				validator.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+					responseWriter.WriteHeader(http.StatusOK)
+				})).ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+				So(gock.IsDone(), ShouldBeTrue)
+			})
+		})
+
+		Convey("With expired keys and an unavailable issuer", func() {
+			config.JWKS = &auth0_config.JWKSConfig{CacheTTL: time.Nanosecond, MinRefreshInterval: time.Hour}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+
+			mockJWKSEndpoints()
+			So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+
+			gock.New("https://test-auth0.local").
+				Get("/.well-known/openid-configuration").
+				Reply(503)
+
+			Convey("Should keep verifying tokens with the stale keys", func() {
+				So(serveToken(validator, validJWTToken), ShouldEqual, http.StatusOK)
+				So(gock.IsDone(), ShouldBeTrue)
+			})
+		})
+	})
+}
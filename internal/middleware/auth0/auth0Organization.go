@@ -0,0 +1,92 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+const (
+	// organizationPathSegment is the segment of an organization path pattern holding the organization ID.
+	organizationPathSegment = "{orgId}"
+
+	organizationIDClaim   = "org_id"
+	organizationNameClaim = "org_name"
+)
+
+var ErrInvalidOrganizationPath = errors.New("organization path must have exactly one '{orgId}' segment")
+
+// organizationRule requires the token to be issued for an allowed Auth0 Organization,
+// which must also be the organization addressed by the request path or header.
+type organizationRule struct {
+	allowedIDs   []string
+	allowedNames []string
+	pathRule     *authorizationRule
+	pathIndex    int
+	header       string
+}
+
+func newOrganizationRule(config *subrouter_config.OrganizationConfig) (*organizationRule, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	rule := &organizationRule{
+		allowedIDs:   config.AllowedIDs,
+		allowedNames: config.AllowedNames,
+		header:       config.Header,
+	}
+
+	if config.Path != "" {
+		pathSegments := strings.Split(config.Path, "/")
+
+		rule.pathIndex = slices.Index(pathSegments, organizationPathSegment)
+		if rule.pathIndex < 0 || slices.Contains(pathSegments[rule.pathIndex+1:], organizationPathSegment) {
+			return nil, fmt.Errorf("%w: '%s'", ErrInvalidOrganizationPath, config.Path)
+		}
+
+		pathSegments[rule.pathIndex] = "*"
+
+		pathRules, err := newAuthorizationRules([]subrouter_config.RuleConfig{{Path: strings.Join(pathSegments, "/")}})
+		if err != nil {
+			return nil, fmt.Errorf("invalid organization path '%s': %w", config.Path, err)
+		}
+
+		rule.pathRule = &pathRules[0]
+	}
+
+	return rule, nil
+}
+
+// check reports whether the organization of the token is allowed to make the request, and if not, the reason why.
+// Requests with paths not matching the organization path pattern are not checked against it.
+func (r *organizationRule) check(req *http.Request, claims map[string]any) (string, bool) {
+	organizationID, _ := claims[organizationIDClaim].(string)
+	if organizationID == "" {
+		return fmt.Sprintf("claim '%s' is missing", organizationIDClaim), false
+	}
+
+	if len(r.allowedIDs) > 0 || len(r.allowedNames) > 0 {
+		organizationName, _ := claims[organizationNameClaim].(string)
+
+		if !slices.Contains(r.allowedIDs, organizationID) && (organizationName == "" || !slices.Contains(r.allowedNames, organizationName)) {
+			return fmt.Sprintf("organization '%s' is not allowed", organizationID), false
+		}
+	}
+
+	if r.pathRule != nil && r.pathRule.matches(req) {
+		if strings.Split(req.URL.Path, "/")[r.pathIndex] != organizationID {
+			return fmt.Sprintf("path must address organization '%s'", organizationID), false
+		}
+	}
+
+	if r.header != "" && req.Header.Get(r.header) != organizationID {
+		return fmt.Sprintf("header '%s' must equal organization '%s'", r.header, organizationID), false
+	}
+
+	return "", true
+}
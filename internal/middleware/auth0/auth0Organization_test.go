@@ -0,0 +1,134 @@
+package auth0_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Auth0ScopeValidator_Organization(t *testing.T) {
+	Convey("When testing Auth0 scope validator organizations", t, func() {
+		auth0ValidatorFactory := middleware.NewAuth0ValidatorFactory()
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		organizationConfig := subrouter_config.OrganizationConfig{}
+
+		serve := func(req *http.Request, claims map[string]any) *httptest.ResponseRecorder {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				Organization: &organizationConfig,
+			})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: &middleware.CustomAuth0Claims{Claims: claims},
+			}))
+
+			recorder := httptest.NewRecorder()
+			errorResponse_util.WithVerboseReasons(true)(validator.Handler()(testHandler)).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		acmeClaims := map[string]any{"org_id": "org_acme", "org_name": "acme"}
+
+		Convey("Should allow access with an organization", func() {
+			recorder := serve(httptest.NewRequest("GET", "/orgs/org_acme/orders", nil), acmeClaims)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+		})
+
+		Convey("Should deny access without an organization", func() {
+			recorder := serve(httptest.NewRequest("GET", "/orders", nil), map[string]any{"sub": "auth0|1234567890"})
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"organization_mismatch","message":"Token is not issued for this organization.","reason":"claim 'org_id' is missing"}`)
+		})
+
+		Convey("With allowlisted organizations", func() {
+			organizationConfig.AllowedIDs = []string{"org_globex"}
+			organizationConfig.AllowedNames = []string{"acme"}
+
+			Convey("Should allow access by organization ID", func() {
+				recorder := serve(httptest.NewRequest("GET", "/orders", nil), map[string]any{"org_id": "org_globex"})
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should allow access by organization name", func() {
+				recorder := serve(httptest.NewRequest("GET", "/orders", nil), acmeClaims)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should deny access to other organizations", func() {
+				recorder := serve(httptest.NewRequest("GET", "/orders", nil), map[string]any{"org_id": "org_initech", "org_name": "initech"})
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldContainSubstring, "organization 'org_initech' is not allowed")
+			})
+		})
+
+		Convey("With the organization in the path", func() {
+			organizationConfig.Path = "/orgs/{orgId}/**"
+
+			Convey("Should allow access to the token's organization", func() {
+				recorder := serve(httptest.NewRequest("GET", "/orgs/org_acme/orders/42", nil), acmeClaims)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should deny access to other organizations", func() {
+				recorder := serve(httptest.NewRequest("GET", "/orgs/org_globex/orders/42", nil), acmeClaims)
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldContainSubstring, "path must address organization 'org_acme'")
+			})
+
+			Convey("Should not check paths not matching the pattern", func() {
+				recorder := serve(httptest.NewRequest("GET", "/health", nil), acmeClaims)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("With the organization in a header", func() {
+			organizationConfig.Header = "X-Org-Id"
+
+			Convey("Should allow access to the token's organization", func() {
+				req := httptest.NewRequest("GET", "/orders", nil)
+				req.Header.Set("X-Org-Id", "org_acme")
+
+				So(serve(req, acmeClaims).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should deny access to other organizations", func() {
+				req := httptest.NewRequest("GET", "/orders", nil)
+				req.Header.Set("X-Org-Id", "org_globex")
+
+				So(serve(req, acmeClaims).Code, ShouldEqual, http.StatusForbidden)
+			})
+
+			Convey("Should deny access without the header", func() {
+				recorder := serve(httptest.NewRequest("GET", "/orders", nil), acmeClaims)
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldContainSubstring, "header 'X-Org-Id' must equal organization 'org_acme'")
+			})
+		})
+
+		Convey("Should not create a validator with an invalid organization path", func() {
+			for _, path := range []string{"/orgs/*/orders", "/orgs/{orgId}/users/{orgId}", "/orgs/{orgId}/[/**"} {
+				validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+					Organization: &subrouter_config.OrganizationConfig{Path: path},
+				})
+				So(validator, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
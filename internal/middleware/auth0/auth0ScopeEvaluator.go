@@ -0,0 +1,157 @@
+package auth0
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+var ErrInvalidScopeExpression = errors.New("scope expression must set exactly one of scope, allOf, anyOf or not")
+
+type scopeExpression interface {
+	evaluate(scopes []string) bool
+	// scopes lists the scopes whose presence can satisfy the expression.
+	scopes() []string
+}
+
+type scopeTerm string
+
+func (t scopeTerm) evaluate(scopes []string) bool {
+	return slices.Contains(scopes, string(t))
+}
+
+func (t scopeTerm) scopes() []string {
+	return []string{string(t)}
+}
+
+type allOfScopeExpression []scopeExpression
+
+func (e allOfScopeExpression) evaluate(scopes []string) bool {
+	for _, expression := range e {
+		if !expression.evaluate(scopes) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e allOfScopeExpression) scopes() []string {
+	return collectScopes(e)
+}
+
+type anyOfScopeExpression []scopeExpression
+
+func (e anyOfScopeExpression) evaluate(scopes []string) bool {
+	for _, expression := range e {
+		if expression.evaluate(scopes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e anyOfScopeExpression) scopes() []string {
+	return collectScopes(e)
+}
+
+type notScopeExpression struct {
+	expression scopeExpression
+}
+
+func (e notScopeExpression) evaluate(scopes []string) bool {
+	return !e.expression.evaluate(scopes)
+}
+
+// scopes of a negation are empty, as its scopes can only be required to be absent.
+func (e notScopeExpression) scopes() []string {
+	return nil
+}
+
+func collectScopes(expressions []scopeExpression) []string {
+	var scopes []string
+
+	for _, expression := range expressions {
+		scopes = append(scopes, expression.scopes()...)
+	}
+
+	return scopes
+}
+
+// scopeEvaluator decides whether the space-delimited scope claim of a token satisfies a scope expression.
+type scopeEvaluator struct {
+	expression scopeExpression
+}
+
+func newScopeEvaluator(config subrouter_config.ScopeExpressionConfig) (*scopeEvaluator, error) {
+	expression, err := compileScopeExpression(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scopeEvaluator{
+		expression: expression,
+	}, nil
+}
+
+func (e *scopeEvaluator) evaluate(scope string) bool {
+	return e.expression.evaluate(strings.Fields(scope))
+}
+
+func compileScopeExpression(config subrouter_config.ScopeExpressionConfig) (scopeExpression, error) {
+	var operandCount int
+
+	for _, isSet := range []bool{config.Scope != "", len(config.AllOf) > 0, len(config.AnyOf) > 0, config.Not != nil} {
+		if isSet {
+			operandCount++
+		}
+	}
+
+	if operandCount != 1 {
+		return nil, ErrInvalidScopeExpression
+	}
+
+	switch {
+	case config.Scope != "":
+		return scopeTerm(config.Scope), nil
+	case config.Not != nil:
+		expression, err := compileScopeExpression(*config.Not)
+		if err != nil {
+			return nil, err
+		}
+
+		return notScopeExpression{expression: expression}, nil
+	case len(config.AllOf) > 0:
+		expressions, err := compileScopeExpressions(config.AllOf)
+		if err != nil {
+			return nil, err
+		}
+
+		return allOfScopeExpression(expressions), nil
+	default:
+		expressions, err := compileScopeExpressions(config.AnyOf)
+		if err != nil {
+			return nil, err
+		}
+
+		return anyOfScopeExpression(expressions), nil
+	}
+}
+
+func compileScopeExpressions(configs []subrouter_config.ScopeExpressionConfig) ([]scopeExpression, error) {
+	expressions := make([]scopeExpression, 0, len(configs))
+
+	for _, config := range configs {
+		expression, err := compileScopeExpression(config)
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, expression)
+	}
+
+	return expressions, nil
+}
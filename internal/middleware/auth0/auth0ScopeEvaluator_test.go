@@ -0,0 +1,135 @@
+package auth0_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Auth0ScopeValidator_ScopeExpression(t *testing.T) {
+	Convey("When testing Auth0 scope validator with a scope expression", t, func() {
+		auth0ValidatorFactory := middleware.NewAuth0ValidatorFactory()
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		serveRecorder := func(validator middleware.IAuth0ScopeValidator, scope string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: &middleware.CustomAuth0Claims{
+					Scope: scope,
+				},
+			}))
+
+			recorder := httptest.NewRecorder()
+			validator.Handler()(testHandler).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		serve := func(validator middleware.IAuth0ScopeValidator, scope string) int {
+			return serveRecorder(validator, scope).Code
+		}
+
+		Convey("With an anyOf expression", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				ScopeExpression: &subrouter_config.ScopeExpressionConfig{
+					AnyOf: []subrouter_config.ScopeExpressionConfig{
+						{Scope: "read:orders"},
+						{Scope: "admin:all"},
+					},
+				},
+			})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			So(serve(validator, "read:orders"), ShouldEqual, http.StatusOK)
+			So(serve(validator, "profile admin:all"), ShouldEqual, http.StatusOK)
+			So(serve(validator, "write:orders"), ShouldEqual, http.StatusForbidden)
+			So(serve(validator, ""), ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("With nested allOf and anyOf expressions", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				ScopeExpression: &subrouter_config.ScopeExpressionConfig{
+					AnyOf: []subrouter_config.ScopeExpressionConfig{
+						{
+							AllOf: []subrouter_config.ScopeExpressionConfig{
+								{Scope: "read:a"},
+								{Scope: "read:b"},
+							},
+						},
+						{Scope: "superuser"},
+					},
+				},
+			})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			So(serve(validator, "read:a read:b"), ShouldEqual, http.StatusOK)
+			So(serve(validator, "superuser"), ShouldEqual, http.StatusOK)
+			So(serve(validator, "read:a"), ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("With a not expression", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				ScopeExpression: &subrouter_config.ScopeExpressionConfig{
+					AllOf: []subrouter_config.ScopeExpressionConfig{
+						{Scope: "read:orders"},
+						{Not: &subrouter_config.ScopeExpressionConfig{Scope: "suspended"}},
+					},
+				},
+			})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			So(serve(validator, "read:orders"), ShouldEqual, http.StatusOK)
+			So(serve(validator, "read:orders suspended"), ShouldEqual, http.StatusForbidden)
+			So(serveRecorder(validator, "read:orders suspended").Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="insufficient_scope", scope="read:orders"`)
+		})
+
+		Convey("With both required scopes and an expression", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				RequiredScopes: []string{"openid"},
+				ScopeExpression: &subrouter_config.ScopeExpressionConfig{
+					AnyOf: []subrouter_config.ScopeExpressionConfig{
+						{Scope: "read:orders"},
+						{Scope: "admin:all"},
+					},
+				},
+			})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			So(serve(validator, "openid admin:all"), ShouldEqual, http.StatusOK)
+			So(serve(validator, "admin:all"), ShouldEqual, http.StatusForbidden)
+			So(serveRecorder(validator, "admin:all").Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="insufficient_scope", scope="openid read:orders admin:all"`)
+		})
+
+		Convey("With invalid expressions", func() {
+			invalidExpressions := []subrouter_config.ScopeExpressionConfig{
+				{},
+				{Scope: "read:orders", Not: &subrouter_config.ScopeExpressionConfig{Scope: "admin:all"}},
+				{AnyOf: []subrouter_config.ScopeExpressionConfig{{Scope: "read:orders"}, {}}},
+				{Not: &subrouter_config.ScopeExpressionConfig{}},
+			}
+
+			for _, invalidExpression := range invalidExpressions {
+				validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+					ScopeExpression: &invalidExpression,
+				})
+				So(validator, ShouldBeNil)
+				So(err, ShouldWrap, middleware.ErrInvalidScopeExpression)
+			}
+		})
+	})
+}
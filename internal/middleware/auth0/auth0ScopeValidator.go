@@ -7,6 +7,7 @@ import (
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/gorilla/mux"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
 )
 
 type IAuth0ScopeValidator interface {
@@ -21,36 +22,94 @@ func (a *Auth0ScopeValidator) Handler() mux.MiddlewareFunc {
 	return a.middlewareFunc
 }
 
-func buildAuth0ScopeMiddlewareFunc(config subrouter_config.AuthorizationConfig) mux.MiddlewareFunc {
+// buildAuth0ScopeMiddlewareFunc builds the middleware authorizing requests by their validated claims.
+// The scope requirement of the first authorization rule matching the request replaces the default one.
+func buildAuth0ScopeMiddlewareFunc(
+	config subrouter_config.AuthorizationConfig,
+	defaultScopeRequirement scopeRequirement,
+	authorizationRules []authorizationRule,
+	claimRules []claimRule,
+	organizationRule *organizationRule,
+) mux.MiddlewareFunc {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			// With optional credentials, anonymous requests have no claims to authorize.
+			if config.CredentialsOptional && req.Context().Value(jwtmiddleware.ContextKey{}) == nil {
+				handler.ServeHTTP(responseWriter, req)
+
+				return
+			}
+
 			token, isValidatedClaim := req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 			if !isValidatedClaim {
-				handleScopeError(responseWriter, http.StatusBadRequest, "Cannot access token.")
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusBadRequest,
+					Code:       errorResponse_util.CodeInvalidRequest,
+					Message:    "Cannot access token.",
+				})
 
 				return
 			}
 
 			customAuth0Claims, isValidatedClaim := token.CustomClaims.(*CustomAuth0Claims)
 			if !isValidatedClaim {
-				handleScopeError(responseWriter, http.StatusBadRequest, "Invalid claims in token.")
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusBadRequest,
+					Code:       errorResponse_util.CodeInvalidRequest,
+					Message:    "Invalid claims in token.",
+				})
 
 				return
 			}
 
-			if !customAuth0Claims.HasAllScopes(config.RequiredScopes) {
-				handleScopeError(responseWriter, http.StatusForbidden, "Insufficient access privileges.")
+			scopeRequirement := defaultScopeRequirement
+
+			for _, authorizationRule := range authorizationRules {
+				if authorizationRule.matches(req) {
+					scopeRequirement = authorizationRule.scopeRequirement
+
+					break
+				}
+			}
+
+			if !scopeRequirement.isSatisfiedBy(customAuth0Claims) || !customAuth0Claims.HasAllPermissions(config.RequiredPermissions) {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusForbidden,
+					Code:       errorResponse_util.CodeInsufficientScope,
+					Message:    "Insufficient access privileges.",
+					Scopes:     scopeRequirement.hintedScopes(config.RequiredPermissions),
+				})
 
 				return
 			}
 
+			for _, claimRule := range claimRules {
+				if reason, isSatisfied := claimRule.check(customAuth0Claims.Claims); !isSatisfied {
+					errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+						StatusCode: http.StatusForbidden,
+						Code:       errorResponse_util.CodeClaimMismatch,
+						Message:    "Token claims do not meet the requirements.",
+						Reason:     reason,
+					})
+
+					return
+				}
+			}
+
+			if organizationRule != nil {
+				if reason, isSatisfied := organizationRule.check(req, customAuth0Claims.Claims); !isSatisfied {
+					errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+						StatusCode: http.StatusForbidden,
+						Code:       errorResponse_util.CodeOrganizationMismatch,
+						Message:    "Token is not issued for this organization.",
+						Reason:     reason,
+					})
+
+					return
+				}
+			}
+
 			handler.ServeHTTP(responseWriter, req)
 		})
 	}
 }
-
-func handleScopeError(responseWriter http.ResponseWriter, httpStatusCode int, message string) {
-	responseWriter.Header().Set("Content-Type", "application/json")
-	responseWriter.WriteHeader(httpStatusCode)
-	_, _ = responseWriter.Write([]byte(`{"message":"` + message + `"}`))
-}
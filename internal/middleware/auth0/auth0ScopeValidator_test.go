@@ -10,6 +10,7 @@ import (
 	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
 	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -22,7 +23,8 @@ func Test_Auth0ScopeValidator_Creation(t *testing.T) {
 				RequiredScopes: []string{"read:all"},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 			So(validator, ShouldImplement, (*middleware.IAuth0ScopeValidator)(nil))
 
@@ -35,7 +37,8 @@ func Test_Auth0ScopeValidator_Creation(t *testing.T) {
 				RequiredScopes: []string{"read:all", "write:users", "admin:system"},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 
 			handler := validator.Handler()
@@ -47,7 +50,8 @@ func Test_Auth0ScopeValidator_Creation(t *testing.T) {
 				RequiredScopes: []string{},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 		})
 
@@ -56,7 +60,8 @@ func Test_Auth0ScopeValidator_Creation(t *testing.T) {
 				RequiredScopes: nil,
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 		})
 	})
@@ -70,7 +75,8 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 			config := subrouter_config.AuthorizationConfig{
 				RequiredScopes: []string{"read:all", "write:users"},
 			}
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
 				responseWriter.WriteHeader(http.StatusOK)
 				_, _ = responseWriter.Write([]byte("success"))
@@ -101,7 +107,8 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 				RequiredScopes: []string{"read:all", "admin:system"},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
 				responseWriter.WriteHeader(http.StatusOK)
@@ -127,7 +134,7 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 
 			So(recorder.Code, ShouldEqual, http.StatusForbidden)
 			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
-			So(recorder.Body.String(), ShouldEqual, `{"message":"Insufficient access privileges."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"insufficient_scope","message":"Insufficient access privileges."}`)
 		})
 
 		Convey("Should deny access when no token in context", func() {
@@ -135,7 +142,8 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 				RequiredScopes: []string{"read:all"},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
 				responseWriter.WriteHeader(http.StatusOK)
@@ -152,7 +160,33 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 
 			So(recorder.Code, ShouldEqual, http.StatusBadRequest)
 			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
-			So(recorder.Body.String(), ShouldEqual, `{"message":"Cannot access token."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_request","message":"Cannot access token."}`)
+		})
+
+		Convey("Should allow anonymous access when credentials are optional", func() {
+			config := subrouter_config.AuthorizationConfig{
+				RequiredScopes:      []string{"read:all"},
+				CredentialsOptional: true,
+			}
+
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
+
+			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				responseWriter.WriteHeader(http.StatusOK)
+				_, _ = responseWriter.Write([]byte("success"))
+			})
+
+			middlewareHandler := validator.Handler()(testHandler)
+
+			req := httptest.NewRequest("GET", "/test", nil)
+
+			recorder := httptest.NewRecorder()
+
+			middlewareHandler.ServeHTTP(recorder, req)
+
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
 		})
 
 		Convey("Should deny access when token has invalid custom claims", func() {
@@ -160,7 +194,8 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 				RequiredScopes: []string{"read:all"},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
 				responseWriter.WriteHeader(http.StatusOK)
@@ -184,7 +219,7 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 
 			So(recorder.Code, ShouldEqual, http.StatusBadRequest)
 			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
-			So(recorder.Body.String(), ShouldEqual, `{"message":"Invalid claims in token."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_request","message":"Invalid claims in token."}`)
 		})
 
 		Convey("Should allow access when no scopes required", func() {
@@ -192,7 +227,8 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 				RequiredScopes: []string{},
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
 				responseWriter.WriteHeader(http.StatusOK)
@@ -225,7 +261,8 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 				RequiredScopes: nil,
 			}
 
-			validator := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
 				responseWriter.WriteHeader(http.StatusOK)
@@ -254,3 +291,171 @@ func Test_Auth0ScopeValidator_Handler(t *testing.T) {
 		})
 	})
 }
+
+func Test_Auth0ScopeValidator_ClaimRules(t *testing.T) {
+	Convey("When testing Auth0 scope validator claim rules", t, func() {
+		auth0ValidatorFactory := middleware.NewAuth0ValidatorFactory()
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		config := subrouter_config.AuthorizationConfig{
+			RequiredScopes: []string{"read:all"},
+			ClaimRules: []subrouter_config.ClaimRuleConfig{
+				{Claim: "org_id", Operator: middleware.ClaimRuleOperatorEquals, Value: "acme"},
+				{Claim: "email_verified", Operator: middleware.ClaimRuleOperatorEquals, Value: "true"},
+				{Claim: "https://example.com/roles", Operator: middleware.ClaimRuleOperatorContains, Value: "admin"},
+				{Claim: "sid", Operator: middleware.ClaimRuleOperatorExists},
+			},
+		}
+
+		validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(config)
+		So(err, ShouldBeNil)
+		So(validator, ShouldNotBeNil)
+
+		serve := func(claims map[string]any) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/test", nil)
+
+			validatedClaims := &jwtvalidator.ValidatedClaims{
+				CustomClaims: &middleware.CustomAuth0Claims{
+					Scope:  "read:all",
+					Claims: claims,
+				},
+			}
+
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, validatedClaims))
+
+			recorder := httptest.NewRecorder()
+			errorResponse_util.WithVerboseReasons(true)(validator.Handler()(testHandler)).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		validClaims := func() map[string]any {
+			return map[string]any{
+				"org_id":                    "acme",
+				"email_verified":            true,
+				"https://example.com/roles": []any{"user", "admin"},
+				"sid":                       "session-id",
+			}
+		}
+
+		Convey("Should allow access when every rule is satisfied", func() {
+			recorder := serve(validClaims())
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+		})
+
+		Convey("Should deny access when a claim has a different value", func() {
+			claims := validClaims()
+			claims["org_id"] = "globex"
+
+			recorder := serve(claims)
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"claim_mismatch","message":"Token claims do not meet the requirements.","reason":"claim 'org_id' must equal 'acme'"}`)
+		})
+
+		Convey("Should deny access when a boolean claim is false", func() {
+			claims := validClaims()
+			claims["email_verified"] = false
+
+			recorder := serve(claims)
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"claim_mismatch","message":"Token claims do not meet the requirements.","reason":"claim 'email_verified' must equal 'true'"}`)
+		})
+
+		Convey("Should deny access when an array claim lacks the value", func() {
+			claims := validClaims()
+			claims["https://example.com/roles"] = []any{"user"}
+
+			recorder := serve(claims)
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"claim_mismatch","message":"Token claims do not meet the requirements.","reason":"claim 'https://example.com/roles' must contain 'admin'"}`)
+		})
+
+		Convey("Should accept a space-delimited string for contains", func() {
+			claims := validClaims()
+			claims["https://example.com/roles"] = "user admin"
+
+			recorder := serve(claims)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should deny access when a claim is missing", func() {
+			claims := validClaims()
+			delete(claims, "sid")
+
+			recorder := serve(claims)
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"claim_mismatch","message":"Token claims do not meet the requirements.","reason":"claim 'sid' is missing"}`)
+		})
+
+		Convey("Should not create a validator with an unknown operator", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				ClaimRules: []subrouter_config.ClaimRuleConfig{
+					{Claim: "org_id", Operator: "matches", Value: "acme"},
+				},
+			})
+			So(validator, ShouldBeNil)
+			So(err, ShouldWrap, middleware.ErrUnknownClaimRuleOperator)
+		})
+
+		Convey("Should not create a validator with a rule without a claim", func() {
+			validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+				ClaimRules: []subrouter_config.ClaimRuleConfig{
+					{Operator: middleware.ClaimRuleOperatorExists},
+				},
+			})
+			So(validator, ShouldBeNil)
+			So(err, ShouldWrap, middleware.ErrMissingClaimRuleClaim)
+		})
+	})
+}
+
+func Test_Auth0ScopeValidator_Permissions(t *testing.T) {
+	Convey("When testing Auth0 scope validator with required permissions", t, func() {
+		auth0ValidatorFactory := middleware.NewAuth0ValidatorFactory()
+
+		validator, err := auth0ValidatorFactory.NewAuth0ScopeValidator(subrouter_config.AuthorizationConfig{
+			RequiredPermissions: []string{"read:orders"},
+		})
+		So(err, ShouldBeNil)
+		So(validator, ShouldNotBeNil)
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		serve := func(customClaims *middleware.CustomAuth0Claims) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: customClaims,
+			}))
+
+			recorder := httptest.NewRecorder()
+			validator.Handler()(testHandler).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("Should allow access when the token has the permissions", func() {
+			recorder := serve(&middleware.CustomAuth0Claims{
+				Permissions: []string{"read:orders", "write:orders"},
+			})
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+		})
+
+		Convey("Should deny access when the permission is only a scope", func() {
+			recorder := serve(&middleware.CustomAuth0Claims{
+				Scope: "read:orders",
+			})
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="insufficient_scope", scope="read:orders"`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"insufficient_scope","message":"Insufficient access privileges."}`)
+		})
+	})
+}
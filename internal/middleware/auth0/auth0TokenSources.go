@@ -0,0 +1,137 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+var (
+	ErrUnknownTokenSource     = errors.New("unknown token source")
+	ErrMissingTokenSourceName = errors.New("cookie and query token sources require a name")
+)
+
+// tokenSources extracts tokens from the places a subrouter accepts them from, in order,
+// and verifies the DPoP binding of the tokens if the subrouter accepts DPoP-bound tokens.
+type tokenSources struct {
+	tokenExtractor  jwtmiddleware.TokenExtractor
+	queryParameters []string
+	dpopVerifier    *dpopVerifier
+}
+
+// newTokenSources sets up the token extractor of the given sources. Without any sources,
+// tokens are only read from the Authorization header.
+func newTokenSources(tokenSourceConfigs []subrouter_config.TokenSourceConfig, dpopConfig *subrouter_config.DPoPConfig) (*tokenSources, error) {
+	var (
+		tokenExtractors      = make([]jwtmiddleware.TokenExtractor, 0, len(tokenSourceConfigs))
+		headerTokenExtractor = jwtmiddleware.AuthHeaderTokenExtractor
+		queryParameters      []string
+		dpopVerifier         *dpopVerifier
+	)
+
+	if dpopConfig != nil {
+		var err error
+
+		dpopVerifier, err = newDPoPVerifier(*dpopConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		headerTokenExtractor = dpopAuthHeaderTokenExtractor
+	}
+
+	if len(tokenSourceConfigs) == 0 {
+		return &tokenSources{tokenExtractor: headerTokenExtractor, dpopVerifier: dpopVerifier}, nil
+	}
+
+	for _, tokenSourceConfig := range tokenSourceConfigs {
+		if tokenSourceConfig.Type != subrouter_config.TokenSourceHeader && tokenSourceConfig.Name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingTokenSourceName, tokenSourceConfig.Type)
+		}
+
+		switch tokenSourceConfig.Type {
+		case subrouter_config.TokenSourceHeader:
+			tokenExtractors = append(tokenExtractors, headerTokenExtractor)
+		case subrouter_config.TokenSourceCookie:
+			tokenExtractors = append(tokenExtractors, jwtmiddleware.CookieTokenExtractor(tokenSourceConfig.Name))
+		case subrouter_config.TokenSourceQuery:
+			tokenExtractors = append(tokenExtractors, jwtmiddleware.ParameterTokenExtractor(tokenSourceConfig.Name))
+			queryParameters = append(queryParameters, tokenSourceConfig.Name)
+		default:
+			return nil, fmt.Errorf("%w '%s'", ErrUnknownTokenSource, tokenSourceConfig.Type)
+		}
+	}
+
+	return &tokenSources{
+		tokenExtractor:  jwtmiddleware.MultiTokenExtractor(tokenExtractors...),
+		queryParameters: queryParameters,
+		dpopVerifier:    dpopVerifier,
+	}, nil
+}
+
+// wrap verifies the DPoP binding of validated tokens, and removes the query parameters carrying tokens.
+func (t *tokenSources) wrap(tokenMiddlewareFunc mux.MiddlewareFunc) mux.MiddlewareFunc {
+	if len(t.queryParameters) == 0 && t.dpopVerifier == nil {
+		return tokenMiddlewareFunc
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return tokenMiddlewareFunc(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			if t.dpopVerifier != nil {
+				token, _ := t.tokenExtractor(req)
+
+				if err := t.dpopVerifier.verify(req, token); err != nil {
+					handleDPoPError(responseWriter, req, err)
+
+					return
+				}
+			}
+
+			handler.ServeHTTP(responseWriter, ScrubQueryParameters(req, t.queryParameters))
+		}))
+	}
+}
+
+// handleDPoPError rejects requests whose token is presented without a valid DPoP proof, or in spite of one.
+func handleDPoPError(responseWriter http.ResponseWriter, req *http.Request, err error) {
+	code := errorResponse_util.CodeInvalidToken
+	if errors.Is(err, ErrInvalidDPoPProof) {
+		code = errorResponse_util.CodeInvalidDPoPProof
+	}
+
+	errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+		StatusCode: http.StatusUnauthorized,
+		Code:       code,
+		Message:    "Invalid proof of possession.",
+		Reason:     err.Error(),
+	})
+}
+
+// ScrubQueryParameters returns a copy of the request without the given query parameters, if it has any of them.
+func ScrubQueryParameters(req *http.Request, queryParameters []string) *http.Request {
+	query := req.URL.Query()
+
+	hasQueryParameter := false
+	for _, queryParameter := range queryParameters {
+		if query.Has(queryParameter) {
+			query.Del(queryParameter)
+
+			hasQueryParameter = true
+		}
+	}
+
+	if !hasQueryParameter {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.RawQuery = query.Encode()
+	req.RequestURI = req.URL.RequestURI()
+
+	return req
+}
@@ -0,0 +1,138 @@
+package auth0_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	"github.com/h2non/gock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Auth0TokenValidator_TokenSources(t *testing.T) {
+	Convey("When creating a token validator with token sources", t, func() {
+		defer gock.Off()
+
+		gock.New("https://test-auth0.local").
+			Get("/.well-known/openid-configuration").
+			Reply(200).
+			JSON(openIdConfig)
+
+		gock.New("https://test-auth0.local").
+			Get("/.well-known/jwks.json").
+			Reply(200).
+			JSON(jwks)
+
+		factory := middleware.NewAuth0ValidatorFactory()
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience: "https://test-api.local/",
+				Domain:   "test-auth0.local",
+			},
+		}
+
+		Convey("With a cookie and a query parameter after the header", func() {
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+				TokenSources: []subrouter_config.TokenSourceConfig{
+					{Type: subrouter_config.TokenSourceHeader},
+					{Type: subrouter_config.TokenSourceCookie, Name: "access_token"},
+					{Type: subrouter_config.TokenSourceQuery, Name: "access_token"},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			var proxiedReq *http.Request
+
+			wrappedHandler := validator.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				proxiedReq = req
+
+				responseWriter.WriteHeader(http.StatusOK)
+			}))
+
+			serve := func(req *http.Request) *httptest.ResponseRecorder {
+				recorder := httptest.NewRecorder()
+				wrappedHandler.ServeHTTP(recorder, req)
+
+				return recorder
+			}
+
+			Convey("Should accept a token from the Authorization header", func() {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+				So(serve(req).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should accept a token from the cookie", func() {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: validJWTToken})
+
+				So(serve(req).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should accept a token from the query parameter and scrub it", func() {
+				req := httptest.NewRequest("GET", "/test?access_token="+validJWTToken+"&page=2", nil)
+
+				So(serve(req).Code, ShouldEqual, http.StatusOK)
+				So(proxiedReq.URL.RawQuery, ShouldEqual, "page=2")
+				So(proxiedReq.RequestURI, ShouldEqual, "/test?page=2")
+			})
+
+			Convey("Should prefer the header over the later sources", func() {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+invalidJWTToken)
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: validJWTToken})
+
+				So(serve(req).Code, ShouldEqual, http.StatusUnauthorized)
+			})
+
+			Convey("Should reject requests without a token", func() {
+				recorder := serve(httptest.NewRequest("GET", "/test", nil))
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, "Bearer")
+				So(recorder.Body.String(), ShouldEqual, `{"error":"missing_token","message":"Missing bearer token."}`)
+			})
+		})
+
+		Convey("With only a cookie", func() {
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+				TokenSources: []subrouter_config.TokenSourceConfig{
+					{Type: subrouter_config.TokenSourceCookie, Name: "access_token"},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			Convey("Should ignore the Authorization header", func() {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+				recorder := httptest.NewRecorder()
+				validator.Handler()(http.NotFoundHandler()).ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+		})
+
+		Convey("With a query parameter source without a name", func() {
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+				TokenSources: []subrouter_config.TokenSourceConfig{
+					{Type: subrouter_config.TokenSourceQuery},
+				},
+			})
+			So(err, ShouldWrap, middleware.ErrMissingTokenSourceName)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("With an unknown token source", func() {
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+				TokenSources: []subrouter_config.TokenSourceConfig{
+					{Type: "body", Name: "token"},
+				},
+			})
+			So(err, ShouldWrap, middleware.ErrUnknownTokenSource)
+			So(validator, ShouldBeNil)
+		})
+	})
+}
@@ -1,19 +1,29 @@
 package auth0
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
-	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/gorilla/mux"
-	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
 )
 
-const jwtCacheTTL = time.Duration(5 * time.Minute)
+const (
+	jwtCacheTTL      = time.Duration(5 * time.Minute)
+	allowedClockSkew = time.Minute
+)
+
+var (
+	ErrUntrustedIssuer              = errors.New("token issuer is not trusted")
+	ErrDisallowedSignatureAlgorithm = errors.New("token signature algorithm is not allowed")
+)
 
 type IAuth0TokenValidator interface {
 	Handler() mux.MiddlewareFunc
@@ -27,45 +37,133 @@ func (a *Auth0TokenValidator) Handler() mux.MiddlewareFunc {
 	return a.middlewareFunc
 }
 
-func buildJWTMiddlewareFunc(config auth0_config.Config) (mux.MiddlewareFunc, error) {
-	issuerURL, err := url.Parse("https://" + config.Domain + "/")
+// issuerValidators holds the validators of an issuer, keyed by the signature algorithm they accept.
+type issuerValidators map[validator.SignatureAlgorithm][]*validator.Validator
+
+// buildJWTMiddlewareFunc builds the middleware validating tokens of the given issuers. When the authorization config
+// lists audiences, they replace the audience of every issuer, and tokens must be issued for at least one of them.
+func buildJWTMiddlewareFunc(tokenIssuers []*tokenIssuer, authorizationConfig subrouter_config.AuthorizationConfig) (mux.MiddlewareFunc, error) {
+	jwtValidators := make(map[string]issuerValidators, len(tokenIssuers))
+
+	for _, tokenIssuer := range tokenIssuers {
+		issuerAudiences := []string(authorizationConfig.Audiences)
+
+		if len(issuerAudiences) == 0 && tokenIssuer.audience != "" {
+			issuerAudiences = []string{tokenIssuer.audience}
+		}
+
+		if jwtValidators[tokenIssuer.issuer] == nil {
+			jwtValidators[tokenIssuer.issuer] = make(issuerValidators)
+		}
+
+		for _, signatureAlgorithm := range tokenIssuer.signatureAlgorithms {
+			jwtValidator, err := validator.New(
+				tokenIssuer.keyFuncFor(signatureAlgorithm),
+				signatureAlgorithm,
+				tokenIssuer.issuer,
+				issuerAudiences,
+				validator.WithCustomClaims(
+					func() validator.CustomClaims {
+						return &CustomAuth0Claims{}
+					},
+				),
+				validator.WithAllowedClockSkew(allowedClockSkew),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up the %s jwt validator for issuer '%s': %w", signatureAlgorithm, tokenIssuer.name, err)
+			}
+
+			jwtValidators[tokenIssuer.issuer][signatureAlgorithm] = append(jwtValidators[tokenIssuer.issuer][signatureAlgorithm], jwtValidator)
+		}
+	}
+
+	tokenSources, err := newTokenSources(authorizationConfig.TokenSources, authorizationConfig.DPoP)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse the issuer url: %w", err)
+		return nil, err
 	}
 
-	var (
-		audiences       []string
-		cachingProvider = jwks.NewCachingProvider(issuerURL, jwtCacheTTL)
-	)
+	return tokenSources.wrap(jwtmiddleware.New(
+		newClaimsCache(authorizationConfig.ClaimsCache).wrap(buildValidateTokenFunc(jwtValidators)),
+		jwtmiddleware.WithErrorHandler(newTokenErrorHandler("Failed to validate JWT.")),
+		jwtmiddleware.WithCredentialsOptional(authorizationConfig.CredentialsOptional),
+		jwtmiddleware.WithTokenExtractor(tokenSources.tokenExtractor),
+	).CheckJWT), nil
+}
 
-	if config.Audience != "" {
-		audiences = []string{config.Audience}
+// newTokenErrorHandler builds the handler responding to requests whose token is missing, can't be extracted,
+// or failed validation. The validation error is only revealed as the reason of the response.
+func newTokenErrorHandler(message string) jwtmiddleware.ErrorHandler {
+	return func(responseWriter http.ResponseWriter, req *http.Request, err error) {
+		switch {
+		case errors.Is(err, jwtmiddleware.ErrJWTMissing):
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeMissingToken,
+				Message:    "Missing bearer token.",
+			})
+		case errors.Is(err, jwtmiddleware.ErrJWTInvalid):
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeInvalidToken,
+				Message:    message,
+				Reason:     err.Error(),
+			})
+		default:
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusBadRequest,
+				Code:       errorResponse_util.CodeMalformedToken,
+				Message:    "Malformed bearer token.",
+				Reason:     err.Error(),
+			})
+		}
 	}
+}
+
+// buildValidateTokenFunc dispatches each token to the validator of its unverified issuer and algorithm.
+func buildValidateTokenFunc(jwtValidators map[string]issuerValidators) jwtmiddleware.ValidateToken {
+	return func(ctx context.Context, token string) (interface{}, error) {
+		issuer, signatureAlgorithm, keyID, err := peekToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx = context.WithValue(ctx, keyIDContextKey{}, keyID)
+
+		validatorsByAlgorithm, isTrusted := jwtValidators[issuer]
+		if !isTrusted {
+			return nil, fmt.Errorf("%w: %s", ErrUntrustedIssuer, issuer)
+		}
+
+		algorithmValidators, isAllowed := validatorsByAlgorithm[signatureAlgorithm]
+		if !isAllowed {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowedSignatureAlgorithm, signatureAlgorithm)
+		}
+
+		for _, jwtValidator := range algorithmValidators {
+			var validatedClaims interface{}
 
-	jwtValidator, err := validator.New(
-		cachingProvider.KeyFunc,
-		validator.RS256,
-		issuerURL.String(),
-		audiences,
-		validator.WithCustomClaims(
-			func() validator.CustomClaims {
-				return &CustomAuth0Claims{}
-			},
-		),
-		validator.WithAllowedClockSkew(time.Minute),
-	)
+			validatedClaims, err = jwtValidator.ValidateToken(ctx, token)
+			if err == nil {
+				return validatedClaims, nil
+			}
+		}
+
+		return nil, err
+	}
+}
+
+// peekToken reads the issuer, the signature algorithm and the key ID of a token without verifying it.
+func peekToken(token string) (string, validator.SignatureAlgorithm, string, error) {
+	parsedToken, err := jwt.ParseSigned(token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set up the jwt validator: %w", err)
+		return "", "", "", fmt.Errorf("could not parse the token: %w", err)
 	}
 
-	errorHandler := func(responseWriter http.ResponseWriter, req *http.Request, err error) {
-		responseWriter.Header().Set("Content-Type", "application/json")
-		responseWriter.WriteHeader(http.StatusUnauthorized)
-		_, _ = responseWriter.Write([]byte(`{"message":"Failed to validate JWT: ` + err.Error() + `"}`))
+	var claims jwt.Claims
+
+	if err := parsedToken.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return "", "", "", fmt.Errorf("could not read the token claims: %w", err)
 	}
 
-	return jwtmiddleware.New(
-		jwtValidator.ValidateToken,
-		jwtmiddleware.WithErrorHandler(errorHandler),
-	).CheckJWT, nil
+	return claims.Issuer, validator.SignatureAlgorithm(parsedToken.Headers[0].Algorithm), parsedToken.Headers[0].KeyID, nil
 }
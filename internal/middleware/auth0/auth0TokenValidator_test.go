@@ -4,12 +4,19 @@ import (
 	_ "embed"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
 	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
 	"github.com/h2non/gock"
 	. "github.com/smartystreets/goconvey/convey"
+	jose "gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
 )
 
 var (
@@ -19,6 +26,9 @@ var (
 	//go:embed testdata/mock_openIdConfig.json
 	openIdConfig string
 
+	//go:embed testdata/mock_discovery_openIdConfig.json
+	discoveryOpenIdConfig string
+
 	//go:embed testdata/jwtToken_valid.txt
 	validJWTToken string
 
@@ -44,11 +54,13 @@ func Test_Auth0TokenValidator_Interface(t *testing.T) {
 				JSON(jwks)
 
 			config := auth0_config.Config{
-				Audience: "https://test-api.local/",
-				Domain:   "test-auth0.local",
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "https://test-api.local/",
+					Domain:   "test-auth0.local",
+				},
 			}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 			So(validator, ShouldImplement, (*middleware.IAuth0TokenValidator)(nil))
@@ -91,33 +103,39 @@ func Test_Auth0TokenValidator_Interface(t *testing.T) {
 		Convey("Should handle invalid configuration", func() {
 			Convey("With invalid domain URL", func() {
 				config := auth0_config.Config{
-					Audience: "https://api.example.com",
-					Domain:   "invalid domain with spaces",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: "https://api.example.com",
+						Domain:   "invalid domain with spaces",
+					},
 				}
 
-				validator, err := factory.NewAuth0TokenValidator(config)
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 				So(err, ShouldNotBeNil)
 				So(validator, ShouldBeNil)
 			})
 
 			Convey("With empty domain", func() {
 				config := auth0_config.Config{
-					Audience: "https://api.example.com",
-					Domain:   "",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: "https://api.example.com",
+						Domain:   "",
+					},
 				}
 
-				validator, err := factory.NewAuth0TokenValidator(config)
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 				So(err, ShouldBeNil)
 				So(validator, ShouldNotBeNil)
 			})
 
 			Convey("With empty audience", func() {
 				config := auth0_config.Config{
-					Audience: "",
-					Domain:   "example.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: "",
+						Domain:   "example.auth0.com",
+					},
 				}
 
-				validator, err := factory.NewAuth0TokenValidator(config)
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 				So(err, ShouldNotBeNil)
 				So(validator, ShouldBeNil)
 			})
@@ -126,22 +144,28 @@ func Test_Auth0TokenValidator_Interface(t *testing.T) {
 		Convey("Should create validator with different configurations", func() {
 			testConfigs := []auth0_config.Config{
 				{
-					Audience: "https://api1.example.com",
-					Domain:   "tenant1.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: "https://api1.example.com",
+						Domain:   "tenant1.auth0.com",
+					},
 				},
 				{
-					Audience: "https://api2.example.com",
-					Domain:   "tenant2.eu.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: "https://api2.example.com",
+						Domain:   "tenant2.eu.auth0.com",
+					},
 				},
 				{
-					Audience: "test-audience",
-					Domain:   "test.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: "test-audience",
+						Domain:   "test.auth0.com",
+					},
 				},
 			}
 
 			for i, config := range testConfigs {
 				Convey("Should handle config "+string(rune(i+'1')), func() {
-					validator, err := factory.NewAuth0TokenValidator(config)
+					validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 					So(err, ShouldBeNil)
 					So(validator, ShouldNotBeNil)
 					So(validator, ShouldImplement, (*middleware.IAuth0TokenValidator)(nil))
@@ -157,22 +181,26 @@ func Test_BuildJWTMiddlewareFunc_EdgeCases(t *testing.T) {
 
 		Convey("Should handle special characters in domain", func() {
 			config := auth0_config.Config{
-				Audience: "https://api.example.com",
-				Domain:   "test-tenant.auth0.com",
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "https://api.example.com",
+					Domain:   "test-tenant.auth0.com",
+				},
 			}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 		})
 
 		Convey("Should handle domain with subdomains", func() {
 			config := auth0_config.Config{
-				Audience: "https://api.example.com",
-				Domain:   "subdomain.tenant.eu.auth0.com",
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "https://api.example.com",
+					Domain:   "subdomain.tenant.eu.auth0.com",
+				},
 			}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 		})
@@ -187,14 +215,315 @@ func Test_BuildJWTMiddlewareFunc_EdgeCases(t *testing.T) {
 
 			for _, audience := range testAudiences {
 				config := auth0_config.Config{
-					Audience: audience,
-					Domain:   "test.auth0.com",
+					IssuerConfig: auth0_config.IssuerConfig{
+						Audience: audience,
+						Domain:   "test.auth0.com",
+					},
 				}
 
-				validator, err := factory.NewAuth0TokenValidator(config)
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 				So(err, ShouldBeNil)
 				So(validator, ShouldNotBeNil)
 			}
 		})
 	})
 }
+
+func Test_Auth0TokenValidator_SignatureAlgorithms(t *testing.T) {
+	Convey("When creating a token validator with configured signature algorithms", t, func() {
+		const testSecret = "test-signing-secret-of-sufficient-length"
+
+		t.Setenv("TEST_JWT_SECRET", testSecret)
+
+		factory := middleware.NewAuth0ValidatorFactory()
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		signToken := func(secret string) string {
+			signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, nil)
+			So(err, ShouldBeNil)
+
+			token, err := jwt.Signed(signer).Claims(jwt.Claims{
+				Issuer:   "https://test-auth0.local/",
+				Audience: jwt.Audience{"https://test-api.local/"},
+				Subject:  "1234567890",
+				Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			}).CompactSerialize()
+			So(err, ShouldBeNil)
+
+			return token
+		}
+
+		serve := func(validator middleware.IAuth0TokenValidator, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			recorder := httptest.NewRecorder()
+			errorResponse_util.WithVerboseReasons(true)(validator.Handler()(testHandler)).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("With HS256 and a secret from an environment variable", func() {
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience:   "https://test-api.local/",
+					Domain:     "test-auth0.local",
+					Algorithms: []string{"HS256"},
+					SecretEnv:  "TEST_JWT_SECRET",
+				},
+			}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			Convey("Should accept a token signed with the secret", func() {
+				recorder := serve(validator, signToken(testSecret))
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(recorder.Body.String(), ShouldEqual, "success")
+			})
+
+			Convey("Should reject a token signed with another secret", func() {
+				recorder := serve(validator, signToken("another-signing-secret-of-sufficient-length"))
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+
+			Convey("Should reject an RS256 token", func() {
+				recorder := serve(validator, validJWTToken)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrDisallowedSignatureAlgorithm.Error())
+			})
+		})
+
+		Convey("With HS256 and a secret from a file", func() {
+			secretFile := filepath.Join(t.TempDir(), "secret")
+			So(os.WriteFile(secretFile, []byte(testSecret+"\n"), 0o600), ShouldBeNil)
+
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience:   "https://test-api.local/",
+					Domain:     "test-auth0.local",
+					Algorithms: []string{"HS256"},
+					SecretFile: secretFile,
+				},
+			}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			recorder := serve(validator, signToken(testSecret))
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("With the default RS256 algorithm", func() {
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "https://test-api.local/",
+					Domain:   "test-auth0.local",
+				},
+			}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			Convey("Should reject an HS256 token", func() {
+				recorder := serve(validator, signToken(testSecret))
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrDisallowedSignatureAlgorithm.Error())
+			})
+		})
+
+		Convey("With asymmetric algorithms only", func() {
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience:   "https://test-api.local/",
+					Domain:     "test-auth0.local",
+					Algorithms: []string{"ES256", "PS256", "RS256"},
+				},
+			}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+		})
+
+		Convey("With an HMAC algorithm but no secret", func() {
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience:   "https://test-api.local/",
+					Domain:     "test-auth0.local",
+					Algorithms: []string{"HS256"},
+					SecretEnv:  "TEST_JWT_SECRET_UNSET",
+				},
+			}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrMissingSigningSecret)
+			So(validator, ShouldBeNil)
+		})
+
+		Convey("With an unsupported algorithm", func() {
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience:   "https://test-api.local/",
+					Domain:     "test-auth0.local",
+					Algorithms: []string{"none"},
+				},
+			}
+
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+			So(err, ShouldWrap, middleware.ErrUnsupportedSignatureAlgorithm)
+			So(validator, ShouldBeNil)
+		})
+	})
+}
+
+func Test_Auth0TokenValidator_Audiences(t *testing.T) {
+	Convey("When creating a token validator with subrouter audiences", t, func() {
+		defer gock.Off()
+
+		gock.New("https://test-auth0.local").
+			Get("/.well-known/openid-configuration").
+			Reply(200).
+			JSON(openIdConfig)
+
+		gock.New("https://test-auth0.local").
+			Get("/.well-known/jwks.json").
+			Reply(200).
+			JSON(jwks)
+
+		factory := middleware.NewAuth0ValidatorFactory()
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience: "https://global-api.local/",
+				Domain:   "test-auth0.local",
+			},
+		}
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		serve := func(authorizationConfig subrouter_config.AuthorizationConfig) *httptest.ResponseRecorder {
+			validator, err := factory.NewAuth0TokenValidator(config, authorizationConfig)
+			So(err, ShouldBeNil)
+			So(validator, ShouldNotBeNil)
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+			recorder := httptest.NewRecorder()
+			validator.Handler()(testHandler).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("Should reject tokens for the global audience when not overridden", func() {
+			recorder := serve(subrouter_config.AuthorizationConfig{})
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("Should accept tokens issued for any of the subrouter audiences", func() {
+			recorder := serve(subrouter_config.AuthorizationConfig{
+				Audiences: []string{"https://other-api.local/", "https://test-api.local/"},
+			})
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+		})
+
+		Convey("Should reject tokens issued for none of the subrouter audiences", func() {
+			recorder := serve(subrouter_config.AuthorizationConfig{
+				Audiences: []string{"https://other-api.local/"},
+			})
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+		})
+	})
+}
+
+func Test_Auth0TokenValidator_CredentialsOptional(t *testing.T) {
+	Convey("When creating a token validator with optional credentials", t, func() {
+		defer gock.Off()
+
+		gock.New("https://test-auth0.local").
+			Get("/.well-known/openid-configuration").
+			Reply(200).
+			JSON(openIdConfig)
+
+		gock.New("https://test-auth0.local").
+			Get("/.well-known/jwks.json").
+			Reply(200).
+			JSON(jwks)
+
+		factory := middleware.NewAuth0ValidatorFactory()
+		config := auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				Audience: "https://test-api.local/",
+				Domain:   "test-auth0.local",
+			},
+		}
+
+		validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+			CredentialsOptional: true,
+		})
+		So(err, ShouldBeNil)
+		So(validator, ShouldNotBeNil)
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		wrappedHandler := validator.Handler()(testHandler)
+
+		Convey("Should let anonymous requests through", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+		})
+
+		Convey("Should accept a valid JWT", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should still reject an invalid JWT", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+invalidJWTToken)
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="invalid_token"`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_token","message":"Failed to validate JWT."}`)
+		})
+
+		Convey("Should reject a malformed Authorization header", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+
+			recorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(recorder, req)
+			So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="invalid_request"`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"malformed_token","message":"Malformed bearer token."}`)
+		})
+	})
+}
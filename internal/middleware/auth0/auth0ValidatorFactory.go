@@ -1,29 +1,88 @@
 package auth0
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
 )
 
+var ErrIntrospectionIssuerNotExclusive = errors.New("an issuer validating tokens by introspection can't be trusted alongside other issuers")
+
 type IAuth0ValidatorFactory interface {
-	NewAuth0ScopeValidator(config subrouter_config.AuthorizationConfig) IAuth0ScopeValidator
-	NewAuth0TokenValidator(config auth0_config.Config) (IAuth0TokenValidator, error)
+	NewAuth0ScopeValidator(config subrouter_config.AuthorizationConfig) (IAuth0ScopeValidator, error)
+	NewAuth0TokenValidator(config auth0_config.Config, authorizationConfig subrouter_config.AuthorizationConfig) (IAuth0TokenValidator, error)
 }
 
 func NewAuth0ValidatorFactory() IAuth0ValidatorFactory {
 	return &Auth0ValidatorFactory{}
 }
 
-type Auth0ValidatorFactory struct{}
+type Auth0ValidatorFactory struct {
+	mutex        sync.Mutex
+	tokenIssuers map[string]cachedTokenIssuer
+}
+
+type cachedTokenIssuer struct {
+	config      auth0_config.IssuerConfig
+	tokenIssuer *tokenIssuer
+}
 
-func (a *Auth0ValidatorFactory) NewAuth0ScopeValidator(config subrouter_config.AuthorizationConfig) IAuth0ScopeValidator {
-	return &Auth0ScopeValidator{
-		middlewareFunc: buildAuth0ScopeMiddlewareFunc(config),
+func (a *Auth0ValidatorFactory) NewAuth0ScopeValidator(config subrouter_config.AuthorizationConfig) (IAuth0ScopeValidator, error) {
+	defaultScopeRequirement, err := newScopeRequirement(config.RequiredScopes, config.ScopeExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationRules, err := newAuthorizationRules(config.Rules)
+	if err != nil {
+		return nil, err
 	}
+
+	claimRules, err := newClaimRules(config.ClaimRules)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationRule, err := newOrganizationRule(config.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Auth0ScopeValidator{
+		middlewareFunc: buildAuth0ScopeMiddlewareFunc(config, defaultScopeRequirement, authorizationRules, claimRules, organizationRule),
+	}, nil
 }
 
-func (a *Auth0ValidatorFactory) NewAuth0TokenValidator(config auth0_config.Config) (IAuth0TokenValidator, error) {
-	jwtMiddlewareFunc, err := buildJWTMiddlewareFunc(config)
+func (a *Auth0ValidatorFactory) NewAuth0TokenValidator(config auth0_config.Config, authorizationConfig subrouter_config.AuthorizationConfig) (IAuth0TokenValidator, error) {
+	tokenIssuers, err := a.getTokenIssuers(config, authorizationConfig.Issuers)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tokenIssuer := range tokenIssuers {
+		if tokenIssuer.introspector == nil {
+			continue
+		}
+
+		if len(tokenIssuers) > 1 {
+			return nil, fmt.Errorf("%w: %s", ErrIntrospectionIssuerNotExclusive, tokenIssuer.name)
+		}
+
+		introspectionMiddlewareFunc, err := buildIntrospectionMiddlewareFunc(tokenIssuer, authorizationConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Auth0IntrospectionValidator{
+			middlewareFunc: introspectionMiddlewareFunc,
+		}, nil
+	}
+
+	jwtMiddlewareFunc, err := buildJWTMiddlewareFunc(tokenIssuers, authorizationConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -32,3 +91,45 @@ func (a *Auth0ValidatorFactory) NewAuth0TokenValidator(config auth0_config.Confi
 		middlewareFunc: jwtMiddlewareFunc,
 	}, nil
 }
+
+// getTokenIssuers resolves the issuers trusted by a subrouter, reusing the ones already set up
+// for other subrouters so that they share their discovery results and JWKS caches.
+func (a *Auth0ValidatorFactory) getTokenIssuers(config auth0_config.Config, issuerNames []string) ([]*tokenIssuer, error) {
+	if len(issuerNames) == 0 {
+		issuerNames = []string{config.Name}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.tokenIssuers == nil {
+		a.tokenIssuers = make(map[string]cachedTokenIssuer)
+	}
+
+	tokenIssuers := make([]*tokenIssuer, 0, len(issuerNames))
+
+	for _, issuerName := range issuerNames {
+		issuerConfig, err := findIssuerConfig(config, issuerName)
+		if err != nil {
+			return nil, err
+		}
+
+		cached, isCached := a.tokenIssuers[issuerName]
+		if !isCached || !reflect.DeepEqual(cached.config, issuerConfig) {
+			tokenIssuer, err := newTokenIssuer(issuerConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			cached = cachedTokenIssuer{
+				config:      issuerConfig,
+				tokenIssuer: tokenIssuer,
+			}
+			a.tokenIssuers[issuerName] = cached
+		}
+
+		tokenIssuers = append(tokenIssuers, cached.tokenIssuer)
+	}
+
+	return tokenIssuers, nil
+}
@@ -11,6 +11,8 @@ import (
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
 	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/h2non/gock"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -31,7 +33,8 @@ func Test_Auth0ValidatorFactory_NewAuth0ScopeValidator(t *testing.T) {
 				RequiredScopes: []string{"read:all", "write:users"},
 			}
 
-			validator := factory.NewAuth0ScopeValidator(config)
+			validator, err := factory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 			So(validator, ShouldImplement, (*middleware.IAuth0ScopeValidator)(nil))
 
@@ -44,7 +47,8 @@ func Test_Auth0ValidatorFactory_NewAuth0ScopeValidator(t *testing.T) {
 				RequiredScopes: []string{},
 			}
 
-			validator := factory.NewAuth0ScopeValidator(config)
+			validator, err := factory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 
 			handler := validator.Handler()
@@ -56,7 +60,8 @@ func Test_Auth0ValidatorFactory_NewAuth0ScopeValidator(t *testing.T) {
 				RequiredScopes: nil,
 			}
 
-			validator := factory.NewAuth0ScopeValidator(config)
+			validator, err := factory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 
 			handler := validator.Handler()
@@ -68,7 +73,8 @@ func Test_Auth0ValidatorFactory_NewAuth0ScopeValidator(t *testing.T) {
 				RequiredScopes: []string{"admin:access"},
 			}
 
-			validator := factory.NewAuth0ScopeValidator(config)
+			validator, err := factory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 
 			handler := validator.Handler()
@@ -80,7 +86,8 @@ func Test_Auth0ValidatorFactory_NewAuth0ScopeValidator(t *testing.T) {
 				RequiredScopes: []string{"read:users", "write:users", "delete:users", "admin:system"},
 			}
 
-			validator := factory.NewAuth0ScopeValidator(config)
+			validator, err := factory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 
 			handler := validator.Handler()
@@ -95,11 +102,13 @@ func Test_Auth0ValidatorFactory_NewAuth0TokenValidator(t *testing.T) {
 
 		Convey("With valid Auth0 config", func() {
 			config := auth0_config.Config{
-				Audience: "https://api.example.com",
-				Domain:   "example.auth0.com",
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "https://api.example.com",
+					Domain:   "example.auth0.com",
+				},
 			}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 			So(validator, ShouldImplement, (*middleware.IAuth0TokenValidator)(nil))
@@ -107,11 +116,13 @@ func Test_Auth0ValidatorFactory_NewAuth0TokenValidator(t *testing.T) {
 
 		Convey("With invalid domain", func() {
 			config := auth0_config.Config{
-				Audience: "https://api.example.com",
-				Domain:   "invalid domain with spaces",
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "https://api.example.com",
+					Domain:   "invalid domain with spaces",
+				},
 			}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldNotBeNil)
 			So(validator, ShouldBeNil)
 		})
@@ -119,21 +130,111 @@ func Test_Auth0ValidatorFactory_NewAuth0TokenValidator(t *testing.T) {
 		Convey("With empty config", func() {
 			config := auth0_config.Config{}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldNotBeNil)
 			So(validator, ShouldBeNil)
 		})
 
 		Convey("With minimal valid config", func() {
 			config := auth0_config.Config{
-				Audience: "test-audience",
-				Domain:   "test.auth0.com",
+				IssuerConfig: auth0_config.IssuerConfig{
+					Audience: "test-audience",
+					Domain:   "test.auth0.com",
+				},
 			}
 
-			validator, err := factory.NewAuth0TokenValidator(config)
+			validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
 			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 		})
+
+		Convey("With multiple issuers", func() {
+			config := auth0_config.Config{
+				IssuerConfig: auth0_config.IssuerConfig{
+					Name:     auth0_config.DefaultIssuerName,
+					Audience: "https://api.example.com",
+					Domain:   "example.auth0.com",
+				},
+				Issuers: []auth0_config.IssuerConfig{
+					{
+						Name:     "secondary",
+						Audience: "https://test-api.local/",
+						Domain:   "test-auth0.local",
+					},
+				},
+			}
+
+			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				responseWriter.WriteHeader(http.StatusOK)
+				_, _ = responseWriter.Write([]byte("success"))
+			})
+
+			Convey("Should accept tokens of a selected issuer", func() {
+				defer gock.Off()
+
+				gock.New("https://test-auth0.local").
+					Get("/.well-known/openid-configuration").
+					Reply(200).
+					JSON(openIdConfig)
+
+				gock.New("https://test-auth0.local").
+					Get("/.well-known/jwks.json").
+					Reply(200).
+					JSON(jwks)
+
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+					Issuers: []string{auth0_config.DefaultIssuerName, "secondary"},
+				})
+				So(err, ShouldBeNil)
+				So(validator, ShouldNotBeNil)
+
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+				recorder := httptest.NewRecorder()
+
+				validator.Handler()(testHandler).ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(recorder.Body.String(), ShouldEqual, "success")
+			})
+
+			Convey("Should reject tokens of an issuer that is not selected", func() {
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{})
+				So(err, ShouldBeNil)
+				So(validator, ShouldNotBeNil)
+
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("Authorization", "Bearer "+validJWTToken)
+
+				recorder := httptest.NewRecorder()
+
+				errorResponse_util.WithVerboseReasons(true)(validator.Handler()(testHandler)).ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, middleware.ErrUntrustedIssuer.Error())
+			})
+
+			Convey("Should fail for an unknown issuer name", func() {
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+					Issuers: []string{"unknown"},
+				})
+				So(err, ShouldWrap, middleware.ErrUnknownIssuer)
+				So(validator, ShouldBeNil)
+			})
+
+			Convey("Should fail for a duplicate issuer name", func() {
+				config.Issuers = append(config.Issuers, auth0_config.IssuerConfig{
+					Name:     "secondary",
+					Audience: "https://other-api.local/",
+					Domain:   "other-auth0.local",
+				})
+
+				validator, err := factory.NewAuth0TokenValidator(config, subrouter_config.AuthorizationConfig{
+					Issuers: []string{"secondary"},
+				})
+				So(err, ShouldWrap, middleware.ErrDuplicateIssuer)
+				So(validator, ShouldBeNil)
+			})
+		})
 	})
 }
 
@@ -146,7 +247,8 @@ func Test_Auth0Middleware_ComprehensiveCoverage(t *testing.T) {
 				RequiredScopes: []string{"admin:read"},
 			}
 
-			validator := factory.NewAuth0ScopeValidator(config)
+			validator, err := factory.NewAuth0ScopeValidator(config)
+			So(err, ShouldBeNil)
 			So(validator, ShouldNotBeNil)
 
 			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
@@ -167,7 +269,7 @@ func Test_Auth0Middleware_ComprehensiveCoverage(t *testing.T) {
 
 				So(recorder.Code, ShouldEqual, http.StatusBadRequest)
 				So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
-				So(recorder.Body.String(), ShouldEqual, `{"message":"Cannot access token."}`)
+				So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_request","message":"Cannot access token."}`)
 			})
 
 			Convey("Test with ValidatedClaims but wrong CustomClaims type", func() {
@@ -185,7 +287,7 @@ func Test_Auth0Middleware_ComprehensiveCoverage(t *testing.T) {
 
 				So(recorder.Code, ShouldEqual, http.StatusBadRequest)
 				So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
-				So(recorder.Body.String(), ShouldEqual, `{"message":"Invalid claims in token."}`)
+				So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_request","message":"Invalid claims in token."}`)
 			})
 
 			Convey("Test scope validation failure when missing the required scope", func() {
@@ -205,7 +307,7 @@ func Test_Auth0Middleware_ComprehensiveCoverage(t *testing.T) {
 
 				So(recorder.Code, ShouldEqual, http.StatusForbidden)
 				So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
-				So(recorder.Body.String(), ShouldEqual, `{"message":"Insufficient access privileges."}`)
+				So(recorder.Body.String(), ShouldEqual, `{"error":"insufficient_scope","message":"Insufficient access privileges."}`)
 			})
 
 			Convey("Test successful scope validation", func() {
@@ -259,8 +361,12 @@ func Test_Auth0Middleware_ComprehensiveCoverage(t *testing.T) {
 				RequiredScopes: []string{"scope2"},
 			}
 
-			validator1 := factory.NewAuth0ScopeValidator(config1)
-			validator2 := factory.NewAuth0ScopeValidator(config2)
+			validator1, err := factory.NewAuth0ScopeValidator(config1)
+			So(err, ShouldBeNil)
+
+			validator2, err := factory.NewAuth0ScopeValidator(config2)
+			So(err, ShouldBeNil)
+
 			So(validator1, ShouldNotBeNil)
 			So(validator2, ShouldNotBeNil)
 			So(validator1, ShouldNotEqual, validator2)
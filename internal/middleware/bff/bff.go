@@ -0,0 +1,308 @@
+package bff
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+	"go.uber.org/fx"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	httpTimeout = time.Duration(10 * time.Second)
+
+	// loginCookieSuffix is appended to the session cookie name to name the cookie holding the state of a login.
+	loginCookieSuffix = "_login"
+)
+
+var (
+	ErrMissingClientID       = errors.New("the backend for frontends requires a client ID")
+	ErrMissingRedirectURL    = errors.New("the backend for frontends requires a redirect URL")
+	ErrMissingSessionKey     = errors.New("the cookie session store requires a session key")
+	ErrUnknownSessionStore   = errors.New("unknown session store")
+	ErrMissingTokenEndpoints = errors.New("the authorization server has no authorization or token endpoint")
+)
+
+// IBFF interface defines the methods of the backend for frontends.
+type IBFF interface {
+	CallbackHandler() http.Handler
+	Handler(h http.Handler) http.Handler
+	LoginHandler() http.Handler
+	LogoutHandler() http.Handler
+}
+
+// BFF implements the IBFF interface, logging users in and attaching the access tokens of their sessions to requests.
+type BFF struct {
+	config       *auth0_config.BFFConfig
+	audience     string
+	clientSecret string
+	endpoints    endpoints
+	httpClient   *http.Client
+	sealer       *sealer
+	sessionStore sessionStore
+	logger       zerolog.Logger
+
+	// refreshes makes concurrent requests of a session wait for a single refresh of it, so that they don't use
+	// the same refresh token more than once.
+	refreshes singleflight.Group
+}
+
+type BFFParams struct {
+	fx.In
+
+	Auth0Config *auth0_config.Config
+	Logger      zerolog.Logger
+}
+
+// NewMiddleware sets up the backend for frontends, discovering the endpoints of the authorization server
+// unless they are configured. Without a BFF config, the middleware lets every request through.
+func NewMiddleware(params BFFParams) (IBFF, error) {
+	bff := &BFF{
+		config:     params.Auth0Config.BFF,
+		audience:   params.Auth0Config.IssuerConfig.Audience,
+		httpClient: &http.Client{Timeout: httpTimeout},
+		logger:     params.Logger,
+	}
+
+	if bff.config == nil {
+		return bff, nil
+	}
+
+	if bff.config.ClientID == "" {
+		return nil, ErrMissingClientID
+	}
+
+	if bff.config.RedirectURL == "" {
+		return nil, ErrMissingRedirectURL
+	}
+
+	clientSecret, err := config_util.ReadSecret(bff.config.ClientSecretFile, bff.config.ClientSecretEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client secret: %w", err)
+	}
+
+	bff.clientSecret = string(clientSecret)
+
+	if err := bff.setUpSessions(); err != nil {
+		return nil, err
+	}
+
+	if err := bff.setUpEndpoints(params.Auth0Config.IssuerConfig); err != nil {
+		return nil, err
+	}
+
+	return bff, nil
+}
+
+// setUpSessions sets up the session store, and the sealer of the cookies holding sessions and login states.
+// The memory session store can do without a session key, in which case a random one is generated.
+func (b *BFF) setUpSessions() error {
+	sessionKey, err := config_util.ReadSecret(b.config.SessionKeyFile, b.config.SessionKeyEnv)
+	if err != nil {
+		return fmt.Errorf("failed to read the session key: %w", err)
+	}
+
+	if len(sessionKey) > 0 {
+		if sessionKey, err = base64.StdEncoding.DecodeString(string(sessionKey)); err != nil {
+			return ErrInvalidSessionKey
+		}
+	}
+
+	switch b.config.SessionStore {
+	case auth0_config.SessionStoreCookie:
+		if len(sessionKey) == 0 {
+			return ErrMissingSessionKey
+		}
+
+		if b.sealer, err = newSealer(sessionKey); err != nil {
+			return err
+		}
+
+		b.sessionStore = &cookieSessionStore{sealer: b.sealer, cookieName: b.config.CookieName, secure: b.config.CookieSecure}
+	case auth0_config.SessionStoreMemory:
+		if len(sessionKey) == 0 {
+			sessionKey, _ = base64.RawURLEncoding.DecodeString(randomString())
+		}
+
+		if b.sealer, err = newSealer(sessionKey); err != nil {
+			return err
+		}
+
+		b.sessionStore = newMemorySessionStore(b.config.CookieName, b.config.CookieSecure)
+	default:
+		return fmt.Errorf("%w '%s'", ErrUnknownSessionStore, b.config.SessionStore)
+	}
+
+	return nil
+}
+
+// setUpEndpoints sets the endpoints of the authorization server from the config, discovering the ones missing from
+// it at the default issuer.
+func (b *BFF) setUpEndpoints(issuerConfig auth0_config.IssuerConfig) error {
+	b.endpoints = endpoints{
+		Authorization: b.config.AuthorizationEndpoint,
+		Token:         b.config.TokenEndpoint,
+		Logout:        b.config.LogoutEndpoint,
+	}
+
+	if b.endpoints.Authorization == "" || b.endpoints.Token == "" {
+		issuerURL := issuerConfig.IssuerURL
+		if issuerURL == "" {
+			issuerURL = "https://" + issuerConfig.Domain + "/"
+		}
+
+		parsedIssuerURL, err := url.Parse(issuerURL)
+		if err != nil {
+			return fmt.Errorf("failed to parse the issuer URL: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+		defer cancel()
+
+		configuration, err := auth0_middleware.FetchOpenIDConfiguration(ctx, b.httpClient, parsedIssuerURL)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrDiscoveryFailed, err)
+		}
+
+		b.endpoints.Authorization = cmp.Or(b.endpoints.Authorization, configuration.AuthorizationEndpoint)
+		b.endpoints.Token = cmp.Or(b.endpoints.Token, configuration.TokenEndpoint)
+		b.endpoints.Logout = cmp.Or(b.endpoints.Logout, configuration.EndSessionEndpoint)
+	}
+
+	if b.endpoints.Authorization == "" || b.endpoints.Token == "" {
+		return ErrMissingTokenEndpoints
+	}
+
+	return nil
+}
+
+// Handler attaches the access token of the session of the request as a bearer token, refreshing it if needed.
+func (b *BFF) Handler(h http.Handler) http.Handler {
+	if b.config == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		session := b.sessionStore.load(req)
+		if session != nil && b.needsRefresh(session) {
+			var err error
+			if session, err = b.refreshSession(responseWriter, session); err != nil {
+				b.logger.Error().Err(err).Msg("Failed to refresh the session")
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusBadGateway,
+					Code:       errorResponse_util.CodeSessionRefreshFailed,
+					Message:    "Session could not be refreshed.",
+					Reason:     err.Error(),
+				})
+
+				return
+			}
+		}
+
+		removeCookies(req, b.config.CookieName, b.config.CookieName+loginCookieSuffix)
+
+		if session != nil {
+			req.Header.Set("Authorization", "Bearer "+session.AccessToken)
+		}
+
+		h.ServeHTTP(responseWriter, req)
+	})
+}
+
+func (b *BFF) needsRefresh(session *session) bool {
+	return time.Now().Add(b.config.RefreshBefore).After(session.TokenExpiresAt)
+}
+
+// refreshSession refreshes the access token of the session, and returns the refreshed session. If the session
+// has no refresh token, or it is rejected, the session is ended and nil is returned. Other failures keep the session.
+func (b *BFF) refreshSession(responseWriter http.ResponseWriter, expiringSession *session) (*session, error) {
+	// Sessions kept in cookies have no ID, but concurrent requests of one carry the same refresh token.
+	isLeader := false
+
+	result, err, _ := b.refreshes.Do(cmp.Or(expiringSession.id, expiringSession.RefreshToken), func() (any, error) {
+		isLeader = true
+
+		return b.refreshStoredSession(responseWriter, expiringSession)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshedSession, _ := result.(*session)
+	if isLeader {
+		return refreshedSession, nil
+	}
+
+	// The responses of the requests which waited need the session cookie too, as set by the refreshing request.
+	if refreshedSession == nil {
+		b.sessionStore.delete(responseWriter, expiringSession)
+	} else if err := b.sessionStore.save(responseWriter, refreshedSession); err != nil {
+		b.logger.Error().Err(err).Msg("Failed to save the refreshed session")
+	}
+
+	return refreshedSession, nil
+}
+
+func (b *BFF) refreshStoredSession(responseWriter http.ResponseWriter, session *session) (*session, error) {
+	// Another request of the session may have refreshed or ended it since this one loaded it. This can only be
+	// told for sessions kept on the server, as the cookie of a refreshed session only reaches the browser later.
+	if session.id != "" {
+		storedSession := b.sessionStore.find(session.id)
+		if storedSession == nil || !b.needsRefresh(storedSession) {
+			return storedSession, nil
+		}
+
+		session = storedSession
+	}
+
+	if session.RefreshToken == "" {
+		b.sessionStore.delete(responseWriter, session)
+
+		return nil, nil
+	}
+
+	// The refresh isn't canceled along with the request, as a rotated refresh token would be lost with it.
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	if err := b.refresh(ctx, session); err != nil {
+		if !errors.Is(err, ErrGrantRejected) {
+			return nil, err
+		}
+
+		b.logger.Warn().Err(err).Msg("Session refresh was rejected, ending the session")
+		b.sessionStore.delete(responseWriter, session)
+
+		return nil, nil
+	}
+
+	if err := b.sessionStore.save(responseWriter, session); err != nil {
+		b.logger.Error().Err(err).Msg("Failed to save the refreshed session")
+	}
+
+	return session, nil
+}
+
+// removeCookies removes the named cookies from the Cookie header of the request.
+func removeCookies(req *http.Request, names ...string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+
+	for _, cookie := range cookies {
+		if !slices.Contains(names, cookie.Name) {
+			req.AddCookie(cookie)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package bff
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+// loginTimeout is how long users have to log in at the authorization server.
+const loginTimeout = time.Duration(10 * time.Minute)
+
+// defaultScopes are requested when logging in unless the config lists others.
+var defaultScopes = []string{"openid", "profile", "offline_access"}
+
+// loginState is kept in the login cookie between redirecting the user to the authorization server and the callback.
+type loginState struct {
+	State        string `json:"state"`
+	CodeVerifier string `json:"codeVerifier"`
+	ReturnTo     string `json:"returnTo"`
+}
+
+// LoginHandler redirects the user to the authorization server to log in, with the authorization code flow and PKCE.
+// The path to return to after logging in can be passed in the returnTo query parameter.
+func (b *BFF) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		state := loginState{
+			State:        randomString(),
+			CodeVerifier: randomString(),
+			ReturnTo:     localPath(req.URL.Query().Get("returnTo")),
+		}
+
+		encodedState, _ := json.Marshal(state)
+		loginCookieName := b.config.CookieName + loginCookieSuffix
+
+		http.SetCookie(responseWriter, newCookie(
+			loginCookieName,
+			b.sealer.seal(loginCookieName, encodedState),
+			b.callbackCookiePath(),
+			time.Now().Add(loginTimeout),
+			b.config.CookieSecure,
+		))
+
+		scopes := []string(b.config.Scopes)
+		if len(scopes) == 0 {
+			scopes = defaultScopes
+		}
+
+		codeChallenge := sha256.Sum256([]byte(state.CodeVerifier))
+
+		query := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {b.config.ClientID},
+			"redirect_uri":          {b.config.RedirectURL},
+			"scope":                 {strings.Join(scopes, " ")},
+			"state":                 {state.State},
+			"code_challenge":        {base64.RawURLEncoding.EncodeToString(codeChallenge[:])},
+			"code_challenge_method": {"S256"},
+		}
+
+		if b.audience != "" {
+			query.Set("audience", b.audience)
+		}
+
+		http.Redirect(responseWriter, req, b.endpoints.Authorization+"?"+query.Encode(), http.StatusFound)
+	})
+}
+
+// CallbackHandler completes the login by exchanging the authorization code for tokens, starting a session with them,
+// and redirecting the user to the path they logged in from.
+func (b *BFF) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		loginCookieName := b.config.CookieName + loginCookieSuffix
+
+		state, isStarted := b.loadLoginState(req, loginCookieName)
+
+		http.SetCookie(responseWriter, newCookie(loginCookieName, "", b.callbackCookiePath(), time.Time{}, b.config.CookieSecure))
+
+		query := req.URL.Query()
+
+		switch {
+		case !isStarted:
+			writeLoginError(responseWriter, req, http.StatusBadRequest, "no login in progress")
+
+			return
+		case subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state.State)) != 1:
+			writeLoginError(responseWriter, req, http.StatusBadRequest, "state mismatch")
+
+			return
+		case query.Get("error") != "":
+			writeLoginError(responseWriter, req, http.StatusUnauthorized, query.Get("error")+": "+query.Get("error_description"))
+
+			return
+		}
+
+		tokens, err := b.requestTokens(req.Context(), url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {query.Get("code")},
+			"redirect_uri":  {b.config.RedirectURL},
+			"code_verifier": {state.CodeVerifier},
+		})
+		if err != nil {
+			b.logger.Error().Err(err).Msg("Failed to exchange the authorization code")
+			writeLoginError(responseWriter, req, http.StatusBadGateway, err.Error())
+
+			return
+		}
+
+		now := time.Now()
+
+		if err := b.sessionStore.save(responseWriter, &session{
+			AccessToken:    tokens.AccessToken,
+			RefreshToken:   tokens.RefreshToken,
+			IDToken:        tokens.IDToken,
+			TokenExpiresAt: now.Add(time.Duration(tokens.ExpiresIn) * time.Second),
+			ExpiresAt:      now.Add(b.config.SessionTTL),
+		}); err != nil {
+			b.logger.Error().Err(err).Msg("Failed to save the session")
+			writeLoginError(responseWriter, req, http.StatusInternalServerError, err.Error())
+
+			return
+		}
+
+		http.Redirect(responseWriter, req, state.ReturnTo, http.StatusFound)
+	})
+}
+
+// LogoutHandler ends the session of the user, and logs them out at the authorization server too if it has a logout
+// endpoint. Otherwise, or without a session, the user is redirected to the post logout redirect URL.
+func (b *BFF) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		session := b.sessionStore.load(req)
+		b.sessionStore.delete(responseWriter, session)
+
+		redirectURL := cmp.Or(b.config.PostLogoutRedirectURL, "/")
+
+		if b.endpoints.Logout != "" && session != nil {
+			query := url.Values{"client_id": {b.config.ClientID}}
+
+			if session.IDToken != "" {
+				query.Set("id_token_hint", session.IDToken)
+			}
+
+			if b.config.PostLogoutRedirectURL != "" {
+				query.Set("post_logout_redirect_uri", b.config.PostLogoutRedirectURL)
+			}
+
+			redirectURL = b.endpoints.Logout + "?" + query.Encode()
+		}
+
+		http.Redirect(responseWriter, req, redirectURL, http.StatusFound)
+	})
+}
+
+func (b *BFF) loadLoginState(req *http.Request, loginCookieName string) (loginState, bool) {
+	var state loginState
+
+	cookie, err := req.Cookie(loginCookieName)
+	if err != nil {
+		return state, false
+	}
+
+	plaintext, err := b.sealer.open(loginCookieName, cookie.Value)
+	if err != nil {
+		return state, false
+	}
+
+	return state, json.Unmarshal(plaintext, &state) == nil
+}
+
+// callbackCookiePath limits the login cookie to the callback path, the only one it is needed at.
+func (b *BFF) callbackCookiePath() string {
+	return b.config.CallbackPath
+}
+
+// localPath returns the path if it is local to the gateway, and the root path otherwise,
+// so that the login can't be abused to redirect users to other sites.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+
+	return path
+}
+
+func writeLoginError(responseWriter http.ResponseWriter, req *http.Request, statusCode int, reason string) {
+	errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+		StatusCode: statusCode,
+		Code:       errorResponse_util.CodeLoginFailed,
+		Message:    "Login failed.",
+		Reason:     reason,
+	})
+}
@@ -0,0 +1,247 @@
+package bff
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCookieSize is the size of the largest cookie browsers are guaranteed to store.
+const maxCookieSize = 4096
+
+var (
+	ErrInvalidSessionKey  = errors.New("session key must be 32 bytes, base64 encoded")
+	ErrUnsealableCookie   = errors.New("cookie can't be decrypted")
+	ErrSessionTooLarge    = errors.New("session is too large for a cookie, use the memory session store")
+	ErrUnknownSessionData = errors.New("session data can't be decoded")
+)
+
+// session holds the tokens of a logged in user. TokenExpiresAt is when the access token expires,
+// while ExpiresAt is when the session itself does, and the user has to log in again.
+type session struct {
+	id string
+
+	AccessToken    string    `json:"accessToken"`
+	RefreshToken   string    `json:"refreshToken,omitempty"`
+	IDToken        string    `json:"idToken,omitempty"`
+	TokenExpiresAt time.Time `json:"tokenExpiresAt"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// sessionStore keeps the sessions of the users, identified by their session cookie. Stores keeping the sessions
+// on the server can also find them by their ID, which tells the latest state of sessions shared by concurrent requests.
+type sessionStore interface {
+	load(req *http.Request) *session
+	find(id string) *session
+	save(responseWriter http.ResponseWriter, session *session) error
+	delete(responseWriter http.ResponseWriter, session *session)
+}
+
+// sealer encrypts and authenticates cookie values with AES-GCM. The name of the cookie is authenticated along with
+// the value, so that a value sealed for one cookie can't be passed off as the value of another.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(key []byte) (*sealer, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidSessionKey
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the cookie encryption: %w", err)
+	}
+
+	return &sealer{aead: aead}, nil
+}
+
+func (s *sealer) seal(name string, plaintext []byte) string {
+	nonce := make([]byte, s.aead.NonceSize())
+	_, _ = rand.Read(nonce)
+
+	return base64.RawURLEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plaintext, []byte(name)))
+}
+
+func (s *sealer) open(name, sealed string) ([]byte, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(ciphertext) < s.aead.NonceSize() {
+		return nil, ErrUnsealableCookie
+	}
+
+	nonce, ciphertext := ciphertext[:s.aead.NonceSize()], ciphertext[s.aead.NonceSize():]
+
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, ErrUnsealableCookie
+	}
+
+	return plaintext, nil
+}
+
+// newCookie builds a cookie the browser keeps until it expires, and only sends to the gateway with top-level
+// navigations and same-site requests. A zero expiry deletes the cookie.
+func newCookie(name, value, path string, expiresAt time.Time, secure bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if expiresAt.IsZero() {
+		cookie.MaxAge = -1
+	}
+
+	return cookie
+}
+
+// cookieSessionStore keeps each session encrypted in the session cookie itself.
+type cookieSessionStore struct {
+	sealer     *sealer
+	cookieName string
+	secure     bool
+}
+
+func (c *cookieSessionStore) load(req *http.Request) *session {
+	cookie, err := req.Cookie(c.cookieName)
+	if err != nil {
+		return nil
+	}
+
+	plaintext, err := c.sealer.open(c.cookieName, cookie.Value)
+	if err != nil {
+		return nil
+	}
+
+	var storedSession session
+	if err := json.Unmarshal(plaintext, &storedSession); err != nil || !time.Now().Before(storedSession.ExpiresAt) {
+		return nil
+	}
+
+	return &storedSession
+}
+
+// find never finds a session, as the sessions are only kept in the cookies of the browsers.
+func (c *cookieSessionStore) find(_ string) *session {
+	return nil
+}
+
+func (c *cookieSessionStore) save(responseWriter http.ResponseWriter, session *session) error {
+	plaintext, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnknownSessionData, err)
+	}
+
+	cookie := newCookie(c.cookieName, c.sealer.seal(c.cookieName, plaintext), "/", session.ExpiresAt, c.secure)
+	if len(cookie.String()) > maxCookieSize {
+		return ErrSessionTooLarge
+	}
+
+	http.SetCookie(responseWriter, cookie)
+
+	return nil
+}
+
+func (c *cookieSessionStore) delete(responseWriter http.ResponseWriter, _ *session) {
+	http.SetCookie(responseWriter, newCookie(c.cookieName, "", "/", time.Time{}, c.secure))
+}
+
+// memorySessionStore keeps the sessions in memory, identified by the random ID in the session cookie.
+// Sessions are lost when the gateway restarts, and aren't shared between its instances.
+type memorySessionStore struct {
+	mutex      sync.Mutex
+	sessions   map[string]session
+	cookieName string
+	secure     bool
+}
+
+func newMemorySessionStore(cookieName string, secure bool) *memorySessionStore {
+	return &memorySessionStore{
+		sessions:   make(map[string]session),
+		cookieName: cookieName,
+		secure:     secure,
+	}
+}
+
+func (m *memorySessionStore) load(req *http.Request) *session {
+	cookie, err := req.Cookie(m.cookieName)
+	if err != nil {
+		return nil
+	}
+
+	return m.find(cookie.Value)
+}
+
+func (m *memorySessionStore) find(id string) *session {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storedSession, isStored := m.sessions[id]
+	if !isStored {
+		return nil
+	}
+
+	if !time.Now().Before(storedSession.ExpiresAt) {
+		delete(m.sessions, id)
+
+		return nil
+	}
+
+	storedSession.id = id
+
+	return &storedSession
+}
+
+// save stores the session, under a new ID if it hasn't been stored before. Expired sessions are removed
+// whenever a new session is stored, so that sessions abandoned by their users don't pile up.
+func (m *memorySessionStore) save(responseWriter http.ResponseWriter, session *session) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if session.id == "" {
+		session.id = randomString()
+
+		now := time.Now()
+		for id, storedSession := range m.sessions {
+			if !now.Before(storedSession.ExpiresAt) {
+				delete(m.sessions, id)
+			}
+		}
+
+		http.SetCookie(responseWriter, newCookie(m.cookieName, session.id, "/", session.ExpiresAt, m.secure))
+	}
+
+	m.sessions[session.id] = *session
+
+	return nil
+}
+
+func (m *memorySessionStore) delete(responseWriter http.ResponseWriter, session *session) {
+	if session != nil {
+		m.mutex.Lock()
+		delete(m.sessions, session.id)
+		m.mutex.Unlock()
+	}
+
+	http.SetCookie(responseWriter, newCookie(m.cookieName, "", "/", time.Time{}, m.secure))
+}
+
+// randomString returns 32 random bytes, base64url encoded.
+func randomString() string {
+	randomBytes := make([]byte, 32)
+	_, _ = rand.Read(randomBytes)
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
@@ -0,0 +1,110 @@
+package bff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDiscoveryFailed    = errors.New("failed to discover the endpoints of the authorization server")
+	ErrTokenRequestFailed = errors.New("token request failed")
+	ErrGrantRejected      = errors.New("grant rejected by the authorization server")
+)
+
+// endpoints of the authorization server taking part in logging users in and out.
+type endpoints struct {
+	Authorization string
+	Token         string
+	Logout        string
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type tokenErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// requestTokens posts a token request of the grant in the form to the token endpoint,
+// authenticating with the client secret if the gateway has one.
+func (b *BFF) requestTokens(ctx context.Context, form url.Values) (*tokenResponse, error) {
+	form.Set("client_id", b.config.ClientID)
+
+	if b.clientSecret != "" {
+		form.Set("client_secret", b.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoints.Token, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenRequestFailed, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenRequestFailed, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+		var errorResponse tokenErrorResponse
+		_ = json.Unmarshal(body, &errorResponse)
+
+		if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized ||
+			errorResponse.Error == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %w: HTTP %d: %s", ErrTokenRequestFailed, ErrGrantRejected, res.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		return nil, fmt.Errorf("%w: HTTP %d: %s", ErrTokenRequestFailed, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenRequestFailed, err)
+	}
+
+	if tokens.AccessToken == "" {
+		return nil, fmt.Errorf("%w: no access token in the response", ErrTokenRequestFailed)
+	}
+
+	return &tokens, nil
+}
+
+// refresh replaces the access token of the session with one obtained with its refresh token.
+// The refresh token is replaced too if the authorization server rotates it.
+func (b *BFF) refresh(ctx context.Context, session *session) error {
+	tokens, err := b.requestTokens(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {session.RefreshToken},
+	})
+	if err != nil {
+		return err
+	}
+
+	session.AccessToken = tokens.AccessToken
+	session.TokenExpiresAt = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+
+	if tokens.RefreshToken != "" {
+		session.RefreshToken = tokens.RefreshToken
+	}
+
+	if tokens.IDToken != "" {
+		session.IDToken = tokens.IDToken
+	}
+
+	return nil
+}
@@ -0,0 +1,453 @@
+package bff_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/bff"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	clientID          = "test-client"
+	clientSecret      = "test-secret"
+	sessionKeyEnv     = "TEST_BFF_SESSION_KEY"
+	authorizationCode = "test-code"
+	cookieName        = "gateway_session"
+)
+
+// authorizationServer stands in for Auth0, issuing tokens for the authorization code it hands out
+// once the PKCE code verifier matches the challenge of the login. Refresh tokens are rotated on every refresh.
+type authorizationServer struct {
+	*httptest.Server
+
+	mutex         sync.Mutex
+	codeChallenge string
+	refreshToken  string
+	expiresIn     int64
+	refreshes     int
+	refreshDelay  time.Duration
+	rejectRefresh bool
+	failRefresh   bool
+}
+
+func newAuthorizationServer() *authorizationServer {
+	authorizationServer := &authorizationServer{expiresIn: 3600, refreshToken: "refresh-token-0"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(responseWriter http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(responseWriter).Encode(map[string]string{
+			"issuer":                 authorizationServer.URL + "/",
+			"authorization_endpoint": authorizationServer.URL + "/authorize",
+			"token_endpoint":         authorizationServer.URL + "/oauth/token",
+			"end_session_endpoint":   authorizationServer.URL + "/oidc/logout",
+		})
+	})
+	mux.HandleFunc("/oauth/token", authorizationServer.serveToken)
+
+	authorizationServer.Server = httptest.NewServer(mux)
+
+	return authorizationServer
+}
+
+func (a *authorizationServer) serveToken(responseWriter http.ResponseWriter, req *http.Request) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if req.PostFormValue("client_id") != clientID || req.PostFormValue("client_secret") != clientSecret {
+		http.Error(responseWriter, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+
+		return
+	}
+
+	accessToken := "access-token-0"
+
+	switch req.PostFormValue("grant_type") {
+	case "authorization_code":
+		codeChallenge := sha256.Sum256([]byte(req.PostFormValue("code_verifier")))
+
+		if req.PostFormValue("code") != authorizationCode ||
+			base64.RawURLEncoding.EncodeToString(codeChallenge[:]) != a.codeChallenge {
+			http.Error(responseWriter, `{"error":"invalid_grant"}`, http.StatusForbidden)
+
+			return
+		}
+	case "refresh_token":
+		time.Sleep(a.refreshDelay)
+
+		if a.failRefresh {
+			http.Error(responseWriter, `{"error":"temporarily_unavailable"}`, http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if a.rejectRefresh || req.PostFormValue("refresh_token") != a.refreshToken {
+			http.Error(responseWriter, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+
+			return
+		}
+
+		a.refreshes++
+		accessToken = "access-token-" + string(rune('0'+a.refreshes))
+		a.refreshToken = "refresh-token-" + string(rune('0'+a.refreshes))
+	default:
+		http.Error(responseWriter, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+
+		return
+	}
+
+	_ = json.NewEncoder(responseWriter).Encode(map[string]any{
+		"access_token":  accessToken,
+		"refresh_token": a.refreshToken,
+		"id_token":      "id-token",
+		"expires_in":    a.expiresIn,
+	})
+}
+
+func newBFFParams(authorizationServer *authorizationServer, sessionStore string) middleware.BFFParams {
+	return middleware.BFFParams{
+		Auth0Config: &auth0_config.Config{
+			IssuerConfig: auth0_config.IssuerConfig{
+				IssuerURL: authorizationServer.URL + "/",
+				Audience:  "https://api.example.com",
+			},
+			BFF: &auth0_config.BFFConfig{
+				ClientID:              clientID,
+				ClientSecretEnv:       "TEST_BFF_CLIENT_SECRET",
+				RedirectURL:           "https://gateway.example.com/callback",
+				CallbackPath:          "/callback",
+				PostLogoutRedirectURL: "https://gateway.example.com/",
+				SessionStore:          sessionStore,
+				SessionKeyEnv:         sessionKeyEnv,
+				SessionTTL:            time.Hour,
+				RefreshBefore:         time.Minute,
+				CookieName:            cookieName,
+			},
+		},
+		Logger: zerolog.Nop(),
+	}
+}
+
+// login starts a login with the BFF, and returns the cookies it sets along with the authorize query it redirects to.
+func login(bff middleware.IBFF, path string) ([]*http.Cookie, url.Values) {
+	recorder := httptest.NewRecorder()
+	bff.LoginHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	So(recorder.Code, ShouldEqual, http.StatusFound)
+
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	So(err, ShouldBeNil)
+
+	return recorder.Result().Cookies(), location.Query()
+}
+
+func serve(handler http.Handler, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	errorResponse_util.WithVerboseReasons(true)(handler).ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func Test_NewMiddleware(t *testing.T) {
+	Convey("When creating a new backend for frontends", t, func() {
+		os.Setenv(sessionKeyEnv, base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+		os.Setenv("TEST_BFF_CLIENT_SECRET", clientSecret)
+
+		authorizationServer := newAuthorizationServer()
+		defer authorizationServer.Close()
+
+		Convey("Without a BFF config", func() {
+			bff, err := middleware.NewMiddleware(middleware.BFFParams{Auth0Config: &auth0_config.Config{}, Logger: zerolog.Nop()})
+			So(err, ShouldBeNil)
+
+			Convey("Should let requests through as they are", func() {
+				var authorization string
+
+				serve(bff.Handler(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+					authorization = req.Header.Get("Authorization")
+				})), "/api", []*http.Cookie{{Name: cookieName, Value: "session"}})
+
+				So(authorization, ShouldBeEmpty)
+			})
+		})
+
+		Convey("With an invalid BFF config", func() {
+			params := newBFFParams(authorizationServer, auth0_config.SessionStoreCookie)
+
+			Convey("Should fail without a client ID", func() {
+				params.Auth0Config.BFF.ClientID = ""
+
+				_, err := middleware.NewMiddleware(params)
+				So(err, ShouldWrap, middleware.ErrMissingClientID)
+			})
+
+			Convey("Should fail for the cookie session store without a session key", func() {
+				params.Auth0Config.BFF.SessionKeyEnv = ""
+
+				_, err := middleware.NewMiddleware(params)
+				So(err, ShouldWrap, middleware.ErrMissingSessionKey)
+			})
+
+			Convey("Should fail for an unknown session store", func() {
+				params.Auth0Config.BFF.SessionStore = "redis"
+
+				_, err := middleware.NewMiddleware(params)
+				So(err, ShouldWrap, middleware.ErrUnknownSessionStore)
+			})
+
+			Convey("Should fail when the endpoints can't be discovered", func() {
+				params.Auth0Config.IssuerConfig.IssuerURL = authorizationServer.URL + "/unknown/"
+
+				_, err := middleware.NewMiddleware(params)
+				So(err, ShouldWrap, middleware.ErrDiscoveryFailed)
+			})
+
+			Convey("Should fail when the discovered configuration names another issuer", func() {
+				params.Auth0Config.IssuerConfig.IssuerURL = authorizationServer.URL
+
+				_, err := middleware.NewMiddleware(params)
+				So(err, ShouldWrap, auth0_middleware.ErrDiscoveryIssuerMismatch)
+			})
+		})
+
+		for _, sessionStore := range []string{auth0_config.SessionStoreCookie, auth0_config.SessionStoreMemory} {
+			Convey("With the "+sessionStore+" session store", func() {
+				bff, err := middleware.NewMiddleware(newBFFParams(authorizationServer, sessionStore))
+				So(err, ShouldBeNil)
+
+				var proxiedRequest *http.Request
+
+				proxyHandler := bff.Handler(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+					proxiedRequest = req
+				}))
+
+				loginCookies, authorizeQuery := login(bff, "/login?returnTo=/app/settings")
+				authorizationServer.codeChallenge = authorizeQuery.Get("code_challenge")
+
+				Convey("Should redirect to the authorization server with a PKCE challenge", func() {
+					So(authorizeQuery.Get("response_type"), ShouldEqual, "code")
+					So(authorizeQuery.Get("client_id"), ShouldEqual, clientID)
+					So(authorizeQuery.Get("redirect_uri"), ShouldEqual, "https://gateway.example.com/callback")
+					So(authorizeQuery.Get("scope"), ShouldEqual, "openid profile offline_access")
+					So(authorizeQuery.Get("audience"), ShouldEqual, "https://api.example.com")
+					So(authorizeQuery.Get("code_challenge_method"), ShouldEqual, "S256")
+					So(authorizeQuery.Get("code_challenge"), ShouldNotBeEmpty)
+					So(authorizeQuery.Get("state"), ShouldNotBeEmpty)
+				})
+
+				Convey("Should reject callbacks with a mismatched state", func() {
+					recorder := serve(bff.CallbackHandler(), "/callback?code="+authorizationCode+"&state=forged", loginCookies)
+					So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+					So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeLoginFailed)
+				})
+
+				Convey("Should reject callbacks without a login in progress", func() {
+					recorder := serve(bff.CallbackHandler(), "/callback?code="+authorizationCode+"&state="+authorizeQuery.Get("state"), nil)
+					So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+				})
+
+				Convey("Should fail the login when the code can't be exchanged", func() {
+					recorder := serve(bff.CallbackHandler(), "/callback?code=stolen&state="+authorizeQuery.Get("state"), loginCookies)
+					So(recorder.Code, ShouldEqual, http.StatusBadGateway)
+					So(recorder.Body.String(), ShouldContainSubstring, "invalid_grant")
+				})
+
+				Convey("After completing the login", func() {
+					callbackRecorder := serve(bff.CallbackHandler(), "/callback?code="+authorizationCode+"&state="+authorizeQuery.Get("state"), loginCookies)
+					So(callbackRecorder.Code, ShouldEqual, http.StatusFound)
+					So(callbackRecorder.Header().Get("Location"), ShouldEqual, "/app/settings")
+
+					var sessionCookies []*http.Cookie
+
+					for _, cookie := range callbackRecorder.Result().Cookies() {
+						if cookie.Name == cookieName {
+							So(cookie.HttpOnly, ShouldBeTrue)
+							sessionCookies = append(sessionCookies, cookie)
+						}
+					}
+
+					So(sessionCookies, ShouldHaveLength, 1)
+
+					Convey("Should attach the access token and strip the session cookie when proxying", func() {
+						serve(proxyHandler, "/api", append(sessionCookies, &http.Cookie{Name: "theme", Value: "dark"}))
+						So(proxiedRequest.Header.Get("Authorization"), ShouldEqual, "Bearer access-token-0")
+
+						_, err := proxiedRequest.Cookie(cookieName)
+						So(err, ShouldEqual, http.ErrNoCookie)
+
+						themeCookie, err := proxiedRequest.Cookie("theme")
+						So(err, ShouldBeNil)
+						So(themeCookie.Value, ShouldEqual, "dark")
+					})
+
+					Convey("Should refresh the access token before it expires", func() {
+						authorizationServer.expiresIn = 30
+
+						loginCookies, authorizeQuery := login(bff, "/login")
+						authorizationServer.codeChallenge = authorizeQuery.Get("code_challenge")
+
+						callbackRecorder := serve(bff.CallbackHandler(), "/callback?code="+authorizationCode+"&state="+authorizeQuery.Get("state"), loginCookies)
+						authorizationServer.expiresIn = 3600
+
+						recorder := serve(proxyHandler, "/api", callbackRecorder.Result().Cookies())
+						So(proxiedRequest.Header.Get("Authorization"), ShouldEqual, "Bearer access-token-1")
+						So(authorizationServer.refreshes, ShouldEqual, 1)
+
+						refreshedCookies := callbackRecorder.Result().Cookies()
+						if sessionStore == auth0_config.SessionStoreCookie {
+							refreshedCookies = recorder.Result().Cookies()
+						}
+
+						serve(proxyHandler, "/api", refreshedCookies)
+						So(proxiedRequest.Header.Get("Authorization"), ShouldEqual, "Bearer access-token-1")
+						So(authorizationServer.refreshes, ShouldEqual, 1)
+					})
+
+					Convey("With an access token about to expire", func() {
+						authorizationServer.expiresIn = 30
+
+						loginCookies, authorizeQuery := login(bff, "/login")
+						authorizationServer.codeChallenge = authorizeQuery.Get("code_challenge")
+
+						expiringCookies := serve(bff.CallbackHandler(), "/callback?code="+authorizationCode+"&state="+authorizeQuery.Get("state"), loginCookies).Result().Cookies()
+						authorizationServer.expiresIn = 3600
+
+						Convey("Should keep the session when the authorization server fails to refresh it", func() {
+							authorizationServer.failRefresh = true
+							proxiedRequest = nil
+
+							recorder := serve(proxyHandler, "/api", expiringCookies)
+							So(recorder.Code, ShouldEqual, http.StatusBadGateway)
+							So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeSessionRefreshFailed)
+							So(recorder.Result().Cookies(), ShouldBeEmpty)
+							So(proxiedRequest, ShouldBeNil)
+
+							authorizationServer.failRefresh = false
+
+							serve(proxyHandler, "/api", expiringCookies)
+							So(proxiedRequest.Header.Get("Authorization"), ShouldEqual, "Bearer access-token-1")
+						})
+
+						Convey("Should end the session when the authorization server rejects its refresh token", func() {
+							authorizationServer.rejectRefresh = true
+
+							recorder := serve(proxyHandler, "/api", expiringCookies)
+							So(proxiedRequest.Header.Get("Authorization"), ShouldBeEmpty)
+							So(recorder.Result().Cookies(), ShouldHaveLength, 1)
+							So(recorder.Result().Cookies()[0].MaxAge, ShouldBeLessThan, 0)
+
+							authorizationServer.rejectRefresh = false
+
+							if sessionStore == auth0_config.SessionStoreMemory {
+								serve(proxyHandler, "/api", expiringCookies)
+								So(proxiedRequest.Header.Get("Authorization"), ShouldBeEmpty)
+								So(authorizationServer.refreshes, ShouldEqual, 0)
+							}
+						})
+
+						Convey("Should refresh the access token only once for concurrent requests", func() {
+							authorizationServer.refreshDelay = 50 * time.Millisecond
+
+							var (
+								waitGroup     sync.WaitGroup
+								headersMutex  sync.Mutex
+								authorization []string
+								recorders     [3]*httptest.ResponseRecorder
+							)
+
+							concurrentHandler := bff.Handler(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+								headersMutex.Lock()
+								authorization = append(authorization, req.Header.Get("Authorization"))
+								headersMutex.Unlock()
+							}))
+
+							for i := range recorders {
+								waitGroup.Add(1)
+
+								recorders[i] = httptest.NewRecorder()
+
+								go func() {
+									defer waitGroup.Done()
+
+									req := httptest.NewRequest(http.MethodGet, "/api", nil)
+									for _, cookie := range expiringCookies {
+										req.AddCookie(cookie)
+									}
+
+									concurrentHandler.ServeHTTP(recorders[i], req)
+								}()
+							}
+
+							waitGroup.Wait()
+							authorizationServer.refreshDelay = 0
+
+							So(authorization, ShouldResemble, []string{"Bearer access-token-1", "Bearer access-token-1", "Bearer access-token-1"})
+							So(authorizationServer.refreshes, ShouldEqual, 1)
+
+							if sessionStore == auth0_config.SessionStoreCookie {
+								for _, recorder := range recorders {
+									So(recorder.Result().Cookies(), ShouldHaveLength, 1)
+								}
+
+								expiringCookies = recorders[2].Result().Cookies()
+							}
+
+							serve(proxyHandler, "/api", expiringCookies)
+							So(proxiedRequest.Header.Get("Authorization"), ShouldEqual, "Bearer access-token-1")
+							So(authorizationServer.refreshes, ShouldEqual, 1)
+						})
+					})
+
+					Convey("Should end the session at logout", func() {
+						logoutRecorder := serve(bff.LogoutHandler(), "/logout", sessionCookies)
+						So(logoutRecorder.Code, ShouldEqual, http.StatusFound)
+
+						location, err := url.Parse(logoutRecorder.Header().Get("Location"))
+						So(err, ShouldBeNil)
+						So(location.Path, ShouldEqual, "/oidc/logout")
+						So(location.Query().Get("id_token_hint"), ShouldEqual, "id-token")
+						So(location.Query().Get("post_logout_redirect_uri"), ShouldEqual, "https://gateway.example.com/")
+
+						clearedCookies := logoutRecorder.Result().Cookies()
+						So(clearedCookies, ShouldHaveLength, 1)
+						So(clearedCookies[0].MaxAge, ShouldBeLessThan, 0)
+
+						if sessionStore == auth0_config.SessionStoreMemory {
+							serve(proxyHandler, "/api", sessionCookies)
+							So(proxiedRequest.Header.Get("Authorization"), ShouldBeEmpty)
+						}
+					})
+				})
+			})
+		}
+
+		Convey("With a return path leading off the gateway", func() {
+			bff, err := middleware.NewMiddleware(newBFFParams(authorizationServer, auth0_config.SessionStoreMemory))
+			So(err, ShouldBeNil)
+
+			loginCookies, authorizeQuery := login(bff, "/login?returnTo=//evil.example.com")
+			authorizationServer.codeChallenge = authorizeQuery.Get("code_challenge")
+
+			Convey("Should return to the root path after logging in", func() {
+				recorder := serve(bff.CallbackHandler(), "/callback?code="+authorizationCode+"&state="+authorizeQuery.Get("state"), loginCookies)
+				So(recorder.Header().Get("Location"), ShouldEqual, "/")
+			})
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package claimHeaders
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+)
+
+// IClaimHeaders interface defines the method to get the claim headers middleware handler.
+type IClaimHeaders interface {
+	Handler() mux.MiddlewareFunc
+}
+
+// ClaimHeaders implements the IClaimHeaders interface and provides the claim headers middleware handler.
+type ClaimHeaders struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+// Handler returns the claim headers middleware function.
+func (c *ClaimHeaders) Handler() mux.MiddlewareFunc {
+	return c.middlewareFunc
+}
+
+// buildClaimHeadersMiddlewareFunc builds the middleware forwarding the validated claims to the upstream.
+func buildClaimHeadersMiddlewareFunc(config subrouter_config.ClaimHeadersConfig) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			for _, claimHeader := range config.Headers {
+				req.Header.Del(claimHeader.Name)
+			}
+
+			validatedClaims, isValidatedClaim := req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+			if isValidatedClaim {
+				claims := auth0_middleware.CollectClaims(validatedClaims)
+
+				for _, claimHeader := range config.Headers {
+					if headerValue, isPresent := claimToHeaderValue(claims[claimHeader.Claim]); isPresent {
+						req.Header.Set(claimHeader.Name, headerValue)
+					}
+				}
+			}
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// claimToHeaderValue formats a claim as a header value, joining arrays with commas and encoding objects as JSON.
+func claimToHeaderValue(claim any) (string, bool) {
+	var headerValue string
+
+	switch typedClaim := claim.(type) {
+	case nil:
+		return "", false
+	case string:
+		headerValue = typedClaim
+	case bool:
+		headerValue = strconv.FormatBool(typedClaim)
+	case float64:
+		headerValue = strconv.FormatFloat(typedClaim, 'f', -1, 64)
+	case []any:
+		elements := make([]string, 0, len(typedClaim))
+
+		for _, element := range typedClaim {
+			if elementValue, isPresent := claimToHeaderValue(element); isPresent {
+				elements = append(elements, elementValue)
+			}
+		}
+
+		headerValue = strings.Join(elements, ",")
+	default:
+		encodedClaim, err := json.Marshal(typedClaim)
+		if err != nil {
+			return "", false
+		}
+
+		headerValue = string(encodedClaim)
+	}
+
+	if headerValue == "" || strings.ContainsAny(headerValue, "\r\n\x00") {
+		return "", false
+	}
+
+	return headerValue, true
+}
@@ -0,0 +1,25 @@
+package claimHeaders
+
+import (
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+// IClaimHeadersFactory interface for creating claim headers middleware for subrouters.
+type IClaimHeadersFactory interface {
+	NewClaimHeaders(config subrouter_config.ClaimHeadersConfig) IClaimHeaders
+}
+
+// ClaimHeadersFactory implements the IClaimHeadersFactory interface to create claim headers middleware.
+type ClaimHeadersFactory struct{}
+
+// NewClaimHeadersFactory creates a new claim headers middleware factory.
+func NewClaimHeadersFactory() IClaimHeadersFactory {
+	return &ClaimHeadersFactory{}
+}
+
+// NewClaimHeaders creates a new claim headers middleware based on the provided configuration.
+func (c *ClaimHeadersFactory) NewClaimHeaders(config subrouter_config.ClaimHeadersConfig) IClaimHeaders {
+	return &ClaimHeaders{
+		middlewareFunc: buildClaimHeadersMiddlewareFunc(config),
+	}
+}
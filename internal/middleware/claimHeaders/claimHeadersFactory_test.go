@@ -0,0 +1,40 @@
+package claimHeaders_test
+
+import (
+	"testing"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/claimHeaders"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NewClaimHeadersFactory(t *testing.T) {
+	Convey("When creating a new claim headers factory", t, func() {
+		factory := middleware.NewClaimHeadersFactory()
+		So(factory, ShouldNotBeNil)
+		So(factory, ShouldImplement, (*middleware.IClaimHeadersFactory)(nil))
+	})
+}
+
+func Test_ClaimHeadersFactory_NewClaimHeaders(t *testing.T) {
+	Convey("When creating claim headers middleware", t, func() {
+		factory := middleware.NewClaimHeadersFactory()
+
+		Convey("With headers configured", func() {
+			claimHeaders := factory.NewClaimHeaders(subrouter_config.ClaimHeadersConfig{
+				Headers: []subrouter_config.ClaimHeaderConfig{
+					{Name: "X-User-Id", Claim: "sub"},
+				},
+			})
+			So(claimHeaders, ShouldNotBeNil)
+			So(claimHeaders, ShouldImplement, (*middleware.IClaimHeaders)(nil))
+			So(claimHeaders.Handler(), ShouldNotBeNil)
+		})
+
+		Convey("With no headers configured", func() {
+			claimHeaders := factory.NewClaimHeaders(subrouter_config.ClaimHeadersConfig{})
+			So(claimHeaders, ShouldNotBeNil)
+			So(claimHeaders.Handler(), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,114 @@
+package claimHeaders_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/claimHeaders"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_ClaimHeaders_Handler(t *testing.T) {
+	Convey("When using the claim headers handler", t, func() {
+		factory := middleware.NewClaimHeadersFactory()
+
+		claimHeaders := factory.NewClaimHeaders(subrouter_config.ClaimHeadersConfig{
+			Headers: []subrouter_config.ClaimHeaderConfig{
+				{Name: "X-User-Id", Claim: "sub"},
+				{Name: "X-Scopes", Claim: "scope"},
+				{Name: "X-Org-Id", Claim: "org_id"},
+				{Name: "X-Roles", Claim: "https://example.com/roles"},
+				{Name: "X-Email-Verified", Claim: "email_verified"},
+				{Name: "X-Address", Claim: "address"},
+				{Name: "X-Missing", Claim: "missing"},
+			},
+		})
+
+		var forwardedHeaders http.Header
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			forwardedHeaders = req.Header.Clone()
+
+			responseWriter.WriteHeader(http.StatusOK)
+		})
+
+		wrappedHandler := claimHeaders.Handler()(testHandler)
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-User-Id", "spoofed-user")
+		req.Header.Set("X-Missing", "spoofed-value")
+		req.Header.Set("X-Other", "kept")
+
+		Convey("Should forward the validated claims as headers", func() {
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				RegisteredClaims: jwtvalidator.RegisteredClaims{
+					Subject: "auth0|1234567890",
+				},
+				CustomClaims: &auth0_middleware.CustomAuth0Claims{
+					Scope: "read:all write:all",
+					Claims: map[string]any{
+						"sub":                       "auth0|1234567890",
+						"scope":                     "read:all write:all",
+						"org_id":                    "org_acme",
+						"https://example.com/roles": []any{"admin", "user"},
+						"email_verified":            true,
+						"address":                   map[string]any{"country": "HU"},
+					},
+				},
+			}))
+
+			wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+			So(forwardedHeaders.Get("X-User-Id"), ShouldEqual, "auth0|1234567890")
+			So(forwardedHeaders.Get("X-Scopes"), ShouldEqual, "read:all write:all")
+			So(forwardedHeaders.Get("X-Org-Id"), ShouldEqual, "org_acme")
+			So(forwardedHeaders.Get("X-Roles"), ShouldEqual, "admin,user")
+			So(forwardedHeaders.Get("X-Email-Verified"), ShouldEqual, "true")
+			So(forwardedHeaders.Get("X-Address"), ShouldEqual, `{"country":"HU"}`)
+			So(forwardedHeaders.Values("X-Missing"), ShouldBeEmpty)
+			So(forwardedHeaders.Get("X-Other"), ShouldEqual, "kept")
+		})
+
+		Convey("Should forward the registered claims without custom claims", func() {
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				RegisteredClaims: jwtvalidator.RegisteredClaims{
+					Subject: "client@clients",
+				},
+			}))
+
+			wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+			So(forwardedHeaders.Get("X-User-Id"), ShouldEqual, "client@clients")
+			So(forwardedHeaders.Values("X-Scopes"), ShouldBeEmpty)
+		})
+
+		Convey("Should strip the client-supplied headers of anonymous requests", func() {
+			wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+			So(forwardedHeaders.Values("X-User-Id"), ShouldBeEmpty)
+			So(forwardedHeaders.Values("X-Missing"), ShouldBeEmpty)
+			So(forwardedHeaders.Get("X-Other"), ShouldEqual, "kept")
+		})
+
+		Convey("Should skip claims that can't be sent as a header", func() {
+			req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: &auth0_middleware.CustomAuth0Claims{
+					Claims: map[string]any{
+						"org_id": "org_acme\r\nX-Injected: true",
+					},
+				},
+			}))
+
+			wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+			So(forwardedHeaders.Values("X-Org-Id"), ShouldBeEmpty)
+			So(forwardedHeaders.Values("X-User-Id"), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,119 @@
+package clientCert
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+// IClientCert interface defines the method to get the client certificate middleware handler.
+type IClientCert interface {
+	Handler() mux.MiddlewareFunc
+}
+
+// ClientCert implements the IClientCert interface and provides the client certificate middleware handler.
+type ClientCert struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+// Handler returns the client certificate middleware function.
+func (c *ClientCert) Handler() mux.MiddlewareFunc {
+	return c.middlewareFunc
+}
+
+// buildClientCertMiddlewareFunc builds the middleware requiring a verified client certificate and forwarding its identity.
+func buildClientCertMiddlewareFunc(config subrouter_config.ClientCertConfig) mux.MiddlewareFunc {
+	identityHeaders := []string{config.CommonNameHeader, config.SANsHeader, config.FingerprintHeader}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			for _, identityHeader := range identityHeaders {
+				if identityHeader != "" {
+					req.Header.Del(identityHeader)
+				}
+			}
+
+			if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusUnauthorized,
+					Code:       errorResponse_util.CodeClientCertRequired,
+					Message:    "A verified client certificate is required.",
+				})
+
+				return
+			}
+
+			certificate := req.TLS.VerifiedChains[0][0]
+			subjectAlternativeNames := collectSubjectAlternativeNames(certificate)
+
+			if !isAllowed(config, certificate.Subject.CommonName, subjectAlternativeNames) {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusForbidden,
+					Code:       errorResponse_util.CodeClientCertNotAllowed,
+					Message:    "Client certificate is not allowed.",
+				})
+
+				return
+			}
+
+			setIdentityHeader(req, config.CommonNameHeader, certificate.Subject.CommonName)
+			setIdentityHeader(req, config.SANsHeader, strings.Join(subjectAlternativeNames, ","))
+			setIdentityHeader(req, config.FingerprintHeader, fingerprint(certificate))
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// isAllowed tells whether the certificate matches the allowlists.
+func isAllowed(config subrouter_config.ClientCertConfig, commonName string, subjectAlternativeNames []string) bool {
+	if len(config.AllowedCommonNames) == 0 && len(config.AllowedSANs) == 0 {
+		return true
+	}
+
+	if commonName != "" && slices.Contains(config.AllowedCommonNames, commonName) {
+		return true
+	}
+
+	return slices.ContainsFunc(subjectAlternativeNames, func(subjectAlternativeName string) bool {
+		return slices.Contains(config.AllowedSANs, subjectAlternativeName)
+	})
+}
+
+// collectSubjectAlternativeNames lists the DNS, email, IP and URI subject alternative names of the certificate.
+func collectSubjectAlternativeNames(certificate *x509.Certificate) []string {
+	subjectAlternativeNames := make([]string, 0, len(certificate.DNSNames)+len(certificate.EmailAddresses)+len(certificate.IPAddresses)+len(certificate.URIs))
+
+	subjectAlternativeNames = append(subjectAlternativeNames, certificate.DNSNames...)
+	subjectAlternativeNames = append(subjectAlternativeNames, certificate.EmailAddresses...)
+
+	for _, ipAddress := range certificate.IPAddresses {
+		subjectAlternativeNames = append(subjectAlternativeNames, ipAddress.String())
+	}
+
+	for _, uri := range certificate.URIs {
+		subjectAlternativeNames = append(subjectAlternativeNames, uri.String())
+	}
+
+	return subjectAlternativeNames
+}
+
+// fingerprint returns the hex encoded SHA-256 digest of the DER encoded certificate.
+func fingerprint(certificate *x509.Certificate) string {
+	digest := sha256.Sum256(certificate.Raw)
+
+	return hex.EncodeToString(digest[:])
+}
+
+func setIdentityHeader(req *http.Request, name, value string) {
+	if name != "" && value != "" {
+		req.Header.Set(name, value)
+	}
+}
@@ -0,0 +1,25 @@
+package clientCert
+
+import (
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+)
+
+// Factory interface for creating client certificate middleware for subrouters.
+type IClientCertFactory interface {
+	NewClientCert(config subrouter_config.ClientCertConfig) IClientCert
+}
+
+// ClientCertFactory implements the IClientCertFactory interface to create client certificate middleware.
+type ClientCertFactory struct{}
+
+// NewClientCertFactory creates a new client certificate middleware factory.
+func NewClientCertFactory() IClientCertFactory {
+	return &ClientCertFactory{}
+}
+
+// NewClientCert creates a new client certificate middleware based on the provided configuration.
+func (c *ClientCertFactory) NewClientCert(config subrouter_config.ClientCertConfig) IClientCert {
+	return &ClientCert{
+		middlewareFunc: buildClientCertMiddlewareFunc(config),
+	}
+}
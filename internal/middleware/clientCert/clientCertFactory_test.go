@@ -0,0 +1,23 @@
+package clientCert_test
+
+import (
+	"testing"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/clientCert"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NewClientCertFactory(t *testing.T) {
+	Convey("When creating a new client certificate factory", t, func() {
+		factory := middleware.NewClientCertFactory()
+		So(factory, ShouldNotBeNil)
+		So(factory, ShouldImplement, (*middleware.IClientCertFactory)(nil))
+
+		Convey("Should create a client certificate middleware", func() {
+			clientCert := factory.NewClientCert(subrouter_config.ClientCertConfig{})
+			So(clientCert, ShouldImplement, (*middleware.IClientCert)(nil))
+			So(clientCert.Handler(), ShouldNotBeNil)
+		})
+	})
+}
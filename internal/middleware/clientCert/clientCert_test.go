@@ -0,0 +1,112 @@
+package clientCert_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/clientCert"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_ClientCert_Handler(t *testing.T) {
+	Convey("When using the client certificate handler", t, func() {
+		var (
+			factory = middleware.NewClientCertFactory()
+			config  = subrouter_config.ClientCertConfig{
+				CommonNameHeader:  "X-Client-Cert-CN",
+				SANsHeader:        "X-Client-Cert-SANs",
+				FingerprintHeader: "X-Client-Cert-Fingerprint",
+			}
+			spiffeID, _ = url.Parse("spiffe://example.com/billing")
+			certificate = &x509.Certificate{
+				Raw:         []byte("certificate"),
+				Subject:     pkix.Name{CommonName: "billing-service"},
+				DNSNames:    []string{"billing.internal"},
+				IPAddresses: []net.IP{net.ParseIP("10.0.0.5")},
+				URIs:        []*url.URL{spiffeID},
+			}
+			forwardedRequest *http.Request
+		)
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			forwardedRequest = req
+
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		serve := func(req *http.Request) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			factory.NewClientCert(config).Handler()(testHandler).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		newRequest := func() *http.Request {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{certificate}},
+			}
+
+			return req
+		}
+
+		Convey("Should forward the identity of a verified certificate", func() {
+			req := newRequest()
+			req.Header.Set("X-Client-Cert-CN", "spoofed")
+
+			recorder := serve(req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(forwardedRequest.Header.Get("X-Client-Cert-CN"), ShouldEqual, "billing-service")
+			So(forwardedRequest.Header.Get("X-Client-Cert-SANs"), ShouldEqual, "billing.internal,10.0.0.5,spiffe://example.com/billing")
+			So(forwardedRequest.Header.Get("X-Client-Cert-Fingerprint"), ShouldEqual, "03d66dd08835c1ca3f128cceacd1f31ac94163096b20f445ae84285bc0832d72")
+		})
+
+		Convey("Should reject a request without TLS", func() {
+			recorder := serve(httptest.NewRequest("GET", "/test", nil))
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
+			So(recorder.Body.String(), ShouldEqual, `{"error":"client_certificate_required","message":"A verified client certificate is required."}`)
+		})
+
+		Convey("Should reject a TLS request without a verified certificate", func() {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.TLS = &tls.ConnectionState{}
+			req.Header.Set("X-Client-Cert-CN", "spoofed")
+
+			recorder := serve(req)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("With allowlists", func() {
+			Convey("Should allow a certificate by its common name", func() {
+				config.AllowedCommonNames = []string{"billing-service"}
+
+				So(serve(newRequest()).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should allow a certificate by a subject alternative name", func() {
+				config.AllowedCommonNames = []string{"reporting-service"}
+				config.AllowedSANs = []string{"spiffe://example.com/billing"}
+
+				So(serve(newRequest()).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should reject a certificate matching neither", func() {
+				config.AllowedCommonNames = []string{"reporting-service"}
+				config.AllowedSANs = []string{"reporting.internal"}
+
+				recorder := serve(newRequest())
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldEqual, `{"error":"client_certificate_not_allowed","message":"Client certificate is not allowed."}`)
+			})
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package extAuthz
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+)
+
+// IExtAuthz interface defines the method to get the external authorization middleware handler.
+type IExtAuthz interface {
+	Handler() mux.MiddlewareFunc
+}
+
+// ExtAuthz implements the IExtAuthz interface and provides the external authorization middleware handler.
+type ExtAuthz struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+// Handler returns the external authorization middleware function.
+func (e *ExtAuthz) Handler() mux.MiddlewareFunc {
+	return e.middlewareFunc
+}
+
+// buildExtAuthzMiddlewareFunc builds the middleware asking the external authorization service to approve requests.
+func buildExtAuthzMiddlewareFunc(config subrouter_config.ExtAuthzConfig, checker *checker, logger zerolog.Logger) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			for _, upstreamHeader := range config.UpstreamHeaders {
+				req.Header.Del(upstreamHeader)
+			}
+
+			decision, err := checker.check(req)
+			if err != nil {
+				if config.FailOpen {
+					logger.Warn().Err(err).Str("path", req.URL.Path).Msg("External authorization failed, letting the request through")
+					handler.ServeHTTP(responseWriter, req)
+
+					return
+				}
+
+				logger.Error().Err(err).Str("path", req.URL.Path).Msg("External authorization failed")
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusServiceUnavailable,
+					Code:       errorResponse_util.CodeAuthzUnavailable,
+					Message:    "Authorization service unavailable.",
+					Reason:     err.Error(),
+				})
+
+				return
+			}
+
+			if !decision.isAllowed {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusForbidden,
+					Code:       errorResponse_util.CodeAccessDenied,
+					Message:    "Access denied by the authorization service.",
+					Reason:     decision.reason,
+				})
+
+				return
+			}
+
+			for name, values := range decision.headers {
+				req.Header[name] = slices.Clone(values)
+			}
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package extAuthz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	cache_util "github.com/greencoda/auth0-api-gateway/internal/util/cache"
+)
+
+// maxReasonLength limits how much of a denying response body is kept as the reason of the decision.
+const maxReasonLength = 512
+
+var ErrUnexpectedCheckResponse = errors.New("unexpected response from the authorization service")
+
+// checkRequest is the body of the check requests sent to the external authorization service.
+type checkRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Claims  map[string]any    `json:"claims"`
+}
+
+// decision is the verdict of the external authorization service on a request,
+// with the headers to add to the request when it is allowed.
+type decision struct {
+	isAllowed bool
+	reason    string
+	headers   http.Header
+}
+
+// checker sends check requests to the external authorization service, caching its decisions if configured to.
+type checker struct {
+	config     subrouter_config.ExtAuthzConfig
+	httpClient *http.Client
+	cache      *cache_util.LRU[[sha256.Size]byte, decision]
+}
+
+func newChecker(config subrouter_config.ExtAuthzConfig) *checker {
+	checker := &checker{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}
+
+	if config.CacheTTL > 0 {
+		checker.cache = cache_util.NewLRU[[sha256.Size]byte, decision](config.CacheSize)
+	}
+
+	return checker
+}
+
+// check returns the decision of the external authorization service on the request. Requests with the same method,
+// path, forwarded headers and claims share their cached decision, but failed checks are never cached.
+func (c *checker) check(req *http.Request) (decision, error) {
+	body, err := json.Marshal(c.newCheckRequest(req))
+	if err != nil {
+		return decision{}, fmt.Errorf("failed to encode the check request: %w", err)
+	}
+
+	cacheKey := sha256.Sum256(body)
+
+	if c.cache != nil {
+		if cachedDecision, isCached := c.cache.Get(cacheKey); isCached {
+			return cachedDecision, nil
+		}
+	}
+
+	checkDecision, err := c.send(req.Context(), body)
+	if err != nil {
+		return decision{}, err
+	}
+
+	if c.cache != nil {
+		c.cache.Add(cacheKey, checkDecision, time.Now().Add(c.config.CacheTTL))
+	}
+
+	return checkDecision, nil
+}
+
+// newCheckRequest builds the check request from the request, its configured headers and its validated claims.
+func (c *checker) newCheckRequest(req *http.Request) checkRequest {
+	headers := make(map[string]string, len(c.config.Headers))
+	for _, name := range c.config.Headers {
+		if values := req.Header.Values(name); len(values) > 0 {
+			headers[strings.ToLower(name)] = strings.Join(values, ",")
+		}
+	}
+
+	claims := map[string]any{}
+	if validatedClaims, isValidatedClaim := req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims); isValidatedClaim {
+		claims = auth0_middleware.CollectClaims(validatedClaims)
+	}
+
+	return checkRequest{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Headers: headers,
+		Claims:  claims,
+	}
+}
+
+// send posts the check request to the external authorization service. A 2xx response allows the request,
+// and a 401 or 403 response denies it; any other response is an error.
+func (c *checker) send(ctx context.Context, body []byte) (decision, error) {
+	checkReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return decision{}, fmt.Errorf("failed to build the check request: %w", err)
+	}
+
+	checkReq.Header.Set("Content-Type", "application/json")
+
+	res, err := c.httpClient.Do(checkReq)
+	if err != nil {
+		return decision{}, fmt.Errorf("check request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices:
+		headers := http.Header{}
+
+		for _, name := range c.config.UpstreamHeaders {
+			if values := res.Header.Values(name); len(values) > 0 {
+				headers[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+
+		return decision{isAllowed: true, headers: headers}, nil
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, maxReasonLength))
+
+		return decision{reason: strings.TrimSpace(string(reason))}, nil
+	default:
+		return decision{}, fmt.Errorf("%w: HTTP %d", ErrUnexpectedCheckResponse, res.StatusCode)
+	}
+}
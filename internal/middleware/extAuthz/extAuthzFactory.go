@@ -0,0 +1,44 @@
+package extAuthz
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	"github.com/rs/zerolog"
+)
+
+var ErrMissingExtAuthzURL = errors.New("external authorization requires the URL of the service")
+
+// IExtAuthzFactory interface for creating external authorization middleware for subrouters.
+type IExtAuthzFactory interface {
+	NewExtAuthz(config subrouter_config.ExtAuthzConfig) (IExtAuthz, error)
+}
+
+// ExtAuthzFactory implements the IExtAuthzFactory interface to create external authorization middleware.
+type ExtAuthzFactory struct {
+	logger zerolog.Logger
+}
+
+// NewExtAuthzFactory creates a new external authorization middleware factory.
+func NewExtAuthzFactory(logger zerolog.Logger) IExtAuthzFactory {
+	return &ExtAuthzFactory{
+		logger: logger,
+	}
+}
+
+// NewExtAuthz creates external authorization middleware checking requests at the configured service.
+func (e *ExtAuthzFactory) NewExtAuthz(config subrouter_config.ExtAuthzConfig) (IExtAuthz, error) {
+	if config.URL == "" {
+		return nil, ErrMissingExtAuthzURL
+	}
+
+	if _, err := url.ParseRequestURI(config.URL); err != nil {
+		return nil, fmt.Errorf("failed to parse the URL of the authorization service: %w", err)
+	}
+
+	return &ExtAuthz{
+		middlewareFunc: buildExtAuthzMiddlewareFunc(config, newChecker(config), e.logger),
+	}, nil
+}
@@ -0,0 +1,43 @@
+package extAuthz_test
+
+import (
+	"testing"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/extAuthz"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NewExtAuthzFactory(t *testing.T) {
+	Convey("When creating a new external authorization factory", t, func() {
+		factory := middleware.NewExtAuthzFactory(zerolog.Nop())
+		So(factory, ShouldNotBeNil)
+		So(factory, ShouldImplement, (*middleware.IExtAuthzFactory)(nil))
+	})
+}
+
+func Test_ExtAuthzFactory_NewExtAuthz(t *testing.T) {
+	Convey("When creating a new external authorization middleware", t, func() {
+		factory := middleware.NewExtAuthzFactory(zerolog.Nop())
+
+		Convey("With a valid URL", func() {
+			extAuthz, err := factory.NewExtAuthz(subrouter_config.ExtAuthzConfig{URL: "http://authz.local/check"})
+			So(err, ShouldBeNil)
+			So(extAuthz, ShouldImplement, (*middleware.IExtAuthz)(nil))
+			So(extAuthz.Handler(), ShouldNotBeNil)
+		})
+
+		Convey("Without a URL", func() {
+			extAuthz, err := factory.NewExtAuthz(subrouter_config.ExtAuthzConfig{})
+			So(err, ShouldEqual, middleware.ErrMissingExtAuthzURL)
+			So(extAuthz, ShouldBeNil)
+		})
+
+		Convey("With an invalid URL", func() {
+			extAuthz, err := factory.NewExtAuthz(subrouter_config.ExtAuthzConfig{URL: "authz check"})
+			So(err, ShouldNotBeNil)
+			So(extAuthz, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,199 @@
+package extAuthz_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/extAuthz"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type checkRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Claims  map[string]any    `json:"claims"`
+}
+
+func Test_ExtAuthz_Handler(t *testing.T) {
+	Convey("When using the external authorization handler", t, func() {
+		var (
+			checkCount    atomic.Int32
+			lastCheck     checkRequest
+			checkResponse = func(responseWriter http.ResponseWriter, check checkRequest) {
+				if check.Claims["sub"] != "auth0|1234567890" {
+					responseWriter.WriteHeader(http.StatusForbidden)
+					_, _ = responseWriter.Write([]byte("not entitled"))
+
+					return
+				}
+
+				responseWriter.Header().Set("X-Entitlements", "reports")
+				responseWriter.Header().Set("X-Internal", "secret")
+				responseWriter.WriteHeader(http.StatusOK)
+			}
+		)
+
+		authzServer := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			checkCount.Add(1)
+
+			var check checkRequest
+			_ = json.NewDecoder(req.Body).Decode(&check)
+			lastCheck = check
+
+			checkResponse(responseWriter, check)
+		}))
+		defer authzServer.Close()
+
+		config := subrouter_config.ExtAuthzConfig{
+			URL:             authzServer.URL,
+			Timeout:         time.Second,
+			Headers:         []string{"X-Tenant"},
+			UpstreamHeaders: []string{"X-Entitlements"},
+			CacheSize:       10,
+		}
+
+		var forwardedHeaders http.Header
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			forwardedHeaders = req.Header.Clone()
+
+			responseWriter.WriteHeader(http.StatusOK)
+		})
+
+		newRequest := func(subject string) *http.Request {
+			req := httptest.NewRequest("GET", "/reports/monthly", nil)
+			req.Header.Set("X-Tenant", "acme")
+			req.Header.Set("X-Other", "not-forwarded")
+			req.Header.Set("X-Entitlements", "spoofed")
+
+			return req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: &auth0_middleware.CustomAuth0Claims{Claims: map[string]any{"sub": subject}},
+			}))
+		}
+
+		serve := func(req *http.Request) *httptest.ResponseRecorder {
+			extAuthz, err := middleware.NewExtAuthzFactory(zerolog.Nop()).NewExtAuthz(config)
+			So(err, ShouldBeNil)
+
+			recorder := httptest.NewRecorder()
+			errorResponse_util.WithVerboseReasons(true)(extAuthz.Handler()(testHandler)).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("Should send the request details to the service", func() {
+			serve(newRequest("auth0|1234567890"))
+
+			So(lastCheck.Method, ShouldEqual, "GET")
+			So(lastCheck.Path, ShouldEqual, "/reports/monthly")
+			So(lastCheck.Headers, ShouldResemble, map[string]string{"x-tenant": "acme"})
+			So(lastCheck.Claims["sub"], ShouldEqual, "auth0|1234567890")
+		})
+
+		Convey("Should let allowed requests through with the upstream headers", func() {
+			recorder := serve(newRequest("auth0|1234567890"))
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(forwardedHeaders.Get("X-Entitlements"), ShouldEqual, "reports")
+			So(forwardedHeaders.Values("X-Internal"), ShouldBeEmpty)
+		})
+
+		Convey("Should reject denied requests", func() {
+			recorder := serve(newRequest("auth0|0987654321"))
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+
+			var body map[string]string
+			So(json.Unmarshal(recorder.Body.Bytes(), &body), ShouldBeNil)
+			So(body["error"], ShouldEqual, errorResponse_util.CodeAccessDenied)
+			So(body["reason"], ShouldEqual, "not entitled")
+		})
+
+		Convey("With the decisions cached", func() {
+			config.CacheTTL = time.Minute
+
+			extAuthz, _ := middleware.NewExtAuthzFactory(zerolog.Nop()).NewExtAuthz(config)
+			wrappedHandler := extAuthz.Handler()(testHandler)
+
+			Convey("Should check identical requests only once", func() {
+				for range 3 {
+					recorder := httptest.NewRecorder()
+					wrappedHandler.ServeHTTP(recorder, newRequest("auth0|1234567890"))
+					So(recorder.Code, ShouldEqual, http.StatusOK)
+					So(forwardedHeaders.Get("X-Entitlements"), ShouldEqual, "reports")
+				}
+
+				So(checkCount.Load(), ShouldEqual, 1)
+			})
+
+			Convey("Should not let requests change the upstream headers of the cached decision", func() {
+				extAuthz.Handler()(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+					req.Header["X-Entitlements"][0] = "admin"
+				})).ServeHTTP(httptest.NewRecorder(), newRequest("auth0|1234567890"))
+
+				wrappedHandler.ServeHTTP(httptest.NewRecorder(), newRequest("auth0|1234567890"))
+				So(forwardedHeaders.Get("X-Entitlements"), ShouldEqual, "reports")
+				So(checkCount.Load(), ShouldEqual, 1)
+			})
+
+			Convey("Should check requests with different claims separately", func() {
+				wrappedHandler.ServeHTTP(httptest.NewRecorder(), newRequest("auth0|1234567890"))
+				wrappedHandler.ServeHTTP(httptest.NewRecorder(), newRequest("auth0|0987654321"))
+
+				So(checkCount.Load(), ShouldEqual, 2)
+			})
+
+			Convey("Should not cache failed checks", func() {
+				checkResponse = func(responseWriter http.ResponseWriter, _ checkRequest) {
+					responseWriter.WriteHeader(http.StatusInternalServerError)
+				}
+
+				wrappedHandler.ServeHTTP(httptest.NewRecorder(), newRequest("auth0|1234567890"))
+				wrappedHandler.ServeHTTP(httptest.NewRecorder(), newRequest("auth0|1234567890"))
+
+				So(checkCount.Load(), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the service fails", func() {
+			checkResponse = func(responseWriter http.ResponseWriter, _ checkRequest) {
+				responseWriter.WriteHeader(http.StatusBadGateway)
+			}
+
+			Convey("Should reject requests when failing closed", func() {
+				recorder := serve(newRequest("auth0|1234567890"))
+				So(recorder.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeAuthzUnavailable)
+			})
+
+			Convey("Should let requests through when failing open", func() {
+				config.FailOpen = true
+
+				recorder := serve(newRequest("auth0|1234567890"))
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(forwardedHeaders.Values("X-Entitlements"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the service times out", func() {
+			config.Timeout = 10 * time.Millisecond
+			checkResponse = func(responseWriter http.ResponseWriter, _ checkRequest) {
+				time.Sleep(100 * time.Millisecond)
+				responseWriter.WriteHeader(http.StatusOK)
+			}
+
+			recorder := serve(newRequest("auth0|1234567890"))
+			So(recorder.Code, ShouldEqual, http.StatusServiceUnavailable)
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package ipFilter
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/gorilla/mux"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+// IIPFilter interface defines the methods of the IP filter middleware.
+type IIPFilter interface {
+	Handler() mux.MiddlewareFunc
+}
+
+// IPFilter implements the IIPFilter interface and provides the IP filter middleware.
+type IPFilter struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+// Handler returns the IP filter middleware function.
+func (i *IPFilter) Handler() mux.MiddlewareFunc {
+	return i.middlewareFunc
+}
+
+// ipRanges holds the allowed and denied ranges of a subrouter. Denied ranges take precedence over allowed ones,
+// and without allowed ranges, every address not denied is allowed.
+type ipRanges struct {
+	allowed []netip.Prefix
+	denied  []netip.Prefix
+}
+
+// check tells whether the address is allowed, and the reason if it isn't.
+func (i ipRanges) check(addr netip.Addr) (string, bool) {
+	if clientIP_util.ContainsAddr(i.denied, addr) {
+		return "client IP " + addr.String() + " is denied", false
+	}
+
+	if len(i.allowed) > 0 && !clientIP_util.ContainsAddr(i.allowed, addr) {
+		return "client IP " + addr.String() + " is not allowed", false
+	}
+
+	return "", true
+}
+
+// buildIPFilterMiddlewareFunc builds the middleware rejecting requests from clients outside the allowed or inside
+// the denied ranges. Requests whose client IP can't be determined are rejected too.
+func buildIPFilterMiddlewareFunc(ipRanges ipRanges, clientIPResolver *clientIP_util.Resolver, trustForwardHeader bool) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			reason := "client IP is unknown"
+
+			clientIP, isKnown := clientIPResolver.Resolve(req, trustForwardHeader)
+			if isKnown {
+				var isAllowed bool
+				if reason, isAllowed = ipRanges.check(clientIP); isAllowed {
+					handler.ServeHTTP(responseWriter, req)
+
+					return
+				}
+			}
+
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusForbidden,
+				Code:       errorResponse_util.CodeAccessDenied,
+				Message:    "Access denied by IP filter.",
+				Reason:     reason,
+			})
+		})
+	}
+}
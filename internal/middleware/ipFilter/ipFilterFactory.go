@@ -0,0 +1,58 @@
+package ipFilter
+
+import (
+	"errors"
+	"fmt"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+)
+
+var ErrEmptyIPFilter = errors.New("an IP filter requires allowed or denied ranges")
+
+// Factory interface for creating IP filter middleware for subrouters.
+type IIPFilterFactory interface {
+	NewIPFilter(config subrouter_config.IPFilterConfig) (IIPFilter, error)
+}
+
+// IPFilterFactory implements the IIPFilterFactory interface to create IP filter middleware.
+type IPFilterFactory struct {
+	clientIPResolver *clientIP_util.Resolver
+}
+
+// NewIPFilterFactory creates a new IP filter middleware factory, resolving client IPs with the resolver.
+func NewIPFilterFactory(clientIPResolver *clientIP_util.Resolver) IIPFilterFactory {
+	return &IPFilterFactory{
+		clientIPResolver: clientIPResolver,
+	}
+}
+
+// NewIPFilter creates a new IP filter middleware, rejecting clients outside the allowed or inside the denied ranges.
+func (i *IPFilterFactory) NewIPFilter(config subrouter_config.IPFilterConfig) (IIPFilter, error) {
+	if len(config.Allow) == 0 && len(config.Deny) == 0 {
+		return nil, ErrEmptyIPFilter
+	}
+
+	allowedRanges, err := clientIP_util.ParseRanges(config.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the allowed ranges: %w", err)
+	}
+
+	deniedRanges, err := clientIP_util.ParseRanges(config.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the denied ranges: %w", err)
+	}
+
+	// Without trusted proxies, any client could claim an allowed address in a forwarded header.
+	if err := i.clientIPResolver.CheckTrustForwardHeader(config.TrustForwardHeader); err != nil {
+		return nil, err
+	}
+
+	return &IPFilter{
+		middlewareFunc: buildIPFilterMiddlewareFunc(
+			ipRanges{allowed: allowedRanges, denied: deniedRanges},
+			i.clientIPResolver,
+			config.TrustForwardHeader,
+		),
+	}, nil
+}
@@ -0,0 +1,57 @@
+package ipFilter_test
+
+import (
+	"testing"
+
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/ipFilter"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NewIPFilterFactory(t *testing.T) {
+	Convey("When creating a new IP filter factory", t, func() {
+		clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{TrustedProxies: []string{"10.0.0.0/8"}})
+		So(err, ShouldBeNil)
+
+		factory := middleware.NewIPFilterFactory(clientIPResolver)
+		So(factory, ShouldNotBeNil)
+		So(factory, ShouldImplement, (*middleware.IIPFilterFactory)(nil))
+
+		Convey("Should create an IP filter middleware", func() {
+			ipFilter, err := factory.NewIPFilter(subrouter_config.IPFilterConfig{
+				Allow:              []string{"203.0.113.0/24", "2001:db8::/32", "198.51.100.7"},
+				Deny:               []string{"203.0.113.66"},
+				TrustForwardHeader: true,
+			})
+			So(err, ShouldBeNil)
+			So(ipFilter, ShouldImplement, (*middleware.IIPFilter)(nil))
+			So(ipFilter.Handler(), ShouldNotBeNil)
+		})
+
+		Convey("Should fail without any ranges", func() {
+			_, err := factory.NewIPFilter(subrouter_config.IPFilterConfig{TrustForwardHeader: true})
+			So(err, ShouldWrap, middleware.ErrEmptyIPFilter)
+		})
+
+		Convey("Should fail to trust forwarded headers without trusted proxies", func() {
+			clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{})
+			So(err, ShouldBeNil)
+
+			_, err = middleware.NewIPFilterFactory(clientIPResolver).NewIPFilter(subrouter_config.IPFilterConfig{
+				Allow:              []string{"203.0.113.0/24"},
+				TrustForwardHeader: true,
+			})
+			So(err, ShouldWrap, clientIP_util.ErrNoTrustedProxy)
+		})
+
+		Convey("Should fail for invalid ranges", func() {
+			_, err := factory.NewIPFilter(subrouter_config.IPFilterConfig{Allow: []string{"203.0.113.0/33"}})
+			So(err, ShouldWrap, clientIP_util.ErrInvalidIPRange)
+
+			_, err = factory.NewIPFilter(subrouter_config.IPFilterConfig{Deny: []string{"office"}})
+			So(err, ShouldWrap, clientIP_util.ErrInvalidIPRange)
+		})
+	})
+}
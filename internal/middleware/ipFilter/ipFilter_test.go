@@ -0,0 +1,115 @@
+package ipFilter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/ipFilter"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_IPFilter(t *testing.T) {
+	Convey("When filtering requests by client IP", t, func() {
+		clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{TrustedProxies: []string{"10.0.0.0/8"}})
+		So(err, ShouldBeNil)
+
+		newHandler := func(config subrouter_config.IPFilterConfig) http.Handler {
+			ipFilter, err := middleware.NewIPFilterFactory(clientIPResolver).NewIPFilter(config)
+			So(err, ShouldBeNil)
+
+			return errorResponse_util.WithVerboseReasons(true)(ipFilter.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				responseWriter.WriteHeader(http.StatusOK)
+			})))
+		}
+
+		serve := func(handler http.Handler, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/admin", nil)
+			req.RemoteAddr = remoteAddr
+
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("With allowed and denied ranges", func() {
+			handler := newHandler(subrouter_config.IPFilterConfig{
+				Allow: []string{"203.0.113.0/24", "2001:db8::/32"},
+				Deny:  []string{"203.0.113.66"},
+			})
+
+			Convey("Should let clients in the allowed ranges through", func() {
+				So(serve(handler, "203.0.113.10:51234", nil).Code, ShouldEqual, http.StatusOK)
+				So(serve(handler, "[2001:db8::1]:51234", nil).Code, ShouldEqual, http.StatusOK)
+				So(serve(handler, "[::ffff:203.0.113.10]:51234", nil).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should reject clients outside the allowed ranges", func() {
+				recorder := serve(handler, "198.51.100.1:51234", nil)
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Header().Get("Content-Type"), ShouldStartWith, "application/json")
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeAccessDenied)
+				So(recorder.Body.String(), ShouldContainSubstring, "client IP 198.51.100.1 is not allowed")
+			})
+
+			Convey("Should reject denied clients even within the allowed ranges", func() {
+				recorder := serve(handler, "203.0.113.66:51234", nil)
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldContainSubstring, "client IP 203.0.113.66 is denied")
+			})
+
+			Convey("Should ignore forwarded headers unless trusted", func() {
+				So(serve(handler, "198.51.100.1:51234", map[string]string{"X-Forwarded-For": "203.0.113.10"}).Code, ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		Convey("With only denied ranges", func() {
+			handler := newHandler(subrouter_config.IPFilterConfig{Deny: []string{"192.0.2.0/24"}})
+
+			Convey("Should let every other client through", func() {
+				So(serve(handler, "198.51.100.1:51234", nil).Code, ShouldEqual, http.StatusOK)
+				So(serve(handler, "192.0.2.1:51234", nil).Code, ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		Convey("With trusted proxies", func() {
+			handler := newHandler(subrouter_config.IPFilterConfig{
+				Allow:              []string{"203.0.113.0/24"},
+				TrustForwardHeader: true,
+			})
+
+			Convey("Should take the last address forwarded by the trusted proxies", func() {
+				So(serve(handler, "10.0.0.5:51234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.10, 10.0.0.2"}).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should not be fooled by addresses spoofed by the client", func() {
+				recorder := serve(handler, "10.0.0.5:51234", map[string]string{"X-Forwarded-For": "203.0.113.10, 198.51.100.1"})
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldContainSubstring, "client IP 198.51.100.1 is not allowed")
+			})
+
+			Convey("Should ignore forwarded headers of requests not sent by a trusted proxy", func() {
+				So(serve(handler, "198.51.100.1:51234", map[string]string{"X-Forwarded-For": "203.0.113.10"}).Code, ShouldEqual, http.StatusForbidden)
+			})
+
+			Convey("Should use X-Real-IP when there is no X-Forwarded-For", func() {
+				So(serve(handler, "10.0.0.5:51234", map[string]string{"X-Real-IP": "203.0.113.10"}).Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should reject malformed forwarded addresses", func() {
+				recorder := serve(handler, "10.0.0.5:51234", map[string]string{"X-Forwarded-For": "203.0.113.10, unknown"})
+				So(recorder.Code, ShouldEqual, http.StatusForbidden)
+				So(recorder.Body.String(), ShouldContainSubstring, "client IP is unknown")
+			})
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"net/http"
+	"strings"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/google/cel-go/cel"
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+)
+
+// defaultRuleName names the default effect in the decision logs.
+const defaultRuleName = "default"
+
+// IPolicy interface defines the method to get the policy middleware handler.
+type IPolicy interface {
+	Handler() mux.MiddlewareFunc
+}
+
+// Policy implements the IPolicy interface and provides the policy middleware handler.
+type Policy struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+// Handler returns the policy middleware function.
+func (p *Policy) Handler() mux.MiddlewareFunc {
+	return p.middlewareFunc
+}
+
+// policyRule is a policy rule with its condition compiled into a CEL program.
+type policyRule struct {
+	name    string
+	program cel.Program
+	effect  string
+}
+
+// buildPolicyMiddlewareFunc builds the middleware deciding requests by the first policy rule whose condition
+// holds. A condition that fails to evaluate denies the request, so that a broken rule can't let requests through.
+func buildPolicyMiddlewareFunc(config subrouter_config.PolicyConfig, policyRules []policyRule, clientIPResolver *clientIP_util.Resolver, logger zerolog.Logger) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			input := newPolicyInput(req, config, clientIPResolver)
+			ruleName, effect := defaultRuleName, config.Default
+
+			for _, rule := range policyRules {
+				output, _, err := rule.program.Eval(input)
+				if err != nil {
+					logger.Error().Err(err).Str("rule", rule.name).Msg("Failed to evaluate policy rule")
+
+					ruleName, effect = rule.name, subrouter_config.PolicyEffectDeny
+
+					break
+				}
+
+				if isMatching, _ := output.Value().(bool); isMatching {
+					ruleName, effect = rule.name, rule.effect
+
+					break
+				}
+			}
+
+			logger.Info().
+				Str("method", req.Method).
+				Str("path", req.URL.Path).
+				Str("rule", ruleName).
+				Str("effect", effect).
+				Msg("Policy decision")
+
+			if effect != subrouter_config.PolicyEffectAllow {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusForbidden,
+					Code:       errorResponse_util.CodeAccessDenied,
+					Message:    "Access denied by policy.",
+					Reason:     "denied by rule '" + ruleName + "'",
+				})
+
+				return
+			}
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// newPolicyInput builds the input document of the policy rules from the request and its validated claims.
+// Header names are lowercased, and the values of repeated headers are joined with commas.
+func newPolicyInput(req *http.Request, config subrouter_config.PolicyConfig, clientIPResolver *clientIP_util.Resolver) map[string]any {
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	claims := map[string]any{}
+	if validatedClaims, isValidatedClaim := req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims); isValidatedClaim {
+		claims = auth0_middleware.CollectClaims(validatedClaims)
+	}
+
+	var clientIP string
+	if ip, isKnown := clientIPResolver.Resolve(req, config.TrustForwardHeader); isKnown {
+		clientIP = ip.String()
+	}
+
+	return map[string]any{
+		"request": map[string]any{
+			"method":   req.Method,
+			"path":     req.URL.Path,
+			"headers":  headers,
+			"clientIp": clientIP,
+		},
+		"claims": claims,
+	}
+}
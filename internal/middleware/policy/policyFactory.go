@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrInvalidPolicyRule   = errors.New("invalid policy rule")
+	ErrUnknownPolicyEffect = errors.New("unknown policy effect")
+)
+
+// IPolicyFactory interface for creating policy middleware for subrouters.
+type IPolicyFactory interface {
+	NewPolicy(config subrouter_config.PolicyConfig) (IPolicy, error)
+}
+
+// PolicyFactory implements the IPolicyFactory interface to create policy middleware.
+type PolicyFactory struct {
+	environment      *cel.Env
+	clientIPResolver *clientIP_util.Resolver
+	logger           zerolog.Logger
+}
+
+// NewPolicyFactory creates a new policy middleware factory, resolving the client IPs of the policy input with the resolver.
+func NewPolicyFactory(clientIPResolver *clientIP_util.Resolver, logger zerolog.Logger) (IPolicyFactory, error) {
+	environment, err := cel.NewEnv(
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the policy environment: %w", err)
+	}
+
+	return &PolicyFactory{
+		environment:      environment,
+		clientIPResolver: clientIPResolver,
+		logger:           logger,
+	}, nil
+}
+
+// NewPolicy compiles the rules of the policy, failing if any of them is invalid.
+func (p *PolicyFactory) NewPolicy(config subrouter_config.PolicyConfig) (IPolicy, error) {
+	if !isPolicyEffect(config.Default) {
+		return nil, fmt.Errorf("%w '%s' of the default rule", ErrUnknownPolicyEffect, config.Default)
+	}
+
+	if err := p.clientIPResolver.CheckTrustForwardHeader(config.TrustForwardHeader); err != nil {
+		p.logger.Warn().Err(err).Msg("Policy trusts the forwarded headers of every request")
+	}
+
+	policyRules := make([]policyRule, 0, len(config.Rules))
+
+	for index, ruleConfig := range config.Rules {
+		if ruleConfig.Name == "" {
+			ruleConfig.Name = fmt.Sprintf("#%d", index+1)
+		}
+
+		if !isPolicyEffect(ruleConfig.Effect) {
+			return nil, fmt.Errorf("%w '%s' of rule '%s'", ErrUnknownPolicyEffect, ruleConfig.Effect, ruleConfig.Name)
+		}
+
+		program, err := p.compile(ruleConfig.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s': %w", ErrInvalidPolicyRule, ruleConfig.Name, err)
+		}
+
+		policyRules = append(policyRules, policyRule{
+			name:    ruleConfig.Name,
+			program: program,
+			effect:  ruleConfig.Effect,
+		})
+	}
+
+	return &Policy{
+		middlewareFunc: buildPolicyMiddlewareFunc(config, policyRules, p.clientIPResolver, p.logger),
+	}, nil
+}
+
+// compile compiles a rule condition, which must be a boolean expression.
+func (p *PolicyFactory) compile(condition string) (cel.Program, error) {
+	ast, issues := p.environment.Compile(condition)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("condition must be a boolean expression, not %s", ast.OutputType())
+	}
+
+	return p.environment.Program(ast)
+}
+
+func isPolicyEffect(effect string) bool {
+	return effect == subrouter_config.PolicyEffectAllow || effect == subrouter_config.PolicyEffectDeny
+}
@@ -0,0 +1,104 @@
+package policy_test
+
+import (
+	"bytes"
+	"testing"
+
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/policy"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newClientIPResolver() *clientIP_util.Resolver {
+	clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{TrustedProxies: []string{"192.0.2.0/24"}})
+	So(err, ShouldBeNil)
+
+	return clientIPResolver
+}
+
+func Test_NewPolicyFactory(t *testing.T) {
+	Convey("When creating a new policy factory", t, func() {
+		factory, err := middleware.NewPolicyFactory(newClientIPResolver(), zerolog.Nop())
+		So(err, ShouldBeNil)
+		So(factory, ShouldImplement, (*middleware.IPolicyFactory)(nil))
+	})
+}
+
+func Test_PolicyFactory_NewPolicy(t *testing.T) {
+	Convey("When creating a new policy middleware", t, func() {
+		factory, _ := middleware.NewPolicyFactory(newClientIPResolver(), zerolog.Nop())
+
+		config := subrouter_config.PolicyConfig{
+			Rules: []subrouter_config.PolicyRuleConfig{
+				{Name: "reads", Condition: `request.method == "GET"`, Effect: subrouter_config.PolicyEffectAllow},
+			},
+			Default: subrouter_config.PolicyEffectDeny,
+		}
+
+		Convey("With valid rules", func() {
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldBeNil)
+			So(policy, ShouldImplement, (*middleware.IPolicy)(nil))
+			So(policy.Handler(), ShouldNotBeNil)
+		})
+
+		Convey("With a syntax error in a condition", func() {
+			config.Rules[0].Condition = `request.method ==`
+
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldWrap, middleware.ErrInvalidPolicyRule)
+			So(err.Error(), ShouldContainSubstring, "'reads'")
+			So(policy, ShouldBeNil)
+		})
+
+		Convey("With a condition that isn't boolean", func() {
+			config.Rules[0].Condition = `request.method`
+
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldWrap, middleware.ErrInvalidPolicyRule)
+			So(policy, ShouldBeNil)
+		})
+
+		Convey("With an undeclared variable in a condition", func() {
+			config.Rules[0].Condition = `user.admin == true`
+
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldWrap, middleware.ErrInvalidPolicyRule)
+			So(policy, ShouldBeNil)
+		})
+
+		Convey("With an unknown rule effect", func() {
+			config.Rules[0].Effect = "permit"
+
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldWrap, middleware.ErrUnknownPolicyEffect)
+			So(policy, ShouldBeNil)
+		})
+
+		Convey("With forwarded headers trusted without trusted proxies", func() {
+			clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{})
+			So(err, ShouldBeNil)
+
+			var logs bytes.Buffer
+
+			factory, _ := middleware.NewPolicyFactory(clientIPResolver, zerolog.New(&logs))
+			config.TrustForwardHeader = true
+
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldBeNil)
+			So(policy, ShouldNotBeNil)
+			So(logs.String(), ShouldContainSubstring, clientIP_util.ErrNoTrustedProxy.Error())
+		})
+
+		Convey("With an unknown default effect", func() {
+			config.Default = "maybe"
+
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldWrap, middleware.ErrUnknownPolicyEffect)
+			So(policy, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package policy_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	jwtvalidator "github.com/auth0/go-jwt-middleware/v2/validator"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/policy"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Policy_Handler(t *testing.T) {
+	Convey("When using the policy handler", t, func() {
+		var logs bytes.Buffer
+
+		factory, _ := middleware.NewPolicyFactory(newClientIPResolver(), zerolog.New(&logs))
+
+		config := subrouter_config.PolicyConfig{
+			Rules: []subrouter_config.PolicyRuleConfig{
+				{Name: "blocked-network", Condition: `request.clientIp.startsWith("10.66.")`, Effect: subrouter_config.PolicyEffectDeny},
+				{Name: "admins", Condition: `"roles" in claims && "admin" in claims.roles`, Effect: subrouter_config.PolicyEffectAllow},
+				{Name: "reads", Condition: `request.method == "GET" && request.path.startsWith("/reports/")`, Effect: subrouter_config.PolicyEffectAllow},
+				{Name: "tenant-header", Condition: `"x-tenant" in request.headers && request.headers["x-tenant"] == claims.org_id`, Effect: subrouter_config.PolicyEffectAllow},
+			},
+			Default: subrouter_config.PolicyEffectDeny,
+		}
+
+		testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("success"))
+		})
+
+		withClaims := func(req *http.Request, claims map[string]any) *http.Request {
+			return req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &jwtvalidator.ValidatedClaims{
+				CustomClaims: &auth0_middleware.CustomAuth0Claims{Claims: claims},
+			}))
+		}
+
+		serve := func(req *http.Request) *httptest.ResponseRecorder {
+			policy, err := factory.NewPolicy(config)
+			So(err, ShouldBeNil)
+
+			recorder := httptest.NewRecorder()
+			errorResponse_util.WithVerboseReasons(true)(policy.Handler()(testHandler)).ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("Should allow a request matching an allow rule", func() {
+			recorder := serve(httptest.NewRequest("GET", "/reports/monthly", nil))
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldEqual, "success")
+			So(logs.String(), ShouldContainSubstring, `"rule":"reads"`)
+			So(logs.String(), ShouldContainSubstring, `"effect":"allow"`)
+		})
+
+		Convey("Should allow a request by its claims", func() {
+			req := withClaims(httptest.NewRequest("DELETE", "/reports/monthly", nil), map[string]any{"roles": []any{"admin"}})
+
+			recorder := serve(req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(logs.String(), ShouldContainSubstring, `"rule":"admins"`)
+		})
+
+		Convey("Should allow a request by its headers", func() {
+			req := withClaims(httptest.NewRequest("POST", "/orders", nil), map[string]any{"org_id": "org_acme"})
+			req.Header.Set("X-Tenant", "org_acme")
+
+			recorder := serve(req)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(logs.String(), ShouldContainSubstring, `"rule":"tenant-header"`)
+		})
+
+		Convey("Should apply the first matching rule", func() {
+			req := withClaims(httptest.NewRequest("GET", "/reports/monthly", nil), map[string]any{"roles": []any{"admin"}})
+			req.RemoteAddr = "10.66.0.1:4711"
+
+			recorder := serve(req)
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+
+			var body map[string]string
+			So(json.Unmarshal(recorder.Body.Bytes(), &body), ShouldBeNil)
+			So(body["error"], ShouldEqual, errorResponse_util.CodeAccessDenied)
+			So(body["reason"], ShouldEqual, "denied by rule 'blocked-network'")
+		})
+
+		Convey("Should use the forwarded client IP only when trusted", func() {
+			req := httptest.NewRequest("GET", "/reports/monthly", nil)
+			req.Header.Set("X-Forwarded-For", "10.66.0.1")
+
+			So(serve(req).Code, ShouldEqual, http.StatusOK)
+
+			config.TrustForwardHeader = true
+
+			So(serve(req).Code, ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("Should apply the default effect when no rule matches", func() {
+			recorder := serve(httptest.NewRequest("POST", "/orders", nil))
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(logs.String(), ShouldContainSubstring, `"rule":"default"`)
+
+			config.Default = subrouter_config.PolicyEffectAllow
+
+			So(serve(httptest.NewRequest("POST", "/orders", nil)).Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should deny the request when a rule fails to evaluate", func() {
+			config.Default = subrouter_config.PolicyEffectAllow
+			config.Rules = []subrouter_config.PolicyRuleConfig{
+				{Name: "missing-claim", Condition: `claims.org_id == "org_acme"`, Effect: subrouter_config.PolicyEffectAllow},
+			}
+
+			recorder := serve(httptest.NewRequest("GET", "/orders", nil))
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(logs.String(), ShouldContainSubstring, "Failed to evaluate policy rule")
+		})
+	})
+}
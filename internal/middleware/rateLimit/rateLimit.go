@@ -1,41 +1,102 @@
 package rateLimit
 
 import (
+	"net/http"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/gorilla/mux"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
 	"github.com/ulule/limiter/v3"
 	"github.com/ulule/limiter/v3/drivers/middleware/stdlib"
 	"github.com/ulule/limiter/v3/drivers/store/memory"
 )
 
+const anonymousStorePrefix = "limiter_anonymous"
+
 type IRateLimit interface {
 	Handler() mux.MiddlewareFunc
+	AnonymousHandler() mux.MiddlewareFunc
 }
 
 type RateLimit struct {
-	middlewareFunc mux.MiddlewareFunc
+	middlewareFunc          mux.MiddlewareFunc
+	anonymousMiddlewareFunc mux.MiddlewareFunc
 }
 
 func (c *RateLimit) Handler() mux.MiddlewareFunc {
 	return c.middlewareFunc
 }
 
-func buildRateLimiterFunc(config subrouter_config.RateLimitConfig) mux.MiddlewareFunc {
-	var (
-		limiterStore = memory.NewStore()
-		limiterRate  = limiter.Rate{
-			Period: config.Period,
-			Limit:  config.Limit,
+// AnonymousHandler returns the middleware holding anonymous requests to the anonymous limit. It has to be mounted
+// after the authentication middlewares, as requests are only known to be anonymous once they have been authenticated.
+func (c *RateLimit) AnonymousHandler() mux.MiddlewareFunc {
+	return c.anonymousMiddlewareFunc
+}
+
+func buildRateLimiterFunc(config subrouter_config.RateLimitConfig, keyGetter stdlib.KeyGetter) mux.MiddlewareFunc {
+	return newLimiterMiddleware(memory.NewStore(), config.Limit, config, keyGetter).Handler
+}
+
+// buildAnonymousRateLimiterFunc builds the middleware limiting the requests which weren't authenticated by any means.
+func buildAnonymousRateLimiterFunc(config subrouter_config.RateLimitConfig, keyGetter stdlib.KeyGetter) mux.MiddlewareFunc {
+	if config.AnonymousLimit <= 0 {
+		return func(handler http.Handler) http.Handler {
+			return handler
 		}
+	}
+
+	anonymousMiddleware := newLimiterMiddleware(
+		memory.NewStoreWithOptions(limiter.StoreOptions{
+			Prefix:          anonymousStorePrefix,
+			CleanUpInterval: limiter.DefaultCleanUpInterval,
+		}),
+		config.AnonymousLimit,
+		config,
+		keyGetter,
 	)
 
-	middleware := stdlib.NewMiddleware(
+	return func(handler http.Handler) http.Handler {
+		anonymousLimitedHandler := anonymousMiddleware.Handler(handler)
+
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			if isAuthenticated(req) {
+				handler.ServeHTTP(responseWriter, req)
+
+				return
+			}
+
+			anonymousLimitedHandler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+func isAuthenticated(req *http.Request) bool {
+	if req.Context().Value(jwtmiddleware.ContextKey{}) != nil {
+		return true
+	}
+
+	return req.TLS != nil && len(req.TLS.VerifiedChains) > 0
+}
+
+func newLimiterMiddleware(store limiter.Store, limit int64, config subrouter_config.RateLimitConfig, keyGetter stdlib.KeyGetter) *stdlib.Middleware {
+	return stdlib.NewMiddleware(
 		limiter.New(
-			limiterStore,
-			limiterRate,
-			limiter.WithTrustForwardHeader(config.TrustForwardHeader),
+			store,
+			limiter.Rate{
+				Period: config.Period,
+				Limit:  limit,
+			},
 		),
+		stdlib.WithKeyGetter(keyGetter),
 	)
+}
+
+// newClientIPKeyGetter builds the key getter limiting the requests of each client IP.
+func newClientIPKeyGetter(clientIPResolver *clientIP_util.Resolver, trustForwardHeader bool) stdlib.KeyGetter {
+	return func(req *http.Request) string {
+		clientIP, _ := clientIPResolver.Resolve(req, trustForwardHeader)
 
-	return middleware.Handler
+		return clientIP.String()
+	}
 }
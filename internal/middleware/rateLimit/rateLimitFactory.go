@@ -2,20 +2,35 @@ package rateLimit
 
 import (
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	"github.com/rs/zerolog"
 )
 
 type IRateLimitFactory interface {
 	NewRateLimit(config subrouter_config.RateLimitConfig) IRateLimit
 }
 
-type RateLimitFactory struct{}
+type RateLimitFactory struct {
+	clientIPResolver *clientIP_util.Resolver
+	logger           zerolog.Logger
+}
 
-func NewRateLimitFactory() IRateLimitFactory {
-	return &RateLimitFactory{}
+func NewRateLimitFactory(clientIPResolver *clientIP_util.Resolver, logger zerolog.Logger) IRateLimitFactory {
+	return &RateLimitFactory{
+		clientIPResolver: clientIPResolver,
+		logger:           logger,
+	}
 }
 
 func (r *RateLimitFactory) NewRateLimit(config subrouter_config.RateLimitConfig) IRateLimit {
+	if err := r.clientIPResolver.CheckTrustForwardHeader(config.TrustForwardHeader); err != nil {
+		r.logger.Warn().Err(err).Msg("Rate limit trusts the forwarded headers of every request")
+	}
+
+	keyGetter := newClientIPKeyGetter(r.clientIPResolver, config.TrustForwardHeader)
+
 	return &RateLimit{
-		middlewareFunc: buildRateLimiterFunc(config),
+		middlewareFunc:          buildRateLimiterFunc(config, keyGetter),
+		anonymousMiddlewareFunc: buildAnonymousRateLimiterFunc(config, keyGetter),
 	}
 }
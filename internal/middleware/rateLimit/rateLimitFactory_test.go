@@ -1,17 +1,21 @@
 package rateLimit_test
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
 	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/rateLimit"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	"github.com/rs/zerolog"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func Test_NewRateLimitFactory(t *testing.T) {
 	Convey("When creating a new rate limit factory", t, func() {
-		factory := middleware.NewRateLimitFactory()
+		factory := middleware.NewRateLimitFactory(newClientIPResolver(), zerolog.Nop())
 		So(factory, ShouldNotBeNil)
 		So(factory, ShouldImplement, (*middleware.IRateLimitFactory)(nil))
 	})
@@ -19,7 +23,7 @@ func Test_NewRateLimitFactory(t *testing.T) {
 
 func Test_RateLimitFactory_NewRateLimit(t *testing.T) {
 	Convey("When creating rate limit middleware", t, func() {
-		factory := middleware.NewRateLimitFactory()
+		factory := middleware.NewRateLimitFactory(newClientIPResolver(), zerolog.Nop())
 
 		Convey("With basic configuration", func() {
 			config := subrouter_config.RateLimitConfig{
@@ -49,6 +53,21 @@ func Test_RateLimitFactory_NewRateLimit(t *testing.T) {
 			So(handler, ShouldNotBeNil)
 		})
 
+		Convey("Should warn about trusting forwarded headers without trusted proxies", func() {
+			clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{})
+			So(err, ShouldBeNil)
+
+			var logs bytes.Buffer
+
+			rateLimit := middleware.NewRateLimitFactory(clientIPResolver, zerolog.New(&logs)).NewRateLimit(subrouter_config.RateLimitConfig{
+				Limit:              100,
+				Period:             time.Second,
+				TrustForwardHeader: true,
+			})
+			So(rateLimit, ShouldNotBeNil)
+			So(logs.String(), ShouldContainSubstring, clientIP_util.ErrNoTrustedProxy.Error())
+		})
+
 		Convey("With different time periods", func() {
 			testCases := []struct {
 				period time.Duration
@@ -1,21 +1,36 @@
 package rateLimit_test
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
 	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/rateLimit"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	"github.com/rs/zerolog"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
+func newClientIPResolver() *clientIP_util.Resolver {
+	clientIPResolver, err := clientIP_util.NewResolver(&server_config.Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	So(err, ShouldBeNil)
+
+	return clientIPResolver
+}
+
 func Test_RateLimit_Handler(t *testing.T) {
 	Convey("When using rate limit handler", t, func() {
 		const remoteAddr = "192.168.1.1:8080"
 
-		factory := middleware.NewRateLimitFactory()
+		factory := middleware.NewRateLimitFactory(newClientIPResolver(), zerolog.Nop())
 
 		Convey("Should allow requests within limit", func() {
 			config := subrouter_config.RateLimitConfig{
@@ -98,15 +113,30 @@ func Test_RateLimit_Handler(t *testing.T) {
 
 			wrappedHandler := handler(testHandler)
 
-			for range 3 {
+			serve := func(remoteAddr, forwardedFor string) int {
 				req := httptest.NewRequest("GET", "http://example.com/api", nil)
-				req.Header.Set("X-Forwarded-For", "10.0.0.1")
+				req.Header.Set("X-Forwarded-For", forwardedFor)
 				req.RemoteAddr = remoteAddr
 				responseRecorder := httptest.NewRecorder()
 
 				wrappedHandler.ServeHTTP(responseRecorder, req)
-				So(responseRecorder.Code, ShouldEqual, http.StatusOK)
+
+				return responseRecorder.Code
 			}
+
+			for range 3 {
+				So(serve("10.0.0.2:8080", "203.0.113.1"), ShouldEqual, http.StatusOK)
+			}
+
+			So(serve("10.0.0.2:8080", "203.0.113.1"), ShouldEqual, http.StatusTooManyRequests)
+			So(serve("10.0.0.2:8080", "203.0.113.2"), ShouldEqual, http.StatusOK)
+
+			// Clients not behind a trusted proxy can't escape their limit by spoofing the header.
+			for range 3 {
+				So(serve(remoteAddr, "203.0.113.3"), ShouldEqual, http.StatusOK)
+			}
+
+			So(serve(remoteAddr, "203.0.113.4"), ShouldEqual, http.StatusTooManyRequests)
 		})
 
 		Convey("Should handle missing RemoteAddr gracefully", func() {
@@ -160,5 +190,87 @@ func Test_RateLimit_Handler(t *testing.T) {
 				So(responseRecorder.Body.String(), ShouldEqual, method)
 			}
 		})
+
+		Convey("Should apply the anonymous limit to requests which weren't authenticated", func() {
+			config := subrouter_config.RateLimitConfig{
+				Limit:          5,
+				Period:         time.Minute,
+				AnonymousLimit: 1,
+			}
+
+			rateLimit := factory.NewRateLimit(config)
+
+			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				responseWriter.WriteHeader(http.StatusOK)
+				_, _ = responseWriter.Write([]byte("success"))
+			})
+
+			wrappedHandler := rateLimit.Handler()(rateLimit.AnonymousHandler()(testHandler))
+
+			anonymousStatuses := make([]int, 0, 2)
+
+			for range 2 {
+				// A credential the authentication middlewares didn't accept doesn't make a request authenticated.
+				req := httptest.NewRequest("GET", "http://example.com/api", nil)
+				req.Header.Set("Authorization", "Bearer token")
+				req.RemoteAddr = remoteAddr
+				responseRecorder := httptest.NewRecorder()
+
+				wrappedHandler.ServeHTTP(responseRecorder, req)
+				anonymousStatuses = append(anonymousStatuses, responseRecorder.Code)
+			}
+
+			So(anonymousStatuses, ShouldResemble, []int{http.StatusOK, http.StatusTooManyRequests})
+
+			for range 2 {
+				req := httptest.NewRequest("GET", "http://example.com/api", nil)
+				req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{}))
+				req.RemoteAddr = remoteAddr
+				responseRecorder := httptest.NewRecorder()
+
+				wrappedHandler.ServeHTTP(responseRecorder, req)
+				So(responseRecorder.Code, ShouldEqual, http.StatusOK)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com/api", nil)
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+			req.RemoteAddr = remoteAddr
+			responseRecorder := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(responseRecorder, req)
+			So(responseRecorder.Code, ShouldEqual, http.StatusOK)
+
+			Convey("Should still hold authenticated requests to the limit", func() {
+				req := httptest.NewRequest("GET", "http://example.com/api", nil)
+				req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{}))
+				req.RemoteAddr = remoteAddr
+				responseRecorder := httptest.NewRecorder()
+
+				wrappedHandler.ServeHTTP(responseRecorder, req)
+				So(responseRecorder.Code, ShouldEqual, http.StatusTooManyRequests)
+			})
+		})
+
+		Convey("Should pass every request through the anonymous handler without an anonymous limit", func() {
+			rateLimit := factory.NewRateLimit(subrouter_config.RateLimitConfig{
+				Limit:  1,
+				Period: time.Minute,
+			})
+
+			testHandler := http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				responseWriter.WriteHeader(http.StatusOK)
+			})
+
+			wrappedHandler := rateLimit.AnonymousHandler()(testHandler)
+
+			for range 2 {
+				req := httptest.NewRequest("GET", "http://example.com/api", nil)
+				req.RemoteAddr = remoteAddr
+				responseRecorder := httptest.NewRecorder()
+
+				wrappedHandler.ServeHTTP(responseRecorder, req)
+				So(responseRecorder.Code, ShouldEqual, http.StatusOK)
+			}
+		})
 	})
 }
@@ -0,0 +1,196 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+	"go.uber.org/fx"
+)
+
+var (
+	ErrMissingRevocationFile = errors.New("token revocation requires a denylist file")
+	ErrMissingAdminToken     = errors.New("the revocation admin endpoint requires an admin token")
+)
+
+// IRevocation interface defines the methods of the token revocation middleware.
+type IRevocation interface {
+	AdminHandler() http.Handler
+	Handler(h http.Handler) http.Handler
+}
+
+// Revocation implements the IRevocation interface and rejects the tokens listed in the revocation denylist.
+type Revocation struct {
+	file       string
+	adminToken []byte
+	list       *revocationList
+	logger     zerolog.Logger
+
+	// fileMutex serializes the writes of the admin endpoint with the reloads of the denylist file.
+	fileMutex   sync.Mutex
+	fileModTime time.Time
+}
+
+type RevocationParams struct {
+	fx.In
+
+	Auth0Config *auth0_config.Config
+	Lifecycle   fx.Lifecycle
+	Logger      zerolog.Logger
+}
+
+// NewMiddleware loads the revocation denylist and starts watching its file for changes.
+// Without a revocation config, the middleware lets every request through.
+func NewMiddleware(params RevocationParams) (IRevocation, error) {
+	revocation := &Revocation{
+		list:   newRevocationList(),
+		logger: params.Logger,
+	}
+
+	config := params.Auth0Config.Revocation
+	if config == nil {
+		return revocation, nil
+	}
+
+	if config.File == "" {
+		return nil, ErrMissingRevocationFile
+	}
+
+	revocation.file = config.File
+
+	if config.AdminPath != "" {
+		adminToken, err := config_util.ReadSecret(config.AdminTokenFile, config.AdminTokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the admin token: %w", err)
+		}
+
+		if len(adminToken) == 0 {
+			return nil, ErrMissingAdminToken
+		}
+
+		revocation.adminToken = adminToken
+	}
+
+	if err := revocation.reload(); err != nil {
+		return nil, err
+	}
+
+	if config.PollInterval > 0 {
+		revocation.watch(params.Lifecycle, config.PollInterval)
+	}
+
+	return revocation, nil
+}
+
+// Handler rejects the requests whose validated token has been revoked. It has to run after the
+// authentication middleware; requests without validated claims are let through.
+func (r *Revocation) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		validatedClaims, ok := req.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+		if ok && r.list.isRevoked(validatedClaims) {
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeRevokedToken,
+				Message:    "Token has been revoked.",
+			})
+
+			return
+		}
+
+		h.ServeHTTP(responseWriter, req)
+	})
+}
+
+// watch polls the modification time of the denylist file while the application is running,
+// and reloads the file whenever it changes.
+func (r *Revocation) watch(lifecycle fx.Lifecycle, pollInterval time.Duration) {
+	var (
+		ticker *time.Ticker
+		done   = make(chan struct{})
+	)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			ticker = time.NewTicker(pollInterval)
+
+			go func() {
+				for {
+					select {
+					case <-ticker.C:
+						if err := r.reloadIfChanged(); err != nil {
+							r.logger.Error().Err(err).Msg("Failed to reload the token revocation denylist, keeping the previous one")
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			ticker.Stop()
+			close(done)
+
+			return nil
+		},
+	})
+}
+
+// reloadIfChanged reloads the denylist file if it has been modified since it was last read.
+func (r *Revocation) reloadIfChanged() error {
+	fileInfo, err := os.Stat(r.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read the revocation file: %w", err)
+	}
+
+	r.fileMutex.Lock()
+	isChanged := !fileInfo.ModTime().Equal(r.fileModTime)
+	r.fileMutex.Unlock()
+
+	if !isChanged {
+		return nil
+	}
+
+	return r.reload()
+}
+
+// reload replaces the denylist with the contents of its file.
+func (r *Revocation) reload() error {
+	r.fileMutex.Lock()
+	defer r.fileMutex.Unlock()
+
+	var modTime time.Time
+	if fileInfo, err := os.Stat(r.file); err == nil {
+		modTime = fileInfo.ModTime()
+	}
+
+	list, err := readDenylist(r.file)
+	if err != nil {
+		return err
+	}
+
+	if err := r.list.replace(list); err != nil {
+		return err
+	}
+
+	r.fileModTime = modTime
+
+	r.logger.Info().Msgf("Token revocation denylist loaded with %d token(s) and %d subject(s)", len(list.JTIs), len(list.Subjects))
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package revocation
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+// AdminHandler returns the handler of the revocation admin endpoint, which lists, adds and lifts revocations.
+func (r *Revocation) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		if !r.isAdmin(req) {
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeInvalidToken,
+				Message:    "Invalid admin token.",
+			})
+
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			responseWriter.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(responseWriter).Encode(r.list.snapshot())
+		case http.MethodPost, http.MethodDelete:
+			var revocation revocationEntry
+			if err := json.NewDecoder(http.MaxBytesReader(responseWriter, req.Body, 1<<16)).Decode(&revocation); err != nil {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusBadRequest,
+					Code:       errorResponse_util.CodeInvalidRequest,
+					Message:    "Invalid revocation.",
+					Reason:     err.Error(),
+				})
+
+				return
+			}
+
+			if err := r.update(req.Method, revocation); errors.Is(err, ErrInvalidRevocation) {
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusBadRequest,
+					Code:       errorResponse_util.CodeInvalidRequest,
+					Message:    "Invalid revocation.",
+					Reason:     err.Error(),
+				})
+
+				return
+			} else if err != nil {
+				r.logger.Error().Err(err).Msg("Failed to update the token revocation denylist")
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusInternalServerError,
+					Code:       errorResponse_util.CodeServerError,
+					Message:    "Revocation could not be saved.",
+					Reason:     err.Error(),
+				})
+
+				return
+			}
+
+			responseWriter.WriteHeader(http.StatusNoContent)
+		default:
+			responseWriter.Header().Set("Allow", "GET, POST, DELETE")
+			errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+				StatusCode: http.StatusMethodNotAllowed,
+				Code:       errorResponse_util.CodeMethodNotAllowed,
+				Message:    "Method not allowed.",
+			})
+		}
+	})
+}
+
+// isAdmin tells whether the request carries the admin token.
+func (r *Revocation) isAdmin(req *http.Request) bool {
+	if len(r.adminToken) == 0 {
+		return false
+	}
+
+	token, hasBearerToken := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	return hasBearerToken && subtle.ConstantTimeCompare([]byte(token), r.adminToken) == 1
+}
+
+// update applies a change of the admin endpoint to the denylist and writes it to the denylist file, undoing the
+// change if the file can't be written.
+func (r *Revocation) update(method string, revocation revocationEntry) error {
+	r.fileMutex.Lock()
+	defer r.fileMutex.Unlock()
+
+	previousList := r.list.snapshot()
+
+	var err error
+	if method == http.MethodPost {
+		err = r.list.add(revocation)
+	} else {
+		err = r.list.remove(revocation)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := writeDenylist(r.file, r.list.snapshot()); err != nil {
+		_ = r.list.replace(previousList)
+
+		return err
+	}
+
+	if fileInfo, err := os.Stat(r.file); err == nil {
+		r.fileModTime = fileInfo.ModTime()
+	}
+
+	r.logger.Info().
+		Str("method", method).
+		Str("jti", revocation.JTI).
+		Str("sub", revocation.Subject).
+		Msg("Token revocation denylist updated")
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package revocation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+var ErrInvalidRevocation = errors.New("a revocation must name either a jti or a sub")
+
+// revocationEntry revokes a single token by its jti, or every token of a subject issued before the given time.
+type revocationEntry struct {
+	JTI          string     `json:"jti,omitempty"`
+	Subject      string     `json:"sub,omitempty"`
+	IssuedBefore *time.Time `json:"issuedBefore,omitempty"`
+}
+
+// denylist is the document stored in the revocation file.
+type denylist struct {
+	JTIs     []string          `json:"jtis"`
+	Subjects []revocationEntry `json:"subjects"`
+}
+
+// revocationList holds the revoked tokens, indexed for lookups on every request.
+type revocationList struct {
+	mutex    sync.RWMutex
+	jtis     map[string]struct{}
+	subjects map[string]time.Time
+}
+
+func newRevocationList() *revocationList {
+	return &revocationList{
+		jtis:     make(map[string]struct{}),
+		subjects: make(map[string]time.Time),
+	}
+}
+
+// isRevoked tells whether the token of the validated claims has been revoked.
+// Tokens without an iat claim are revoked by any revocation of their subject.
+func (r *revocationList) isRevoked(validatedClaims *validator.ValidatedClaims) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if validatedClaims.RegisteredClaims.ID != "" {
+		if _, isRevoked := r.jtis[validatedClaims.RegisteredClaims.ID]; isRevoked {
+			return true
+		}
+	}
+
+	issuedBefore, isRevoked := r.subjects[validatedClaims.RegisteredClaims.Subject]
+	if !isRevoked || validatedClaims.RegisteredClaims.Subject == "" {
+		return false
+	}
+
+	return validatedClaims.RegisteredClaims.IssuedAt == 0 || time.Unix(validatedClaims.RegisteredClaims.IssuedAt, 0).Before(issuedBefore)
+}
+
+// replace swaps the contents of the list for those of the denylist.
+func (r *revocationList) replace(list denylist) error {
+	jtis := make(map[string]struct{}, len(list.JTIs))
+	for _, jti := range list.JTIs {
+		jtis[jti] = struct{}{}
+	}
+
+	subjects := make(map[string]time.Time, len(list.Subjects))
+	for _, revocation := range list.Subjects {
+		if revocation.Subject == "" || revocation.IssuedBefore == nil {
+			return fmt.Errorf("%w: subject revocations need a sub and an issuedBefore time", ErrInvalidRevocation)
+		}
+
+		subjects[revocation.Subject] = *revocation.IssuedBefore
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.jtis, r.subjects = jtis, subjects
+
+	return nil
+}
+
+// add revokes a token or a subject. Revoking a subject again moves its issued-before time.
+func (r *revocationList) add(revocation revocationEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	switch {
+	case revocation.JTI != "" && revocation.Subject == "":
+		r.jtis[revocation.JTI] = struct{}{}
+	case revocation.Subject != "" && revocation.JTI == "":
+		issuedBefore := time.Now()
+		if revocation.IssuedBefore != nil {
+			issuedBefore = *revocation.IssuedBefore
+		}
+
+		r.subjects[revocation.Subject] = issuedBefore
+	default:
+		return ErrInvalidRevocation
+	}
+
+	return nil
+}
+
+// remove lifts the revocation of a token or a subject.
+func (r *revocationList) remove(revocation revocationEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	switch {
+	case revocation.JTI != "" && revocation.Subject == "":
+		delete(r.jtis, revocation.JTI)
+	case revocation.Subject != "" && revocation.JTI == "":
+		delete(r.subjects, revocation.Subject)
+	default:
+		return ErrInvalidRevocation
+	}
+
+	return nil
+}
+
+// snapshot returns the contents of the list as a denylist document, in a stable order.
+func (r *revocationList) snapshot() denylist {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	list := denylist{
+		JTIs:     make([]string, 0, len(r.jtis)),
+		Subjects: make([]revocationEntry, 0, len(r.subjects)),
+	}
+
+	for jti := range r.jtis {
+		list.JTIs = append(list.JTIs, jti)
+	}
+
+	for subject, issuedBefore := range r.subjects {
+		list.Subjects = append(list.Subjects, revocationEntry{Subject: subject, IssuedBefore: &issuedBefore})
+	}
+
+	slices.Sort(list.JTIs)
+	slices.SortFunc(list.Subjects, func(a, b revocationEntry) int {
+		switch {
+		case a.Subject < b.Subject:
+			return -1
+		case a.Subject > b.Subject:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return list
+}
+
+// readDenylist reads the denylist file. A missing file is an empty denylist,
+// so that the admin endpoint can create it.
+func readDenylist(path string) (denylist, error) {
+	var list denylist
+
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	}
+
+	if err != nil {
+		return list, fmt.Errorf("failed to read the revocation file: %w", err)
+	}
+
+	if err := json.Unmarshal(contents, &list); err != nil {
+		return list, fmt.Errorf("failed to decode the revocation file: %w", err)
+	}
+
+	return list, nil
+}
+
+// writeDenylist replaces the denylist file atomically, so that it is never read half written.
+func writeDenylist(path string, list denylist) error {
+	contents, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the revocation file: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write the revocation file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(contents); err != nil {
+		tempFile.Close()
+
+		return fmt.Errorf("failed to write the revocation file: %w", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to write the revocation file: %w", err)
+	}
+
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to write the revocation file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package revocation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/revocation"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/fx/fxtest"
+)
+
+const (
+	adminToken = "admin-secret"
+	denylist   = `{
+	"jtis": ["revoked-jti"],
+	"subjects": [{"sub": "auth0|revoked", "issuedBefore": "2025-01-01T00:00:00Z"}]
+}`
+)
+
+var issuedBeforeRevocation = time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC).Unix()
+
+func newRevocationParams(t *testing.T, config *auth0_config.RevocationConfig) middleware.RevocationParams {
+	return middleware.RevocationParams{
+		Auth0Config: &auth0_config.Config{Revocation: config},
+		Lifecycle:   fxtest.NewLifecycle(t),
+		Logger:      zerolog.Nop(),
+	}
+}
+
+func writeDenylistFile(path, contents string) {
+	So(os.WriteFile(path, []byte(contents), 0o600), ShouldBeNil)
+}
+
+func serveWithClaims(revocation middleware.IRevocation, claims *validator.ValidatedClaims) *httptest.ResponseRecorder {
+	handler := revocation.Handler(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+		responseWriter.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, claims))
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func claimsOf(subject, jti string, issuedAt int64) *validator.ValidatedClaims {
+	return &validator.ValidatedClaims{
+		RegisteredClaims: validator.RegisteredClaims{
+			Subject:  subject,
+			ID:       jti,
+			IssuedAt: issuedAt,
+		},
+	}
+}
+
+func Test_NewMiddleware(t *testing.T) {
+	Convey("When creating a new revocation middleware", t, func() {
+		revocationFile := filepath.Join(t.TempDir(), "revocations.json")
+
+		Convey("Without a revocation config", func() {
+			revocation, err := middleware.NewMiddleware(newRevocationParams(t, nil))
+			So(err, ShouldBeNil)
+			So(revocation, ShouldImplement, (*middleware.IRevocation)(nil))
+
+			Convey("Should let every request through", func() {
+				So(serveWithClaims(revocation, claimsOf("auth0|revoked", "revoked-jti", 0)).Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("Without a denylist file", func() {
+			revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{}))
+			So(err, ShouldWrap, middleware.ErrMissingRevocationFile)
+			So(revocation, ShouldBeNil)
+		})
+
+		Convey("With a denylist file that does not exist yet", func() {
+			revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{File: revocationFile}))
+			So(err, ShouldBeNil)
+			So(revocation, ShouldNotBeNil)
+		})
+
+		Convey("With a denylist file that is not valid JSON", func() {
+			writeDenylistFile(revocationFile, "not-json")
+
+			revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{File: revocationFile}))
+			So(err, ShouldNotBeNil)
+			So(revocation, ShouldBeNil)
+		})
+
+		Convey("With a subject revocation without an issuedBefore time", func() {
+			writeDenylistFile(revocationFile, `{"subjects": [{"sub": "auth0|revoked"}]}`)
+
+			revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{File: revocationFile}))
+			So(err, ShouldWrap, middleware.ErrInvalidRevocation)
+			So(revocation, ShouldBeNil)
+		})
+
+		Convey("With an admin path but no admin token", func() {
+			revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{
+				File:      revocationFile,
+				AdminPath: "/admin/revocations",
+			}))
+			So(err, ShouldWrap, middleware.ErrMissingAdminToken)
+			So(revocation, ShouldBeNil)
+		})
+	})
+}
+
+func Test_Revocation_Handler(t *testing.T) {
+	Convey("When using the revocation middleware", t, func() {
+		revocationFile := filepath.Join(t.TempDir(), "revocations.json")
+		writeDenylistFile(revocationFile, denylist)
+
+		revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{File: revocationFile}))
+		So(err, ShouldBeNil)
+
+		Convey("Should reject a token revoked by its jti", func() {
+			recorder := serveWithClaims(revocation, claimsOf("auth0|user", "revoked-jti", time.Now().Unix()))
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"revoked_token","message":"Token has been revoked."}`)
+		})
+
+		Convey("Should reject a token of a revoked subject issued before the revocation", func() {
+			So(serveWithClaims(revocation, claimsOf("auth0|revoked", "", issuedBeforeRevocation)).Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("Should reject a token of a revoked subject without an iat claim", func() {
+			So(serveWithClaims(revocation, claimsOf("auth0|revoked", "", 0)).Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("Should accept a token of a revoked subject issued after the revocation", func() {
+			So(serveWithClaims(revocation, claimsOf("auth0|revoked", "", time.Now().Unix())).Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should accept a token that is not revoked", func() {
+			So(serveWithClaims(revocation, claimsOf("auth0|user", "valid-jti", time.Now().Unix())).Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should let requests without validated claims through", func() {
+			So(serveWithClaims(revocation, nil).Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+func Test_Revocation_Reload(t *testing.T) {
+	Convey("When the denylist file changes while the application is running", t, func() {
+		revocationFile := filepath.Join(t.TempDir(), "revocations.json")
+		writeDenylistFile(revocationFile, `{"jtis": []}`)
+
+		params := newRevocationParams(t, &auth0_config.RevocationConfig{
+			File:         revocationFile,
+			PollInterval: 10 * time.Millisecond,
+		})
+
+		revocation, err := middleware.NewMiddleware(params)
+		So(err, ShouldBeNil)
+
+		lifecycle := params.Lifecycle.(*fxtest.Lifecycle)
+		lifecycle.RequireStart()
+		defer lifecycle.RequireStop()
+
+		claims := claimsOf("auth0|user", "revoked-jti", time.Now().Unix())
+		So(serveWithClaims(revocation, claims).Code, ShouldEqual, http.StatusOK)
+
+		Convey("Should pick up the new revocations", func() {
+			writeDenylistFile(revocationFile, denylist)
+			So(os.Chtimes(revocationFile, time.Now(), time.Now().Add(time.Minute)), ShouldBeNil)
+
+			So(waitForStatus(revocation, claims, http.StatusUnauthorized), ShouldBeTrue)
+		})
+
+		Convey("Should keep the previous revocations if the new file is invalid", func() {
+			writeDenylistFile(revocationFile, denylist)
+			So(os.Chtimes(revocationFile, time.Now(), time.Now().Add(time.Minute)), ShouldBeNil)
+			So(waitForStatus(revocation, claims, http.StatusUnauthorized), ShouldBeTrue)
+
+			writeDenylistFile(revocationFile, "not-json")
+			So(os.Chtimes(revocationFile, time.Now(), time.Now().Add(2*time.Minute)), ShouldBeNil)
+			time.Sleep(50 * time.Millisecond)
+
+			So(serveWithClaims(revocation, claims).Code, ShouldEqual, http.StatusUnauthorized)
+		})
+	})
+}
+
+func Test_Revocation_AdminHandler(t *testing.T) {
+	Convey("When using the revocation admin endpoint", t, func() {
+		revocationFile := filepath.Join(t.TempDir(), "revocations.json")
+		t.Setenv("TEST_REVOCATION_ADMIN_TOKEN", adminToken)
+
+		revocation, err := middleware.NewMiddleware(newRevocationParams(t, &auth0_config.RevocationConfig{
+			File:          revocationFile,
+			AdminPath:     "/admin/revocations",
+			AdminTokenEnv: "TEST_REVOCATION_ADMIN_TOKEN",
+		}))
+		So(err, ShouldBeNil)
+
+		serveAdmin := func(method, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/admin/revocations", strings.NewReader(body))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			recorder := httptest.NewRecorder()
+			revocation.AdminHandler().ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("Should reject requests without the admin token", func() {
+			So(serveAdmin(http.MethodGet, "", "").Code, ShouldEqual, http.StatusUnauthorized)
+			So(serveAdmin(http.MethodGet, "wrong-token", "").Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("Should revoke a token by its jti and persist the denylist", func() {
+			So(serveAdmin(http.MethodPost, adminToken, `{"jti": "revoked-jti"}`).Code, ShouldEqual, http.StatusNoContent)
+			So(serveWithClaims(revocation, claimsOf("auth0|user", "revoked-jti", time.Now().Unix())).Code, ShouldEqual, http.StatusUnauthorized)
+
+			fileContents, err := os.ReadFile(revocationFile)
+			So(err, ShouldBeNil)
+			So(string(fileContents), ShouldContainSubstring, `"revoked-jti"`)
+
+			recorder := serveAdmin(http.MethodGet, adminToken, "")
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Body.String(), ShouldContainSubstring, `"jtis":["revoked-jti"]`)
+
+			Convey("Should lift the revocation", func() {
+				So(serveAdmin(http.MethodDelete, adminToken, `{"jti": "revoked-jti"}`).Code, ShouldEqual, http.StatusNoContent)
+				So(serveWithClaims(revocation, claimsOf("auth0|user", "revoked-jti", time.Now().Unix())).Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("Should revoke the tokens of a subject issued until now", func() {
+			So(serveAdmin(http.MethodPost, adminToken, `{"sub": "auth0|revoked"}`).Code, ShouldEqual, http.StatusNoContent)
+			So(serveWithClaims(revocation, claimsOf("auth0|revoked", "", time.Now().Add(-time.Minute).Unix())).Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("Should reject an invalid revocation", func() {
+			So(serveAdmin(http.MethodPost, adminToken, `{}`).Code, ShouldEqual, http.StatusBadRequest)
+			So(serveAdmin(http.MethodPost, adminToken, `not-json`).Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Should undo a revocation that can't be written to the denylist file", func() {
+			So(os.Mkdir(revocationFile, 0o755), ShouldBeNil)
+
+			recorder := serveAdmin(http.MethodPost, adminToken, `{"jti": "revoked-jti"}`)
+			So(recorder.Code, ShouldEqual, http.StatusInternalServerError)
+			So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeServerError)
+			So(serveWithClaims(revocation, claimsOf("auth0|user", "revoked-jti", time.Now().Unix())).Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should reject other methods", func() {
+			So(serveAdmin(http.MethodPut, adminToken, "").Code, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+	})
+}
+
+func waitForStatus(revocation middleware.IRevocation, claims *validator.ValidatedClaims, statusCode int) bool {
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if serveWithClaims(revocation, claims).Code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
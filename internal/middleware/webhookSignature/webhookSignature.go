@@ -0,0 +1,217 @@
+package webhookSignature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gorilla/mux"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+)
+
+var (
+	ErrMissingSignature   = errors.New("the request has no signature")
+	ErrMissingTimestamp   = errors.New("the request has no timestamp")
+	ErrInvalidTimestamp   = errors.New("the timestamp is not a unix time")
+	ErrStaleTimestamp     = errors.New("the timestamp is outside the tolerance window")
+	ErrSignatureMismatch  = errors.New("the signature does not match the request")
+	ErrRequestBodyTooLong = errors.New("the request body is too large to verify")
+)
+
+// IWebhookSignature interface defines the methods of the webhook signature verification middleware.
+type IWebhookSignature interface {
+	Handler() mux.MiddlewareFunc
+}
+
+// WebhookSignature implements the IWebhookSignature interface and provides the webhook signature verification middleware.
+type WebhookSignature struct {
+	middlewareFunc mux.MiddlewareFunc
+}
+
+// Handler returns the webhook signature verification middleware function.
+func (w *WebhookSignature) Handler() mux.MiddlewareFunc {
+	return w.middlewareFunc
+}
+
+// signedPayload is the data the payload template builds the signed payload from.
+type signedPayload struct {
+	Method    string
+	Path      string
+	Timestamp string
+	Body      string
+}
+
+// signatureVerifier checks the signatures of requests against the HMAC of their signed payload.
+type signatureVerifier struct {
+	config          subrouter_config.WebhookSignatureConfig
+	secret          []byte
+	newHash         func() hash.Hash
+	decodeSignature func(string) ([]byte, error)
+	payloadTemplate *template.Template
+}
+
+// buildWebhookSignatureMiddlewareFunc builds the middleware letting through only requests with a valid signature.
+// The body is read to verify it, and replaced with a buffered copy, so that it is proxied intact.
+func buildWebhookSignatureMiddlewareFunc(config subrouter_config.WebhookSignatureConfig, verifier *signatureVerifier) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			body, err := bufferBody(responseWriter, req, config.MaxBodySize)
+			if err != nil {
+				statusCode, code, message := http.StatusBadRequest, errorResponse_util.CodeInvalidRequest, "Request body could not be read."
+
+				var maxBytesError *http.MaxBytesError
+				if errors.As(err, &maxBytesError) {
+					statusCode, code, message = http.StatusRequestEntityTooLarge, errorResponse_util.CodeRequestTooLarge, "Request body too large."
+				}
+
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: statusCode,
+					Code:       code,
+					Message:    message,
+					Reason:     err.Error(),
+				})
+
+				return
+			}
+
+			if err := verifier.verify(req, body, time.Now()); err != nil {
+				code, message := errorResponse_util.CodeInvalidSignature, "Invalid request signature."
+				if errors.Is(err, ErrMissingSignature) {
+					code, message = errorResponse_util.CodeMissingSignature, "Missing request signature."
+				}
+
+				errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+					StatusCode: http.StatusUnauthorized,
+					Code:       code,
+					Message:    message,
+					Reason:     err.Error(),
+				})
+
+				return
+			}
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// bufferBody reads the body of the request, up to maxBodySize bytes, and replaces it with the buffered copy.
+func bufferBody(responseWriter http.ResponseWriter, req *http.Request, maxBodySize int) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(responseWriter, req.Body, int64(maxBodySize)))
+	_ = req.Body.Close()
+
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return nil, fmt.Errorf("%w: %w", ErrRequestBodyTooLong, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	return body, nil
+}
+
+// verify checks that the request carries a signature of its payload made with the secret, and that its timestamp,
+// if the senders sign one, is within the tolerance window around now.
+func (s *signatureVerifier) verify(req *http.Request, body []byte, now time.Time) error {
+	signatures, timestamp := s.readSignatureHeader(req.Header.Get(s.config.Header))
+	if len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	if s.config.TimestampHeader != "" {
+		timestamp = req.Header.Get(s.config.TimestampHeader)
+	}
+
+	if s.config.TimestampHeader != "" || s.config.TimestampKey != "" {
+		if err := s.checkTimestamp(timestamp, now); err != nil {
+			return err
+		}
+	}
+
+	var payload bytes.Buffer
+
+	if err := s.payloadTemplate.Execute(&payload, signedPayload{
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Timestamp: timestamp,
+		Body:      string(body),
+	}); err != nil {
+		return fmt.Errorf("failed to build the signed payload: %w", err)
+	}
+
+	mac := hmac.New(s.newHash, s.secret)
+	mac.Write(payload.Bytes())
+	expectedSignature := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decodedSignature, err := s.decodeSignature(signature)
+		if err == nil && hmac.Equal(decodedSignature, expectedSignature) {
+			return nil
+		}
+	}
+
+	return ErrSignatureMismatch
+}
+
+// readSignatureHeader returns the signatures in the value of the signature header, and its timestamp if it has one.
+func (s *signatureVerifier) readSignatureHeader(value string) ([]string, string) {
+	if s.config.SignatureKey == "" {
+		signature, hasPrefix := strings.CutPrefix(value, s.config.Prefix)
+		if !hasPrefix || signature == "" {
+			return nil, ""
+		}
+
+		return []string{signature}, ""
+	}
+
+	var (
+		signatures []string
+		timestamp  string
+	)
+
+	for _, pair := range strings.Split(value, ",") {
+		key, pairValue, _ := strings.Cut(strings.TrimSpace(pair), "=")
+
+		switch key {
+		case s.config.SignatureKey:
+			signatures = append(signatures, strings.TrimPrefix(pairValue, s.config.Prefix))
+		case s.config.TimestampKey:
+			timestamp = pairValue
+		}
+	}
+
+	return signatures, timestamp
+}
+
+func (s *signatureVerifier) checkTimestamp(timestamp string, now time.Time) error {
+	if timestamp == "" {
+		return ErrMissingTimestamp
+	}
+
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTimestamp, timestamp)
+	}
+
+	if age := now.Sub(time.Unix(unixTime, 0)); age > s.config.Tolerance || age < -s.config.Tolerance {
+		return fmt.Errorf("%w: %s old", ErrStaleTimestamp, age.Truncate(time.Second))
+	}
+
+	return nil
+}
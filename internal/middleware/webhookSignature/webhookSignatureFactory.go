@@ -0,0 +1,161 @@
+package webhookSignature
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"text/template"
+	"text/template/parse"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+)
+
+// defaultPayloadTemplate signs the body alone, as most webhook senders do.
+const defaultPayloadTemplate = "{{.Body}}"
+
+var (
+	ErrMissingSignatureHeader   = errors.New("a signature header is required")
+	ErrMissingSignatureSecret   = errors.New("a signature secret is required")
+	ErrUnknownSignatureHash     = errors.New("unknown signature algorithm")
+	ErrUnknownSignatureEncoding = errors.New("unknown signature encoding")
+	ErrInvalidPayloadTemplate   = errors.New("invalid signed payload template")
+	ErrUnsignedTimestamp        = errors.New("a timestamp source requires a payload template signing .Timestamp")
+	ErrMissingTimestampSource   = errors.New("a payload template signing .Timestamp requires a timestampHeader or timestampKey")
+)
+
+var signatureHashes = map[string]func() hash.Hash{
+	subrouter_config.SignatureAlgorithmSHA1:   sha1.New,
+	subrouter_config.SignatureAlgorithmSHA256: sha256.New,
+	subrouter_config.SignatureAlgorithmSHA512: sha512.New,
+}
+
+var signatureDecoders = map[string]func(string) ([]byte, error){
+	subrouter_config.SignatureEncodingHex:    hex.DecodeString,
+	subrouter_config.SignatureEncodingBase64: base64.StdEncoding.DecodeString,
+}
+
+// Factory interface for creating webhook signature verification middleware for subrouters.
+type IWebhookSignatureFactory interface {
+	NewWebhookSignature(config subrouter_config.WebhookSignatureConfig) (IWebhookSignature, error)
+}
+
+// WebhookSignatureFactory implements the IWebhookSignatureFactory interface to create webhook signature verification middleware.
+type WebhookSignatureFactory struct{}
+
+// NewWebhookSignatureFactory creates a new webhook signature middleware factory.
+func NewWebhookSignatureFactory() IWebhookSignatureFactory {
+	return &WebhookSignatureFactory{}
+}
+
+// NewWebhookSignature creates a new middleware verifying the HMAC signatures of webhook requests with the configured secret.
+func (w *WebhookSignatureFactory) NewWebhookSignature(config subrouter_config.WebhookSignatureConfig) (IWebhookSignature, error) {
+	if config.Header == "" {
+		return nil, ErrMissingSignatureHeader
+	}
+
+	newHash, isKnown := signatureHashes[config.Algorithm]
+	if !isKnown {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownSignatureHash, config.Algorithm)
+	}
+
+	decodeSignature, isKnown := signatureDecoders[config.Encoding]
+	if !isKnown {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownSignatureEncoding, config.Encoding)
+	}
+
+	secret, err := config_util.ReadSecret(config.SecretFile, config.SecretEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signature secret: %w", err)
+	}
+
+	if len(secret) == 0 {
+		return nil, ErrMissingSignatureSecret
+	}
+
+	payloadTemplate := config.PayloadTemplate
+	if payloadTemplate == "" {
+		payloadTemplate = defaultPayloadTemplate
+	}
+
+	parsedPayloadTemplate, err := template.New("payload").Option("missingkey=error").Parse(payloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayloadTemplate, err)
+	}
+
+	// An unsigned timestamp could be replaced to replay captured requests, and a signed one without a source would
+	// be empty, leaving the tolerance window unenforced.
+	hasTimestampSource := config.TimestampHeader != "" || config.TimestampKey != ""
+	signsTimestamp := referencesField(parsedPayloadTemplate.Root, "Timestamp")
+
+	if hasTimestampSource && !signsTimestamp {
+		return nil, ErrUnsignedTimestamp
+	}
+
+	if signsTimestamp && !hasTimestampSource {
+		return nil, ErrMissingTimestampSource
+	}
+
+	verifier := &signatureVerifier{
+		config:          config,
+		secret:          secret,
+		newHash:         newHash,
+		decodeSignature: decodeSignature,
+		payloadTemplate: parsedPayloadTemplate,
+	}
+
+	return &WebhookSignature{
+		middlewareFunc: buildWebhookSignatureMiddlewareFunc(config, verifier),
+	}, nil
+}
+
+// referencesField reports whether the template node, or any node within it, references the field of the payload.
+func referencesField(node parse.Node, field string) bool {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return false
+		}
+
+		for _, childNode := range node.Nodes {
+			if referencesField(childNode, field) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return referencesField(node.Pipe, field)
+	case *parse.PipeNode:
+		if node == nil {
+			return false
+		}
+
+		for _, command := range node.Cmds {
+			if referencesField(command, field) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, argument := range node.Args {
+			if referencesField(argument, field) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return len(node.Ident) > 0 && node.Ident[0] == field
+	case *parse.IfNode:
+		return referencesField(&node.BranchNode, field)
+	case *parse.RangeNode:
+		return referencesField(&node.BranchNode, field)
+	case *parse.WithNode:
+		return referencesField(&node.BranchNode, field)
+	case *parse.BranchNode:
+		return referencesField(node.Pipe, field) || referencesField(node.List, field) || referencesField(node.ElseList, field)
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package webhookSignature_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/webhookSignature"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NewWebhookSignatureFactory(t *testing.T) {
+	Convey("When creating a new webhook signature factory", t, func() {
+		t.Setenv("TEST_WEBHOOK_SECRET", webhookSecret)
+
+		factory := middleware.NewWebhookSignatureFactory()
+		So(factory, ShouldNotBeNil)
+		So(factory, ShouldImplement, (*middleware.IWebhookSignatureFactory)(nil))
+
+		config := subrouter_config.WebhookSignatureConfig{
+			Header:      "X-Signature",
+			Algorithm:   subrouter_config.SignatureAlgorithmSHA256,
+			Encoding:    subrouter_config.SignatureEncodingHex,
+			SecretEnv:   "TEST_WEBHOOK_SECRET",
+			Tolerance:   5 * time.Minute,
+			MaxBodySize: 1024,
+		}
+
+		Convey("Should create a webhook signature middleware", func() {
+			webhookSignature, err := factory.NewWebhookSignature(config)
+			So(err, ShouldBeNil)
+			So(webhookSignature, ShouldImplement, (*middleware.IWebhookSignature)(nil))
+			So(webhookSignature.Handler(), ShouldNotBeNil)
+		})
+
+		Convey("Should read the secret from a file", func() {
+			secretFile := filepath.Join(t.TempDir(), "secret")
+			So(os.WriteFile(secretFile, []byte(webhookSecret+"\n"), 0o600), ShouldBeNil)
+
+			config.SecretEnv = ""
+			config.SecretFile = secretFile
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Should fail without a signature header", func() {
+			config.Header = ""
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrMissingSignatureHeader)
+		})
+
+		Convey("Should fail without a secret", func() {
+			config.SecretEnv = "TEST_UNSET_WEBHOOK_SECRET"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrMissingSignatureSecret)
+		})
+
+		Convey("Should fail for an unknown algorithm", func() {
+			config.Algorithm = "md5"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrUnknownSignatureHash)
+		})
+
+		Convey("Should fail for an unknown encoding", func() {
+			config.Encoding = "base32"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrUnknownSignatureEncoding)
+		})
+
+		Convey("Should create a webhook signature middleware signing the timestamp of its source", func() {
+			config.TimestampKey = "t"
+			config.PayloadTemplate = "{{if .Timestamp}}{{.Timestamp}}.{{end}}{{.Body}}"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Should fail for a timestamp source the payload template doesn't sign", func() {
+			config.TimestampHeader = "X-Timestamp"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrUnsignedTimestamp)
+		})
+
+		Convey("Should fail for a payload template signing a timestamp without a source", func() {
+			config.PayloadTemplate = "{{.Timestamp}}.{{.Body}}"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrMissingTimestampSource)
+		})
+
+		Convey("Should fail for an invalid payload template", func() {
+			config.PayloadTemplate = "{{.Timestamp"
+
+			_, err := factory.NewWebhookSignature(config)
+			So(err, ShouldWrap, middleware.ErrInvalidPayloadTemplate)
+		})
+	})
+}
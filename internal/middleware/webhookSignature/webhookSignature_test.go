@@ -0,0 +1,193 @@
+package webhookSignature_test
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/webhookSignature"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	webhookSecret = "whsec_test-secret"
+	webhookBody   = `{"type":"invoice.paid","id":"evt_1"}`
+)
+
+func sign(newHash func() hash.Hash, payload string) []byte {
+	mac := hmac.New(newHash, []byte(webhookSecret))
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
+
+func Test_WebhookSignature(t *testing.T) {
+	Convey("When verifying webhook signatures", t, func() {
+		t.Setenv("TEST_WEBHOOK_SECRET", webhookSecret)
+
+		var proxiedBody string
+
+		newHandler := func(config subrouter_config.WebhookSignatureConfig) http.Handler {
+			config.SecretEnv = "TEST_WEBHOOK_SECRET"
+			config.Algorithm = cmp.Or(config.Algorithm, subrouter_config.SignatureAlgorithmSHA256)
+			config.Encoding = cmp.Or(config.Encoding, subrouter_config.SignatureEncodingHex)
+			config.Tolerance = 5 * time.Minute
+			config.MaxBodySize = 1024
+
+			webhookSignature, err := middleware.NewWebhookSignatureFactory().NewWebhookSignature(config)
+			So(err, ShouldBeNil)
+
+			return errorResponse_util.WithVerboseReasons(true)(webhookSignature.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				proxiedBody = string(body)
+
+				responseWriter.WriteHeader(http.StatusOK)
+			})))
+		}
+
+		serve := func(handler http.Handler, body string, headers map[string]string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/webhooks/billing", strings.NewReader(body))
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		Convey("With a prefixed signature of the body", func() {
+			handler := newHandler(subrouter_config.WebhookSignatureConfig{
+				Header: "X-Hub-Signature-256",
+				Prefix: "sha256=",
+			})
+			signature := "sha256=" + hex.EncodeToString(sign(sha256.New, webhookBody))
+
+			Convey("Should let valid requests through with their body intact", func() {
+				recorder := serve(handler, webhookBody, map[string]string{"X-Hub-Signature-256": signature})
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(proxiedBody, ShouldEqual, webhookBody)
+			})
+
+			Convey("Should reject requests without a signature", func() {
+				recorder := serve(handler, webhookBody, nil)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeMissingSignature)
+			})
+
+			Convey("Should reject requests with a tampered body", func() {
+				recorder := serve(handler, strings.Replace(webhookBody, "evt_1", "evt_2", 1), map[string]string{"X-Hub-Signature-256": signature})
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeInvalidSignature)
+			})
+
+			Convey("Should reject signatures without the prefix", func() {
+				recorder := serve(handler, webhookBody, map[string]string{"X-Hub-Signature-256": strings.TrimPrefix(signature, "sha256=")})
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeMissingSignature)
+			})
+
+			Convey("Should reject bodies too large to verify", func() {
+				recorder := serve(handler, strings.Repeat("a", 2048), map[string]string{"X-Hub-Signature-256": signature})
+				So(recorder.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeRequestTooLarge)
+			})
+
+			Convey("Should reject bodies that can't be read", func() {
+				req := httptest.NewRequest("POST", "/webhooks/billing", iotest.ErrReader(io.ErrUnexpectedEOF))
+				req.Header.Set("X-Hub-Signature-256", signature)
+
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, req)
+				So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeInvalidRequest)
+			})
+		})
+
+		Convey("With a signed timestamp in the signature header", func() {
+			handler := newHandler(subrouter_config.WebhookSignatureConfig{
+				Header:          "Stripe-Signature",
+				SignatureKey:    "v1",
+				TimestampKey:    "t",
+				PayloadTemplate: "{{.Timestamp}}.{{.Body}}",
+			})
+
+			signatureHeader := func(timestamp time.Time, signatures ...string) string {
+				unixTime := strconv.FormatInt(timestamp.Unix(), 10)
+
+				header := "t=" + unixTime
+				for _, signature := range signatures {
+					header += ",v1=" + signature
+				}
+
+				return header
+			}
+
+			signAt := func(timestamp time.Time) string {
+				return hex.EncodeToString(sign(sha256.New, strconv.FormatInt(timestamp.Unix(), 10)+"."+webhookBody))
+			}
+
+			Convey("Should accept any of the listed signatures", func() {
+				now := time.Now()
+
+				recorder := serve(handler, webhookBody, map[string]string{"Stripe-Signature": signatureHeader(now, "deadbeef", signAt(now))})
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should reject replays outside the tolerance window", func() {
+				past := time.Now().Add(-10 * time.Minute)
+
+				recorder := serve(handler, webhookBody, map[string]string{"Stripe-Signature": signatureHeader(past, signAt(past))})
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, "tolerance window")
+			})
+
+			Convey("Should reject signatures of another timestamp", func() {
+				now := time.Now()
+
+				recorder := serve(handler, webhookBody, map[string]string{"Stripe-Signature": signatureHeader(now, signAt(now.Add(-time.Minute)))})
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, errorResponse_util.CodeInvalidSignature)
+			})
+		})
+
+		Convey("With a timestamp header and a base64 encoded signature", func() {
+			handler := newHandler(subrouter_config.WebhookSignatureConfig{
+				Header:          "X-Signature",
+				Algorithm:       subrouter_config.SignatureAlgorithmSHA1,
+				Encoding:        subrouter_config.SignatureEncodingBase64,
+				TimestampHeader: "X-Timestamp",
+				PayloadTemplate: "v0:{{.Timestamp}}:{{.Method}}:{{.Path}}:{{.Body}}",
+			})
+
+			Convey("Should verify the payload built by the template", func() {
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+				signature := base64.StdEncoding.EncodeToString(sign(sha1.New, "v0:"+timestamp+":POST:/webhooks/billing:"+webhookBody))
+
+				recorder := serve(handler, webhookBody, map[string]string{"X-Signature": signature, "X-Timestamp": timestamp})
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Should reject requests without a timestamp", func() {
+				recorder := serve(handler, webhookBody, map[string]string{"X-Signature": "c2lnbmF0dXJl"})
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+				So(recorder.Body.String(), ShouldContainSubstring, "no timestamp")
+			})
+		})
+	})
+}
@@ -39,7 +39,7 @@ func (_m *IAuth0ValidatorFactory) EXPECT() *IAuth0ValidatorFactory_Expecter {
 }
 
 // NewAuth0ScopeValidator provides a mock function for the type IAuth0ValidatorFactory
-func (_mock *IAuth0ValidatorFactory) NewAuth0ScopeValidator(config subrouter.AuthorizationConfig) auth0.IAuth0ScopeValidator {
+func (_mock *IAuth0ValidatorFactory) NewAuth0ScopeValidator(config subrouter.AuthorizationConfig) (auth0.IAuth0ScopeValidator, error) {
 	ret := _mock.Called(config)
 
 	if len(ret) == 0 {
@@ -47,6 +47,10 @@ func (_mock *IAuth0ValidatorFactory) NewAuth0ScopeValidator(config subrouter.Aut
 	}
 
 	var r0 auth0.IAuth0ScopeValidator
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(subrouter.AuthorizationConfig) (auth0.IAuth0ScopeValidator, error)); ok {
+		return returnFunc(config)
+	}
 	if returnFunc, ok := ret.Get(0).(func(subrouter.AuthorizationConfig) auth0.IAuth0ScopeValidator); ok {
 		r0 = returnFunc(config)
 	} else {
@@ -54,7 +58,12 @@ func (_mock *IAuth0ValidatorFactory) NewAuth0ScopeValidator(config subrouter.Aut
 			r0 = ret.Get(0).(auth0.IAuth0ScopeValidator)
 		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(subrouter.AuthorizationConfig) error); ok {
+		r1 = returnFunc(config)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
 // IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NewAuth0ScopeValidator'
@@ -81,19 +90,19 @@ func (_c *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call) Run(run func(confi
 	return _c
 }
 
-func (_c *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call) Return(iAuth0ScopeValidator auth0.IAuth0ScopeValidator) *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call {
-	_c.Call.Return(iAuth0ScopeValidator)
+func (_c *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call) Return(iAuth0ScopeValidator auth0.IAuth0ScopeValidator, err error) *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call {
+	_c.Call.Return(iAuth0ScopeValidator, err)
 	return _c
 }
 
-func (_c *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call) RunAndReturn(run func(config subrouter.AuthorizationConfig) auth0.IAuth0ScopeValidator) *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call {
+func (_c *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call) RunAndReturn(run func(config subrouter.AuthorizationConfig) (auth0.IAuth0ScopeValidator, error)) *IAuth0ValidatorFactory_NewAuth0ScopeValidator_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // NewAuth0TokenValidator provides a mock function for the type IAuth0ValidatorFactory
-func (_mock *IAuth0ValidatorFactory) NewAuth0TokenValidator(config auth00.Config) (auth0.IAuth0TokenValidator, error) {
-	ret := _mock.Called(config)
+func (_mock *IAuth0ValidatorFactory) NewAuth0TokenValidator(config auth00.Config, authorizationConfig subrouter.AuthorizationConfig) (auth0.IAuth0TokenValidator, error) {
+	ret := _mock.Called(config, authorizationConfig)
 
 	if len(ret) == 0 {
 		panic("no return value specified for NewAuth0TokenValidator")
@@ -101,18 +110,18 @@ func (_mock *IAuth0ValidatorFactory) NewAuth0TokenValidator(config auth00.Config
 
 	var r0 auth0.IAuth0TokenValidator
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(auth00.Config) (auth0.IAuth0TokenValidator, error)); ok {
-		return returnFunc(config)
+	if returnFunc, ok := ret.Get(0).(func(auth00.Config, subrouter.AuthorizationConfig) (auth0.IAuth0TokenValidator, error)); ok {
+		return returnFunc(config, authorizationConfig)
 	}
-	if returnFunc, ok := ret.Get(0).(func(auth00.Config) auth0.IAuth0TokenValidator); ok {
-		r0 = returnFunc(config)
+	if returnFunc, ok := ret.Get(0).(func(auth00.Config, subrouter.AuthorizationConfig) auth0.IAuth0TokenValidator); ok {
+		r0 = returnFunc(config, authorizationConfig)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(auth0.IAuth0TokenValidator)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(auth00.Config) error); ok {
-		r1 = returnFunc(config)
+	if returnFunc, ok := ret.Get(1).(func(auth00.Config, subrouter.AuthorizationConfig) error); ok {
+		r1 = returnFunc(config, authorizationConfig)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -126,18 +135,24 @@ type IAuth0ValidatorFactory_NewAuth0TokenValidator_Call struct {
 
 // NewAuth0TokenValidator is a helper method to define mock.On call
 //   - config auth00.Config
-func (_e *IAuth0ValidatorFactory_Expecter) NewAuth0TokenValidator(config interface{}) *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call {
-	return &IAuth0ValidatorFactory_NewAuth0TokenValidator_Call{Call: _e.mock.On("NewAuth0TokenValidator", config)}
+//   - authorizationConfig subrouter.AuthorizationConfig
+func (_e *IAuth0ValidatorFactory_Expecter) NewAuth0TokenValidator(config interface{}, authorizationConfig interface{}) *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call {
+	return &IAuth0ValidatorFactory_NewAuth0TokenValidator_Call{Call: _e.mock.On("NewAuth0TokenValidator", config, authorizationConfig)}
 }
 
-func (_c *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call) Run(run func(config auth00.Config)) *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call {
+func (_c *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call) Run(run func(config auth00.Config, authorizationConfig subrouter.AuthorizationConfig)) *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 auth00.Config
 		if args[0] != nil {
 			arg0 = args[0].(auth00.Config)
 		}
+		var arg1 subrouter.AuthorizationConfig
+		if args[1] != nil {
+			arg1 = args[1].(subrouter.AuthorizationConfig)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -148,7 +163,7 @@ func (_c *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call) Return(iAuth0Token
 	return _c
 }
 
-func (_c *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call) RunAndReturn(run func(config auth00.Config) (auth0.IAuth0TokenValidator, error)) *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call {
+func (_c *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call) RunAndReturn(run func(config auth00.Config, authorizationConfig subrouter.AuthorizationConfig) (auth0.IAuth0TokenValidator, error)) *IAuth0ValidatorFactory_NewAuth0TokenValidator_Call {
 	_c.Call.Return(run)
 	return _c
 }
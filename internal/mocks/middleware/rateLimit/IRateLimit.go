@@ -36,6 +36,52 @@ func (_m *IRateLimit) EXPECT() *IRateLimit_Expecter {
 	return &IRateLimit_Expecter{mock: &_m.Mock}
 }
 
+// AnonymousHandler provides a mock function for the type IRateLimit
+func (_mock *IRateLimit) AnonymousHandler() mux.MiddlewareFunc {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for AnonymousHandler")
+	}
+
+	var r0 mux.MiddlewareFunc
+	if returnFunc, ok := ret.Get(0).(func() mux.MiddlewareFunc); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mux.MiddlewareFunc)
+		}
+	}
+	return r0
+}
+
+// IRateLimit_AnonymousHandler_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AnonymousHandler'
+type IRateLimit_AnonymousHandler_Call struct {
+	*mock.Call
+}
+
+// AnonymousHandler is a helper method to define mock.On call
+func (_e *IRateLimit_Expecter) AnonymousHandler() *IRateLimit_AnonymousHandler_Call {
+	return &IRateLimit_AnonymousHandler_Call{Call: _e.mock.On("AnonymousHandler")}
+}
+
+func (_c *IRateLimit_AnonymousHandler_Call) Run(run func()) *IRateLimit_AnonymousHandler_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *IRateLimit_AnonymousHandler_Call) Return(middlewareFunc mux.MiddlewareFunc) *IRateLimit_AnonymousHandler_Call {
+	_c.Call.Return(middlewareFunc)
+	return _c
+}
+
+func (_c *IRateLimit_AnonymousHandler_Call) RunAndReturn(run func() mux.MiddlewareFunc) *IRateLimit_AnonymousHandler_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Handler provides a mock function for the type IRateLimit
 func (_mock *IRateLimit) Handler() mux.MiddlewareFunc {
 	ret := _mock.Called()
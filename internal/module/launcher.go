@@ -21,7 +21,14 @@ func Launcher(params LauncherParams) {
 		OnStart: func(context.Context) error {
 			params.Logger.Print("Starting Auth0 API Gateway")
 			go func() {
-				err := params.Server.ListenAndServe()
+				var err error
+
+				if params.Server.TLSConfig != nil {
+					err = params.Server.ListenAndServeTLS("", "")
+				} else {
+					err = params.Server.ListenAndServe()
+				}
+
 				if err != nil {
 					params.Logger.Fatal().Err(err).Msg("Failed to start server")
 				}
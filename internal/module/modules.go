@@ -4,11 +4,21 @@ import (
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
 	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	apiKey_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/apiKey"
 	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	bff_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/bff"
+	claimHeaders_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/claimHeaders"
+	clientCert_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/clientCert"
 	cors_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/cors"
+	extAuthz_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/extAuthz"
+	ipFilter_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/ipFilter"
+	policy_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/policy"
 	rateLimit_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/rateLimit"
 	requestLogger_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/requestLogger"
+	revocation_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/revocation"
+	webhookSignature_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/webhookSignature"
 	"github.com/greencoda/auth0-api-gateway/internal/server"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
 	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
 	logging_util "github.com/greencoda/auth0-api-gateway/internal/util/logging"
 	"go.uber.org/fx"
@@ -28,9 +38,19 @@ var logicModule = fx.Module(
 	"logic",
 	fx.Provide(
 		requestLogger_middleware.NewMiddleware,
+		revocation_middleware.NewMiddleware,
+		bff_middleware.NewMiddleware,
+		apiKey_middleware.NewAPIKeyFactory,
 		auth0_middleware.NewAuth0ValidatorFactory,
+		claimHeaders_middleware.NewClaimHeadersFactory,
+		clientCert_middleware.NewClientCertFactory,
 		cors_middleware.NewCORSFactory,
+		extAuthz_middleware.NewExtAuthzFactory,
+		ipFilter_middleware.NewIPFilterFactory,
+		policy_middleware.NewPolicyFactory,
 		rateLimit_middleware.NewRateLimitFactory,
+		webhookSignature_middleware.NewWebhookSignatureFactory,
+		clientIP_util.NewResolver,
 		server.NewReverseProxyHandler,
 		server.NewServer,
 	),
@@ -5,22 +5,38 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
 	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	apiKey_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/apiKey"
 	auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/auth0"
+	bff_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/bff"
+	claimHeaders_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/claimHeaders"
+	clientCert_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/clientCert"
 	cors_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/cors"
+	extAuthz_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/extAuthz"
+	ipFilter_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/ipFilter"
+	policy_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/policy"
 	rateLimit_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/rateLimit"
 	requestLogger_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/requestLogger"
+	revocation_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/revocation"
+	webhookSignature_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/webhookSignature"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
 	reverseProxy_util "github.com/greencoda/auth0-api-gateway/internal/util/reverseProxy"
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 )
 
-var ErrFailedToCreateReverseProxyHandler = errors.New("failed to create reverse proxy handler")
+var (
+	ErrUnknownCredentials        = errors.New("unknown credential type")
+	ErrMissingAPIKeyConfig       = errors.New("API key credentials require an apiKey config")
+	ErrClientCertWithoutClientCA = errors.New("client certificates require TLS with a client CA bundle on the server")
+	ErrBFFWithoutConfig          = errors.New("subrouters logging users in require a bff config")
+)
 
 type IReverseProxyHandler http.Handler
 
@@ -31,25 +47,49 @@ type ReverseProxyHandlerParams struct {
 	ServerConfig     *server_config.Config
 	SubrouterConfigs *subrouter_config.Config
 
-	Auth0MiddlewareFactory     auth0_middleware.IAuth0ValidatorFactory
-	CORSMiddlewareFactory      cors_middleware.ICORSFactory
-	RateLimitMiddlewareFactory rateLimit_middleware.IRateLimitFactory
-	RequestLoggerMiddleware    requestLogger_middleware.IRequestLogger
+	APIKeyMiddlewareFactory           apiKey_middleware.IAPIKeyFactory
+	Auth0MiddlewareFactory            auth0_middleware.IAuth0ValidatorFactory
+	BFFMiddleware                     bff_middleware.IBFF
+	ClaimHeadersMiddlewareFactory     claimHeaders_middleware.IClaimHeadersFactory
+	ClientCertMiddlewareFactory       clientCert_middleware.IClientCertFactory
+	CORSMiddlewareFactory             cors_middleware.ICORSFactory
+	ExtAuthzMiddlewareFactory         extAuthz_middleware.IExtAuthzFactory
+	IPFilterMiddlewareFactory         ipFilter_middleware.IIPFilterFactory
+	PolicyMiddlewareFactory           policy_middleware.IPolicyFactory
+	RateLimitMiddlewareFactory        rateLimit_middleware.IRateLimitFactory
+	RequestLoggerMiddleware           requestLogger_middleware.IRequestLogger
+	RevocationMiddleware              revocation_middleware.IRevocation
+	WebhookSignatureMiddlewareFactory webhookSignature_middleware.IWebhookSignatureFactory
 
 	Logger zerolog.Logger
 }
 
 func NewReverseProxyHandler(params ReverseProxyHandlerParams) (IReverseProxyHandler, error) {
 	router := mux.NewRouter()
+	router.Use(errorResponse_util.WithVerboseReasons(hasVerboseErrors(params.ServerConfig)))
 
 	if params.ServerConfig.LogRequests {
 		router.Use(params.RequestLoggerMiddleware.Handler)
 		params.Logger.Info().Msg("Request logging enabled")
 	}
 
-	auth0TokenValidatorMiddleware, err := params.Auth0MiddlewareFactory.NewAuth0TokenValidator(*params.Auth0Config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to set up Auth0 token validator middleware: %w", err)
+	if params.ServerConfig.StatsPath != "" {
+		router.Handle(params.ServerConfig.StatsPath, auth0_middleware.ClaimsCacheStatsHandler()).Methods(http.MethodGet)
+		params.Logger.Info().Msgf("Stats endpoint set up at %s", params.ServerConfig.StatsPath)
+	}
+
+	revocationConfig := params.Auth0Config.Revocation
+	if revocationConfig != nil && revocationConfig.AdminPath != "" {
+		router.Handle(revocationConfig.AdminPath, params.RevocationMiddleware.AdminHandler())
+		params.Logger.Info().Msgf("Token revocation admin endpoint set up at %s", revocationConfig.AdminPath)
+	}
+
+	bffConfig := params.Auth0Config.BFF
+	if bffConfig != nil {
+		router.Handle(bffConfig.LoginPath, params.BFFMiddleware.LoginHandler()).Methods(http.MethodGet)
+		router.Handle(bffConfig.CallbackPath, params.BFFMiddleware.CallbackHandler()).Methods(http.MethodGet)
+		router.Handle(bffConfig.LogoutPath, params.BFFMiddleware.LogoutHandler()).Methods(http.MethodGet, http.MethodPost)
+		params.Logger.Info().Msgf("Backend for frontends set up with login at %s", bffConfig.LoginPath)
 	}
 
 	for _, subrouterConfig := range *params.SubrouterConfigs {
@@ -60,8 +100,18 @@ func NewReverseProxyHandler(params ReverseProxyHandlerParams) (IReverseProxyHand
 			return nil, fmt.Errorf("failed to parse target API URL '%s' of subrouter '%s': %w", subrouterConfig.TargetURL, subrouterConfig.Name, err)
 		}
 
+		if subrouterConfig.IPFilterConfig != nil {
+			ipFilterMiddleware, err := params.IPFilterMiddlewareFactory.NewIPFilter(*subrouterConfig.IPFilterConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up IP filter of subrouter '%s': %w", subrouterConfig.Name, err)
+			}
+
+			subRouter.Use(ipFilterMiddleware.Handler())
+		}
+
+		var rateLimiterMiddleware rateLimit_middleware.IRateLimit
 		if subrouterConfig.RateLimitConfig != nil {
-			rateLimiterMiddleware := params.RateLimitMiddlewareFactory.NewRateLimit(*subrouterConfig.RateLimitConfig)
+			rateLimiterMiddleware = params.RateLimitMiddlewareFactory.NewRateLimit(*subrouterConfig.RateLimitConfig)
 			subRouter.Use(rateLimiterMiddleware.Handler())
 		}
 
@@ -70,19 +120,86 @@ func NewReverseProxyHandler(params ReverseProxyHandlerParams) (IReverseProxyHand
 			subRouter.Use(corsMiddleware.Handler())
 		}
 
+		if subrouterConfig.ClientCertConfig != nil {
+			if params.ServerConfig.TLS == nil || params.ServerConfig.TLS.ClientCAFile == "" {
+				return nil, fmt.Errorf("%w: subrouter '%s'", ErrClientCertWithoutClientCA, subrouterConfig.Name)
+			}
+
+			clientCertMiddleware := params.ClientCertMiddlewareFactory.NewClientCert(*subrouterConfig.ClientCertConfig)
+			subRouter.Use(clientCertMiddleware.Handler())
+		}
+
+		if subrouterConfig.WebhookSignatureConfig != nil {
+			webhookSignatureMiddleware, err := params.WebhookSignatureMiddlewareFactory.NewWebhookSignature(*subrouterConfig.WebhookSignatureConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up webhook signature verification of subrouter '%s': %w", subrouterConfig.Name, err)
+			}
+
+			subRouter.Use(webhookSignatureMiddleware.Handler())
+		}
+
+		if subrouterConfig.BFF {
+			if bffConfig == nil {
+				return nil, fmt.Errorf("%w: subrouter '%s'", ErrBFFWithoutConfig, subrouterConfig.Name)
+			}
+
+			subRouter.Use(params.BFFMiddleware.Handler)
+		}
+
 		if subrouterConfig.AuthorizationConfig != nil {
-			subRouter.Use(auth0TokenValidatorMiddleware.Handler())
+			authenticationMiddleware, err := newAuthenticationMiddleware(params, subrouterConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			subRouter.Use(authenticationMiddleware)
+
+			if revocationConfig != nil {
+				subRouter.Use(params.RevocationMiddleware.Handler)
+			}
 
-			if len(subrouterConfig.AuthorizationConfig.RequiredScopes) > 0 {
-				auth0ScopeValidatorMiddleware := params.Auth0MiddlewareFactory.NewAuth0ScopeValidator(*subrouterConfig.AuthorizationConfig)
-				if auth0ScopeValidatorMiddleware == nil {
-					return nil, ErrFailedToCreateReverseProxyHandler
+			if len(subrouterConfig.AuthorizationConfig.RequiredScopes) > 0 ||
+				subrouterConfig.AuthorizationConfig.ScopeExpression != nil ||
+				len(subrouterConfig.AuthorizationConfig.Rules) > 0 ||
+				len(subrouterConfig.AuthorizationConfig.RequiredPermissions) > 0 ||
+				len(subrouterConfig.AuthorizationConfig.ClaimRules) > 0 ||
+				subrouterConfig.AuthorizationConfig.Organization != nil {
+				auth0ScopeValidatorMiddleware, err := params.Auth0MiddlewareFactory.NewAuth0ScopeValidator(*subrouterConfig.AuthorizationConfig)
+				if err != nil {
+					return nil, fmt.Errorf("failed to set up Auth0 scope validator middleware of subrouter '%s': %w", subrouterConfig.Name, err)
 				}
 
 				subRouter.Use(auth0ScopeValidatorMiddleware.Handler())
 			}
 		}
 
+		if rateLimiterMiddleware != nil && subrouterConfig.RateLimitConfig.AnonymousLimit > 0 {
+			subRouter.Use(rateLimiterMiddleware.AnonymousHandler())
+		}
+
+		if subrouterConfig.PolicyConfig != nil {
+			policyMiddleware, err := params.PolicyMiddlewareFactory.NewPolicy(*subrouterConfig.PolicyConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up policy of subrouter '%s': %w", subrouterConfig.Name, err)
+			}
+
+			subRouter.Use(policyMiddleware.Handler())
+		}
+
+		if subrouterConfig.ExtAuthzConfig != nil {
+			extAuthzMiddleware, err := params.ExtAuthzMiddlewareFactory.NewExtAuthz(*subrouterConfig.ExtAuthzConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up external authorization of subrouter '%s': %w", subrouterConfig.Name, err)
+			}
+
+			subRouter.Use(extAuthzMiddleware.Handler())
+		}
+
+		if subrouterConfig.ClaimHeadersConfig != nil {
+			claimHeadersMiddleware := params.ClaimHeadersMiddlewareFactory.NewClaimHeaders(*subrouterConfig.ClaimHeadersConfig)
+			subRouter.Use(claimHeadersMiddleware.Handler())
+		}
+
 		if subrouterConfig.GZip {
 			subRouter.Use(handlers.CompressHandler)
 		}
@@ -103,3 +220,99 @@ func NewReverseProxyHandler(params ReverseProxyHandlerParams) (IReverseProxyHand
 
 	return router, nil
 }
+
+// newAuthenticationMiddleware sets up the middleware authenticating the requests of a subrouter
+// with the credential types it accepts: JWTs, API keys, or any of the two.
+func newAuthenticationMiddleware(params ReverseProxyHandlerParams, subrouterConfig subrouter_config.SubrouterConfig) (mux.MiddlewareFunc, error) {
+	var (
+		authorizationConfig = *subrouterConfig.AuthorizationConfig
+		apiKeyMiddleware    apiKey_middleware.IAPIKey
+		err                 error
+	)
+
+	switch authorizationConfig.Credentials {
+	case "", subrouter_config.CredentialsJWT:
+	case subrouter_config.CredentialsAPIKey, subrouter_config.CredentialsAny:
+		if authorizationConfig.APIKey == nil {
+			return nil, fmt.Errorf("%w: subrouter '%s'", ErrMissingAPIKeyConfig, subrouterConfig.Name)
+		}
+
+		apiKeyMiddleware, err = params.APIKeyMiddlewareFactory.NewAPIKey(*authorizationConfig.APIKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up API key middleware of subrouter '%s': %w", subrouterConfig.Name, err)
+		}
+
+		if authorizationConfig.Credentials == subrouter_config.CredentialsAPIKey && authorizationConfig.CredentialsOptional {
+			return optionalAPIKeyMiddleware(apiKeyMiddleware), nil
+		}
+
+		if authorizationConfig.Credentials == subrouter_config.CredentialsAPIKey {
+			return apiKeyMiddleware.Handler(), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w '%s' of subrouter '%s'", ErrUnknownCredentials, authorizationConfig.Credentials, subrouterConfig.Name)
+	}
+
+	auth0TokenValidatorMiddleware, err := params.Auth0MiddlewareFactory.NewAuth0TokenValidator(*params.Auth0Config, authorizationConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Auth0 token validator middleware of subrouter '%s': %w", subrouterConfig.Name, err)
+	}
+
+	if apiKeyMiddleware == nil {
+		return auth0TokenValidatorMiddleware.Handler(), nil
+	}
+
+	var tokenQueryParameters []string
+
+	for _, tokenSourceConfig := range authorizationConfig.TokenSources {
+		if tokenSourceConfig.Type == subrouter_config.TokenSourceQuery {
+			tokenQueryParameters = append(tokenQueryParameters, tokenSourceConfig.Name)
+		}
+	}
+
+	return apiKeyOrJWTMiddleware(apiKeyMiddleware, auth0TokenValidatorMiddleware.Handler(), tokenQueryParameters), nil
+}
+
+// apiKeyOrJWTMiddleware authenticates requests carrying an API key by the key, and all other requests by their JWT.
+// The query parameters carrying tokens are removed from requests authenticated by their key as well.
+func apiKeyOrJWTMiddleware(apiKeyMiddleware apiKey_middleware.IAPIKey, jwtMiddlewareFunc mux.MiddlewareFunc, tokenQueryParameters []string) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		var (
+			apiKeyHandler = apiKeyMiddleware.Handler()(handler)
+			jwtHandler    = jwtMiddlewareFunc(handler)
+		)
+
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			if apiKeyMiddleware.HasAPIKey(req) {
+				apiKeyHandler.ServeHTTP(responseWriter, auth0_middleware.ScrubQueryParameters(req, tokenQueryParameters))
+
+				return
+			}
+
+			jwtHandler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// optionalAPIKeyMiddleware authenticates requests carrying an API key by the key, and lets all other requests
+// through anonymously.
+func optionalAPIKeyMiddleware(apiKeyMiddleware apiKey_middleware.IAPIKey) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		apiKeyHandler := apiKeyMiddleware.Handler()(handler)
+
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			if apiKeyMiddleware.HasAPIKey(req) {
+				apiKeyHandler.ServeHTTP(responseWriter, req)
+
+				return
+			}
+
+			handler.ServeHTTP(responseWriter, req)
+		})
+	}
+}
+
+// hasVerboseErrors tells whether the error responses of the release stage explain why requests were rejected.
+func hasVerboseErrors(serverConfig *server_config.Config) bool {
+	return slices.Contains(serverConfig.VerboseErrorStages, serverConfig.ReleaseStage)
+}
@@ -3,6 +3,7 @@ package server_test
 import (
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -10,13 +11,24 @@ import (
 	auth0_config "github.com/greencoda/auth0-api-gateway/internal/config/auth0"
 	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
 	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	mock_apiKey_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/apiKey"
 	mock_auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/auth0"
+	mock_bff_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/bff"
+	mock_clientCert_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/clientCert"
 	mock_cors_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/cors"
+	mock_extAuthz_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/extAuthz"
+	mock_ipFilter_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/ipFilter"
+	mock_policy_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/policy"
 	mock_rateLimit_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/rateLimit"
 	mock_requestLogger_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/requestLogger"
+	mock_revocation_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/revocation"
+	mock_webhookSignature_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/webhookSignature"
 	"github.com/greencoda/auth0-api-gateway/internal/server"
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
 	"github.com/rs/zerolog"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
@@ -31,8 +43,10 @@ var (
 		LogLevel:       "info",
 	}
 	validAuth0Config = auth0_config.Config{
-		Audience: "",
-		Domain:   "",
+		IssuerConfig: auth0_config.IssuerConfig{
+			Audience: "",
+			Domain:   "",
+		},
 	}
 	validSubrouterConfigs = subrouter_config.Config{
 		{
@@ -56,6 +70,11 @@ var (
 				AllowCredentials: true,
 				MaxAge:           86400,
 			},
+			ClaimHeadersConfig: &subrouter_config.ClaimHeadersConfig{
+				Headers: []subrouter_config.ClaimHeaderConfig{
+					{Name: "X-User-Id", Claim: "sub"},
+				},
+			},
 		},
 	}
 
@@ -79,23 +98,50 @@ var (
 	errTest = errors.New("test error")
 )
 
+func respondingMiddlewareFunc(body string) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			_, _ = responseWriter.Write([]byte(body))
+		})
+	}
+}
+
 func Test_NewReverseProxyHandler(t *testing.T) {
 	Convey("When creating a new server", t, func() {
 		var (
 			testLogger = zerolog.New(zerolog.NewConsoleWriter())
 
-			mockAuth0ValidatorFactory mock_auth0_middleware.IAuth0ValidatorFactory
-			mockAuth0TokenValidator   mock_auth0_middleware.IAuth0TokenValidator
-			mockCORSFactory           mock_cors_middleware.ICORSFactory
-			mockICORS                 mock_cors_middleware.ICORS
-			mockRateLimitFactory      mock_rateLimit_middleware.IRateLimitFactory
-			mockRateLimit             mock_rateLimit_middleware.IRateLimit
-			mockRequestLogger         mock_requestLogger_middleware.IRequestLogger
+			mockAPIKeyFactory           mock_apiKey_middleware.IAPIKeyFactory
+			mockAPIKey                  mock_apiKey_middleware.IAPIKey
+			mockAuth0ValidatorFactory   mock_auth0_middleware.IAuth0ValidatorFactory
+			mockAuth0TokenValidator     mock_auth0_middleware.IAuth0TokenValidator
+			mockBFF                     mock_bff_middleware.IBFF
+			mockClientCertFactory       mock_clientCert_middleware.IClientCertFactory
+			mockClientCert              mock_clientCert_middleware.IClientCert
+			mockCORSFactory             mock_cors_middleware.ICORSFactory
+			mockICORS                   mock_cors_middleware.ICORS
+			mockExtAuthzFactory         mock_extAuthz_middleware.IExtAuthzFactory
+			mockExtAuthz                mock_extAuthz_middleware.IExtAuthz
+			mockIPFilterFactory         mock_ipFilter_middleware.IIPFilterFactory
+			mockIPFilter                mock_ipFilter_middleware.IIPFilter
+			mockPolicyFactory           mock_policy_middleware.IPolicyFactory
+			mockPolicy                  mock_policy_middleware.IPolicy
+			mockRateLimitFactory        mock_rateLimit_middleware.IRateLimitFactory
+			mockRateLimit               mock_rateLimit_middleware.IRateLimit
+			mockRequestLogger           mock_requestLogger_middleware.IRequestLogger
+			mockRevocation              mock_revocation_middleware.IRevocation
+			mockWebhookSignatureFactory mock_webhookSignature_middleware.IWebhookSignatureFactory
+			mockWebhookSignature        mock_webhookSignature_middleware.IWebhookSignature
 		)
 
 		Convey("With fully valid config", func() {
 			Convey("When Auth0 Token validator cannot be set up", func() {
-				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config).Return(nil, errTest)
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *validSubrouterConfigs[0].AuthorizationConfig).Return(nil, errTest)
+				mockRateLimitFactory.On("NewRateLimit", *(validSubrouterConfigs[0].RateLimitConfig)).Return(&mockRateLimit, nil)
+				mockCORSFactory.On("NewCORS", *validSubrouterConfigs[0].CORSConfig).Return(&mockICORS, nil)
+
+				mockRateLimit.On("Handler").Return(noopMiddlewareFunc)
+				mockICORS.On("Handler").Return(noopMiddlewareFunc)
 
 				reverseProxyHandler, err := server.NewReverseProxyHandler(
 					server.ReverseProxyHandlerParams{
@@ -110,12 +156,12 @@ func Test_NewReverseProxyHandler(t *testing.T) {
 					},
 				)
 				So(reverseProxyHandler, ShouldBeNil)
-				So(err, ShouldNotBeNil)
+				So(err, ShouldWrap, errTest)
 			})
 
 			Convey("When Auth0 Scope validator cannot be set up", func() {
-				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config).Return(&mockAuth0TokenValidator, nil)
-				mockAuth0ValidatorFactory.On("NewAuth0ScopeValidator", *validSubrouterConfigs[0].AuthorizationConfig).Return(nil)
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *validSubrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+				mockAuth0ValidatorFactory.On("NewAuth0ScopeValidator", *validSubrouterConfigs[0].AuthorizationConfig).Return(nil, errTest)
 				mockRateLimitFactory.On("NewRateLimit", *(validSubrouterConfigs[0].RateLimitConfig)).Return(&mockRateLimit, nil)
 				mockCORSFactory.On("NewCORS", *validSubrouterConfigs[0].CORSConfig).Return(&mockICORS, nil)
 
@@ -140,9 +186,621 @@ func Test_NewReverseProxyHandler(t *testing.T) {
 			})
 		})
 
-		Convey("With invalid target URL in config", func() {
-			mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config).Return(&mockAuth0TokenValidator, nil)
+		Convey("With API key credentials", func() {
+			var (
+				serverConfig     = server_config.Config{LogRequests: false}
+				apiKeyConfig     = subrouter_config.APIKeyConfig{Header: "X-API-Key", KeysFile: "api_keys.yaml"}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:      "Test API",
+						TargetURL: "http://localhost:8088",
+						Prefix:    "/protected",
+						AuthorizationConfig: &subrouter_config.AuthorizationConfig{
+							Credentials: subrouter_config.CredentialsAny,
+							APIKey:      &apiKeyConfig,
+						},
+					},
+				}
+				params = server.ReverseProxyHandlerParams{
+					Auth0Config:             &validAuth0Config,
+					ServerConfig:            &serverConfig,
+					SubrouterConfigs:        &subrouterConfigs,
+					APIKeyMiddlewareFactory: &mockAPIKeyFactory,
+					Auth0MiddlewareFactory:  &mockAuth0ValidatorFactory,
+					RequestLoggerMiddleware: &mockRequestLogger,
+					Logger:                  testLogger,
+				}
+			)
+
+			Convey("Should authenticate by API key or JWT when accepting any", func() {
+				mockAPIKeyFactory.On("NewAPIKey", apiKeyConfig).Return(&mockAPIKey, nil)
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *subrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+
+				mockAPIKey.On("Handler").Return(respondingMiddlewareFunc("api key"))
+				mockAPIKey.On("HasAPIKey", mock.Anything).Return(func(req *http.Request) bool {
+					return req.Header.Get("X-API-Key") != ""
+				})
+				mockAuth0TokenValidator.On("Handler").Return(respondingMiddlewareFunc("jwt"))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(err, ShouldBeNil)
+
+				apiKeyReq := httptest.NewRequest("GET", "/protected/test", nil)
+				apiKeyReq.Header.Set("X-API-Key", "key")
+
+				apiKeyRecorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(apiKeyRecorder, apiKeyReq)
+				So(apiKeyRecorder.Body.String(), ShouldEqual, "api key")
+
+				jwtRecorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(jwtRecorder, httptest.NewRequest("GET", "/protected/test", nil))
+				So(jwtRecorder.Body.String(), ShouldEqual, "jwt")
+			})
+
+			Convey("Should only set up the API key middleware when accepting API keys", func() {
+				subrouterConfigs[0].AuthorizationConfig.Credentials = subrouter_config.CredentialsAPIKey
+
+				mockAPIKeyFactory.On("NewAPIKey", apiKeyConfig).Return(&mockAPIKey, nil)
+				mockAPIKey.On("Handler").Return(respondingMiddlewareFunc("api key"))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(err, ShouldBeNil)
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", "/protected/test", nil))
+				So(recorder.Body.String(), ShouldEqual, "api key")
+				mockAuth0ValidatorFactory.AssertNotCalled(t, "NewAuth0TokenValidator", mock.Anything, mock.Anything)
+			})
+
+			Convey("Should pass requests without an API key through when credentials are optional", func() {
+				upstream := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+					_, _ = responseWriter.Write([]byte("upstream"))
+				}))
+				defer upstream.Close()
+
+				subrouterConfigs[0].TargetURL = upstream.URL
+				subrouterConfigs[0].AuthorizationConfig.Credentials = subrouter_config.CredentialsAPIKey
+				subrouterConfigs[0].AuthorizationConfig.CredentialsOptional = true
+
+				mockAPIKeyFactory.On("NewAPIKey", apiKeyConfig).Return(&mockAPIKey, nil)
+				mockAPIKey.On("Handler").Return(respondingMiddlewareFunc("api key"))
+				mockAPIKey.On("HasAPIKey", mock.Anything).Return(func(req *http.Request) bool {
+					return req.Header.Get("X-API-Key") != ""
+				})
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(err, ShouldBeNil)
+
+				apiKeyReq := httptest.NewRequest("GET", "/protected/test", nil)
+				apiKeyReq.Header.Set("X-API-Key", "key")
+
+				apiKeyRecorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(apiKeyRecorder, apiKeyReq)
+				So(apiKeyRecorder.Body.String(), ShouldEqual, "api key")
+
+				anonymousRecorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(anonymousRecorder, httptest.NewRequest("GET", "/protected/test", nil))
+				So(anonymousRecorder.Body.String(), ShouldEqual, "upstream")
+				mockAuth0ValidatorFactory.AssertNotCalled(t, "NewAuth0TokenValidator", mock.Anything, mock.Anything)
+			})
+
+			Convey("Should remove token query parameters from requests authenticated by API key", func() {
+				upstream := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+					_, _ = responseWriter.Write([]byte(req.URL.RawQuery))
+				}))
+				defer upstream.Close()
+
+				subrouterConfigs[0].TargetURL = upstream.URL
+				subrouterConfigs[0].AuthorizationConfig.TokenSources = config_util.List[subrouter_config.TokenSourceConfig]{
+					{Type: subrouter_config.TokenSourceQuery, Name: "access_token"},
+				}
+
+				mockAPIKeyFactory.On("NewAPIKey", apiKeyConfig).Return(&mockAPIKey, nil)
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *subrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+
+				mockAPIKey.On("Handler").Return(mux.MiddlewareFunc(func(h http.Handler) http.Handler { return h }))
+				mockAPIKey.On("HasAPIKey", mock.Anything).Return(true)
+				mockAuth0TokenValidator.On("Handler").Return(respondingMiddlewareFunc("jwt"))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(err, ShouldBeNil)
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", "/protected/test?access_token=token&page=2", nil))
+				So(recorder.Body.String(), ShouldEqual, "page=2")
+			})
+
+			Convey("When the API key middleware cannot be set up", func() {
+				mockAPIKeyFactory.On("NewAPIKey", apiKeyConfig).Return(nil, errTest)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, errTest)
+			})
+
+			Convey("Without an API key config", func() {
+				subrouterConfigs[0].AuthorizationConfig.APIKey = nil
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, server.ErrMissingAPIKeyConfig)
+			})
+
+			Convey("With an unknown credential type", func() {
+				subrouterConfigs[0].AuthorizationConfig.Credentials = "password"
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, server.ErrUnknownCredentials)
+			})
+		})
+
+		Convey("With client certificates required by a subrouter", func() {
+			var (
+				clientCertConfig = subrouter_config.ClientCertConfig{AllowedCommonNames: []string{"billing-service"}}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:             "Test API",
+						TargetURL:        "http://localhost:8088",
+						Prefix:           "/protected",
+						ClientCertConfig: &clientCertConfig,
+					},
+				}
+				params = server.ReverseProxyHandlerParams{
+					Auth0Config:                 &validAuth0Config,
+					ServerConfig:                &serverConfig,
+					SubrouterConfigs:            &subrouterConfigs,
+					ClientCertMiddlewareFactory: &mockClientCertFactory,
+					RequestLoggerMiddleware:     &mockRequestLogger,
+					Logger:                      testLogger,
+				}
+			)
+
+			Convey("Should set up the client certificate middleware", func() {
+				serverConfig.TLS = &server_config.TLSConfig{ClientCAFile: "clients-ca.pem"}
+
+				mockClientCertFactory.On("NewClientCert", clientCertConfig).Return(&mockClientCert)
+				mockClientCert.On("Handler").Return(noopMiddlewareFunc)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldNotBeNil)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Should fail without a client CA bundle on the server", func() {
+				serverConfig.TLS = &server_config.TLSConfig{}
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, server.ErrClientCertWithoutClientCA)
+			})
+
+			Convey("Should fail without TLS on the server", func() {
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, server.ErrClientCertWithoutClientCA)
+			})
+		})
+
+		Convey("With a policy on a subrouter", func() {
+			var (
+				policyConfig = subrouter_config.PolicyConfig{
+					Rules: []subrouter_config.PolicyRuleConfig{
+						{Name: "reads", Condition: `request.method == "GET"`, Effect: subrouter_config.PolicyEffectAllow},
+					},
+					Default: subrouter_config.PolicyEffectDeny,
+				}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:         "Test API",
+						TargetURL:    "http://localhost:8088",
+						Prefix:       "/protected",
+						PolicyConfig: &policyConfig,
+					},
+				}
+				params = server.ReverseProxyHandlerParams{
+					Auth0Config:             &validAuth0Config,
+					ServerConfig:            &serverConfig,
+					SubrouterConfigs:        &subrouterConfigs,
+					PolicyMiddlewareFactory: &mockPolicyFactory,
+					RequestLoggerMiddleware: &mockRequestLogger,
+					Logger:                  testLogger,
+				}
+			)
+
+			Convey("Should set up the policy middleware", func() {
+				mockPolicyFactory.On("NewPolicy", policyConfig).Return(&mockPolicy, nil)
+				mockPolicy.On("Handler").Return(noopMiddlewareFunc)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldNotBeNil)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Should fail when the policy doesn't compile", func() {
+				mockPolicyFactory.On("NewPolicy", policyConfig).Return(nil, errTest)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, errTest)
+			})
+		})
+
+		Convey("With external authorization on a subrouter", func() {
+			var (
+				extAuthzConfig   = subrouter_config.ExtAuthzConfig{URL: "http://authz.local/check"}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:           "Test API",
+						TargetURL:      "http://localhost:8088",
+						Prefix:         "/protected",
+						ExtAuthzConfig: &extAuthzConfig,
+					},
+				}
+				params = server.ReverseProxyHandlerParams{
+					Auth0Config:               &validAuth0Config,
+					ServerConfig:              &serverConfig,
+					SubrouterConfigs:          &subrouterConfigs,
+					ExtAuthzMiddlewareFactory: &mockExtAuthzFactory,
+					RequestLoggerMiddleware:   &mockRequestLogger,
+					Logger:                    testLogger,
+				}
+			)
+
+			Convey("Should set up the external authorization middleware", func() {
+				mockExtAuthzFactory.On("NewExtAuthz", extAuthzConfig).Return(&mockExtAuthz, nil)
+				mockExtAuthz.On("Handler").Return(noopMiddlewareFunc)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldNotBeNil)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Should fail when the external authorization can't be set up", func() {
+				mockExtAuthzFactory.On("NewExtAuthz", extAuthzConfig).Return(nil, errTest)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, errTest)
+			})
+		})
+
+		Convey("With an IP filter on a subrouter", func() {
+			var (
+				ipFilterConfig   = subrouter_config.IPFilterConfig{Allow: []string{"203.0.113.0/24"}}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:                "Admin API",
+						TargetURL:           "http://localhost:8088",
+						Prefix:              "/admin",
+						IPFilterConfig:      &ipFilterConfig,
+						AuthorizationConfig: &subrouter_config.AuthorizationConfig{},
+					},
+				}
+				params = server.ReverseProxyHandlerParams{
+					Auth0Config:               &validAuth0Config,
+					ServerConfig:              &serverConfig,
+					SubrouterConfigs:          &subrouterConfigs,
+					Auth0MiddlewareFactory:    &mockAuth0ValidatorFactory,
+					IPFilterMiddlewareFactory: &mockIPFilterFactory,
+					RequestLoggerMiddleware:   &mockRequestLogger,
+					Logger:                    testLogger,
+				}
+			)
 
+			Convey("Should filter requests before authenticating them", func() {
+				mockIPFilterFactory.On("NewIPFilter", ipFilterConfig).Return(&mockIPFilter, nil)
+				mockIPFilter.On("Handler").Return(respondingMiddlewareFunc("blocked"))
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *subrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+				mockAuth0TokenValidator.On("Handler").Return(respondingMiddlewareFunc("authenticated"))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(err, ShouldBeNil)
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/users", nil))
+				So(recorder.Body.String(), ShouldEqual, "blocked")
+			})
+
+			Convey("Should fail when the IP filter can't be set up", func() {
+				mockIPFilterFactory.On("NewIPFilter", ipFilterConfig).Return(nil, errTest)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, errTest)
+			})
+		})
+
+		Convey("With an anonymous rate limit on a subrouter", func() {
+			var (
+				rateLimitConfig  = subrouter_config.RateLimitConfig{Limit: 5, Period: time.Second, AnonymousLimit: 1}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:                "Catalog API",
+						TargetURL:           "http://localhost:8088",
+						Prefix:              "/catalog",
+						RateLimitConfig:     &rateLimitConfig,
+						AuthorizationConfig: &subrouter_config.AuthorizationConfig{CredentialsOptional: true},
+					},
+				}
+				passThroughMiddlewareFunc mux.MiddlewareFunc = func(h http.Handler) http.Handler {
+					return h
+				}
+			)
+
+			Convey("Should limit anonymous requests after authenticating them", func() {
+				mockRateLimitFactory.On("NewRateLimit", rateLimitConfig).Return(&mockRateLimit)
+				mockRateLimit.On("Handler").Return(passThroughMiddlewareFunc)
+				mockRateLimit.On("AnonymousHandler").Return(respondingMiddlewareFunc("limited"))
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *subrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+				mockAuth0TokenValidator.On("Handler").Return(respondingMiddlewareFunc("authenticated"))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(server.ReverseProxyHandlerParams{
+					Auth0Config:                &validAuth0Config,
+					ServerConfig:               &serverConfig,
+					SubrouterConfigs:           &subrouterConfigs,
+					Auth0MiddlewareFactory:     &mockAuth0ValidatorFactory,
+					RateLimitMiddlewareFactory: &mockRateLimitFactory,
+					RequestLoggerMiddleware:    &mockRequestLogger,
+					Logger:                     testLogger,
+				})
+				So(err, ShouldBeNil)
+				mockRateLimit.AssertCalled(t, "AnonymousHandler")
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", "/catalog/items", nil))
+				So(recorder.Body.String(), ShouldEqual, "authenticated")
+			})
+		})
+
+		Convey("With webhook signatures verified by a subrouter", func() {
+			var (
+				webhookSignatureConfig = subrouter_config.WebhookSignatureConfig{Header: "X-Hub-Signature-256", SecretEnv: "WEBHOOK_SECRET"}
+				serverConfig           = server_config.Config{}
+				subrouterConfigs       = subrouter_config.Config{
+					{
+						Name:                   "Webhooks",
+						TargetURL:              "http://localhost:8088",
+						Prefix:                 "/webhooks",
+						WebhookSignatureConfig: &webhookSignatureConfig,
+					},
+				}
+				params = server.ReverseProxyHandlerParams{
+					Auth0Config:                       &validAuth0Config,
+					ServerConfig:                      &serverConfig,
+					SubrouterConfigs:                  &subrouterConfigs,
+					WebhookSignatureMiddlewareFactory: &mockWebhookSignatureFactory,
+					RequestLoggerMiddleware:           &mockRequestLogger,
+					Logger:                            testLogger,
+				}
+			)
+
+			Convey("Should set up the webhook signature middleware", func() {
+				mockWebhookSignatureFactory.On("NewWebhookSignature", webhookSignatureConfig).Return(&mockWebhookSignature, nil)
+				mockWebhookSignature.On("Handler").Return(respondingMiddlewareFunc("signature verified"))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(err, ShouldBeNil)
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhooks/github", nil))
+				So(recorder.Body.String(), ShouldEqual, "signature verified")
+			})
+
+			Convey("Should fail when the webhook signature verification can't be set up", func() {
+				mockWebhookSignatureFactory.On("NewWebhookSignature", webhookSignatureConfig).Return(nil, errTest)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(params)
+				So(reverseProxyHandler, ShouldBeNil)
+				So(err, ShouldWrap, errTest)
+			})
+		})
+
+		Convey("With token revocation enabled", func() {
+			var (
+				auth0Config = auth0_config.Config{
+					Revocation: &auth0_config.RevocationConfig{
+						File:      "revocations.json",
+						AdminPath: "/admin/revocations",
+					},
+				}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:                "Test API",
+						TargetURL:           "http://localhost:8088",
+						Prefix:              "/protected",
+						AuthorizationConfig: &subrouter_config.AuthorizationConfig{},
+					},
+				}
+			)
+
+			Convey("Should check revocations after authentication and serve the admin endpoint", func() {
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", auth0Config, *subrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+				mockAuth0TokenValidator.On("Handler").Return(mux.MiddlewareFunc(func(h http.Handler) http.Handler { return h }))
+				mockRevocation.On("Handler", mock.Anything).Return(respondingMiddlewareFunc("revocation checked")(nil))
+				mockRevocation.On("AdminHandler").Return(respondingMiddlewareFunc("admin")(nil))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(
+					server.ReverseProxyHandlerParams{
+						Auth0Config:             &auth0Config,
+						ServerConfig:            &serverConfig,
+						SubrouterConfigs:        &subrouterConfigs,
+						Auth0MiddlewareFactory:  &mockAuth0ValidatorFactory,
+						RequestLoggerMiddleware: &mockRequestLogger,
+						RevocationMiddleware:    &mockRevocation,
+						Logger:                  testLogger,
+					},
+				)
+				So(err, ShouldBeNil)
+
+				protectedRecorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(protectedRecorder, httptest.NewRequest("GET", "/protected/test", nil))
+				So(protectedRecorder.Body.String(), ShouldEqual, "revocation checked")
+
+				adminRecorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(adminRecorder, httptest.NewRequest("GET", "/admin/revocations", nil))
+				So(adminRecorder.Body.String(), ShouldEqual, "admin")
+			})
+		})
+
+		Convey("With a backend for frontends", func() {
+			var (
+				auth0Config = auth0_config.Config{
+					BFF: &auth0_config.BFFConfig{
+						LoginPath:    "/login",
+						CallbackPath: "/callback",
+						LogoutPath:   "/logout",
+					},
+				}
+				serverConfig     = server_config.Config{}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:                "Test API",
+						TargetURL:           "http://localhost:8088",
+						Prefix:              "/protected",
+						BFF:                 true,
+						AuthorizationConfig: &subrouter_config.AuthorizationConfig{},
+					},
+				}
+			)
+
+			Convey("Should attach sessions before authentication and serve the login routes", func() {
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", auth0Config, *subrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+				mockAuth0TokenValidator.On("Handler").Return(respondingMiddlewareFunc("authenticated"))
+				mockBFF.On("Handler", mock.Anything).Return(func(h http.Handler) http.Handler { return h })
+				mockBFF.On("LoginHandler").Return(respondingMiddlewareFunc("login")(nil))
+				mockBFF.On("CallbackHandler").Return(respondingMiddlewareFunc("callback")(nil))
+				mockBFF.On("LogoutHandler").Return(respondingMiddlewareFunc("logout")(nil))
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(
+					server.ReverseProxyHandlerParams{
+						Auth0Config:             &auth0Config,
+						ServerConfig:            &serverConfig,
+						SubrouterConfigs:        &subrouterConfigs,
+						Auth0MiddlewareFactory:  &mockAuth0ValidatorFactory,
+						BFFMiddleware:           &mockBFF,
+						RequestLoggerMiddleware: &mockRequestLogger,
+						Logger:                  testLogger,
+					},
+				)
+				So(err, ShouldBeNil)
+
+				for path, body := range map[string]string{
+					"/protected/test": "authenticated",
+					"/login":          "login",
+					"/callback":       "callback",
+					"/logout":         "logout",
+				} {
+					recorder := httptest.NewRecorder()
+					reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+					So(recorder.Body.String(), ShouldEqual, body)
+				}
+			})
+
+			Convey("Should fail for subrouters logging users in without a bff config", func() {
+				_, err := server.NewReverseProxyHandler(
+					server.ReverseProxyHandlerParams{
+						Auth0Config:             &auth0_config.Config{},
+						ServerConfig:            &serverConfig,
+						SubrouterConfigs:        &subrouterConfigs,
+						RequestLoggerMiddleware: &mockRequestLogger,
+						Logger:                  testLogger,
+					},
+				)
+				So(err, ShouldWrap, server.ErrBFFWithoutConfig)
+			})
+		})
+
+		Convey("With a stats path", func() {
+			var (
+				serverConfig     = server_config.Config{StatsPath: "/debug/vars"}
+				subrouterConfigs = subrouter_config.Config{}
+			)
+
+			Convey("Should serve the claims cache stats", func() {
+				reverseProxyHandler, err := server.NewReverseProxyHandler(
+					server.ReverseProxyHandlerParams{
+						Auth0Config:             &validAuth0Config,
+						ServerConfig:            &serverConfig,
+						SubrouterConfigs:        &subrouterConfigs,
+						RequestLoggerMiddleware: &mockRequestLogger,
+						Logger:                  testLogger,
+					},
+				)
+				So(err, ShouldBeNil)
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/vars", nil))
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(recorder.Body.String(), ShouldContainSubstring, `"claimsCache"`)
+				So(recorder.Body.String(), ShouldNotContainSubstring, `"cmdline"`)
+				So(recorder.Body.String(), ShouldNotContainSubstring, `"memstats"`)
+			})
+		})
+
+		Convey("With error responses of a release stage", func() {
+			var (
+				rateLimitConfig  = subrouter_config.RateLimitConfig{Limit: 1, Period: time.Second}
+				subrouterConfigs = subrouter_config.Config{
+					{
+						Name:            "Test API",
+						TargetURL:       "http://localhost:8088",
+						Prefix:          "/protected",
+						RateLimitConfig: &rateLimitConfig,
+					},
+				}
+				rejectingMiddlewareFunc mux.MiddlewareFunc = func(h http.Handler) http.Handler {
+					return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+						errorResponse_util.Write(responseWriter, req, errorResponse_util.Error{
+							StatusCode: http.StatusUnauthorized,
+							Code:       errorResponse_util.CodeInvalidToken,
+							Message:    "Failed to validate JWT.",
+							Reason:     "token is expired",
+						})
+					})
+				}
+			)
+
+			serve := func(serverConfig server_config.Config) string {
+				mockRateLimitFactory.On("NewRateLimit", rateLimitConfig).Return(&mockRateLimit)
+				mockRateLimit.On("Handler").Return(rejectingMiddlewareFunc)
+
+				reverseProxyHandler, err := server.NewReverseProxyHandler(
+					server.ReverseProxyHandlerParams{
+						Auth0Config:                &validAuth0Config,
+						ServerConfig:               &serverConfig,
+						SubrouterConfigs:           &subrouterConfigs,
+						RateLimitMiddlewareFactory: &mockRateLimitFactory,
+						RequestLoggerMiddleware:    &mockRequestLogger,
+						Logger:                     testLogger,
+					},
+				)
+				So(err, ShouldBeNil)
+
+				recorder := httptest.NewRecorder()
+				reverseProxyHandler.ServeHTTP(recorder, httptest.NewRequest("GET", "/protected/test", nil))
+
+				return recorder.Body.String()
+			}
+
+			Convey("Should not explain the errors of any release stage by default", func() {
+				So(serve(server_config.Config{ReleaseStage: "local"}), ShouldNotContainSubstring, "token is expired")
+				So(serve(server_config.Config{ReleaseStage: "production"}), ShouldNotContainSubstring, "token is expired")
+			})
+
+			Convey("Should explain the errors of the listed release stages", func() {
+				So(serve(server_config.Config{ReleaseStage: "staging", VerboseErrorStages: []string{"staging"}}), ShouldContainSubstring, "token is expired")
+				So(serve(server_config.Config{ReleaseStage: "local", VerboseErrorStages: []string{"staging"}}), ShouldNotContainSubstring, "token is expired")
+			})
+		})
+
+		Convey("With invalid target URL in config", func() {
 			reverseProxyHandler, err := server.NewReverseProxyHandler(
 				server.ReverseProxyHandlerParams{
 					Auth0Config:                &validAuth0Config,
@@ -1,14 +1,21 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 )
 
+var ErrInvalidClientCABundle = errors.New("client CA bundle contains no PEM encoded certificate")
+
 type ServerParams struct {
 	fx.In
 
@@ -20,7 +27,7 @@ type ServerParams struct {
 func NewServer(params ServerParams) (*http.Server, error) {
 	stdLogger := log.New(params.Logger, "", 0)
 
-	return &http.Server{
+	server := &http.Server{
 		Handler:        params.ReverseProxyHandler,
 		Addr:           params.ServerConfig.Address,
 		ReadTimeout:    params.ServerConfig.ReadTimeout,
@@ -28,5 +35,50 @@ func NewServer(params ServerParams) (*http.Server, error) {
 		IdleTimeout:    params.ServerConfig.IdleTimeout,
 		MaxHeaderBytes: params.ServerConfig.MaxHeaderBytes,
 		ErrorLog:       stdLogger,
-	}, nil
+	}
+
+	if params.ServerConfig.TLS != nil {
+		tlsConfig, err := newTLSConfig(*params.ServerConfig.TLS)
+		if err != nil {
+			return nil, err
+		}
+
+		server.TLSConfig = tlsConfig
+	}
+
+	return server, nil
+}
+
+// newTLSConfig loads the server certificate and, if configured, the CA bundle client certificates are verified against.
+func newTLSConfig(config server_config.TLSConfig) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	clientCABundle, err := os.ReadFile(config.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA bundle: %w", err)
+	}
+
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(clientCABundle) {
+		return nil, ErrInvalidClientCABundle
+	}
+
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if config.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
 }
@@ -0,0 +1,231 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+	subrouter_config "github.com/greencoda/auth0-api-gateway/internal/config/subrouter"
+	clientCert_middleware "github.com/greencoda/auth0-api-gateway/internal/middleware/clientCert"
+	"github.com/greencoda/auth0-api-gateway/internal/server"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	privateKey  *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+func newTestCertificate(t *testing.T, template *x509.Certificate, issuer *testCertificate) *testCertificate {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, signingKey := template, privateKey
+	if issuer != nil {
+		parent, signingKey = issuer.certificate, issuer.privateKey
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, parent, &privateKey.PublicKey, signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{
+		certificate: certificate,
+		privateKey:  privateKey,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeTestFile(t *testing.T, name string, contents []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func Test_NewServer_TLS(t *testing.T) {
+	Convey("When creating a new server with TLS", t, func() {
+		var (
+			testLogger = zerolog.New(zerolog.NewConsoleWriter())
+			notBefore  = time.Now().Add(-time.Minute)
+			notAfter   = time.Now().Add(time.Hour)
+
+			certificateAuthority = newTestCertificate(t, &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "Test CA"},
+				NotBefore:             notBefore,
+				NotAfter:              notAfter,
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+			}, nil)
+			serverCertificate = newTestCertificate(t, &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      pkix.Name{CommonName: "gateway"},
+				IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+				NotBefore:    notBefore,
+				NotAfter:     notAfter,
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}, certificateAuthority)
+			clientCertificate = newTestCertificate(t, &x509.Certificate{
+				SerialNumber: big.NewInt(3),
+				Subject:      pkix.Name{CommonName: "billing-service"},
+				DNSNames:     []string{"billing.internal"},
+				NotBefore:    notBefore,
+				NotAfter:     notAfter,
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}, certificateAuthority)
+
+			tlsConfig = server_config.TLSConfig{
+				CertFile: writeTestFile(t, "server.crt", serverCertificate.certPEM),
+				KeyFile:  writeTestFile(t, "server.key", serverCertificate.keyPEM),
+			}
+			serverConfig = validServerConfig
+		)
+
+		serverConfig.TLS = &tlsConfig
+
+		newServer := func(handler http.Handler) (*http.Server, error) {
+			return server.NewServer(
+				server.ServerParams{
+					ServerConfig:        &serverConfig,
+					ReverseProxyHandler: handler,
+					Logger:              testLogger,
+				},
+			)
+		}
+
+		Convey("Without a client CA bundle", func() {
+			testServer, err := newServer(http.NotFoundHandler())
+			So(err, ShouldBeNil)
+			So(testServer.TLSConfig, ShouldNotBeNil)
+			So(testServer.TLSConfig.Certificates, ShouldHaveLength, 1)
+			So(testServer.TLSConfig.ClientAuth, ShouldEqual, tls.NoClientCert)
+		})
+
+		Convey("With a client CA bundle", func() {
+			tlsConfig.ClientCAFile = writeTestFile(t, "clients-ca.pem", certificateAuthority.certPEM)
+
+			Convey("Should verify client certificates if given", func() {
+				testServer, err := newServer(http.NotFoundHandler())
+				So(err, ShouldBeNil)
+				So(testServer.TLSConfig.ClientAuth, ShouldEqual, tls.VerifyClientCertIfGiven)
+			})
+
+			Convey("Should require client certificates if configured", func() {
+				tlsConfig.RequireClientCert = true
+
+				testServer, err := newServer(http.NotFoundHandler())
+				So(err, ShouldBeNil)
+				So(testServer.TLSConfig.ClientAuth, ShouldEqual, tls.RequireAndVerifyClientCert)
+			})
+
+			Convey("Should pass verified client certificates to the client certificate middleware", func() {
+				clientCertMiddleware := clientCert_middleware.NewClientCertFactory().NewClientCert(subrouter_config.ClientCertConfig{
+					AllowedCommonNames: []string{"billing-service"},
+					CommonNameHeader:   "X-Client-Cert-CN",
+				})
+
+				handler := clientCertMiddleware.Handler()(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+					_, _ = responseWriter.Write([]byte(req.Header.Get("X-Client-Cert-CN")))
+				}))
+
+				testServer, err := newServer(handler)
+				So(err, ShouldBeNil)
+
+				httpServer := httptest.NewUnstartedServer(testServer.Handler)
+				httpServer.TLS = testServer.TLSConfig
+				httpServer.StartTLS()
+				defer httpServer.Close()
+
+				rootCAs := x509.NewCertPool()
+				rootCAs.AddCert(certificateAuthority.certificate)
+
+				clientKeyPair, err := tls.X509KeyPair(clientCertificate.certPEM, clientCertificate.keyPEM)
+				So(err, ShouldBeNil)
+
+				newClient := func(certificates ...tls.Certificate) *http.Client {
+					return &http.Client{
+						Transport: &http.Transport{
+							TLSClientConfig: &tls.Config{
+								RootCAs:      rootCAs,
+								Certificates: certificates,
+								MinVersion:   tls.VersionTLS12,
+							},
+						},
+					}
+				}
+
+				res, err := newClient(clientKeyPair).Get(httpServer.URL)
+				So(err, ShouldBeNil)
+				defer res.Body.Close()
+
+				body, err := io.ReadAll(res.Body)
+				So(err, ShouldBeNil)
+				So(res.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, "billing-service")
+
+				anonymousRes, err := newClient().Get(httpServer.URL)
+				So(err, ShouldBeNil)
+				defer anonymousRes.Body.Close()
+
+				So(anonymousRes.StatusCode, ShouldEqual, http.StatusUnauthorized)
+			})
+		})
+
+		Convey("With an invalid client CA bundle", func() {
+			tlsConfig.ClientCAFile = writeTestFile(t, "clients-ca.pem", []byte("not a certificate"))
+
+			testServer, err := newServer(http.NotFoundHandler())
+			So(err, ShouldEqual, server.ErrInvalidClientCABundle)
+			So(testServer, ShouldBeNil)
+		})
+
+		Convey("With a missing certificate", func() {
+			tlsConfig.CertFile = filepath.Join(t.TempDir(), "missing.crt")
+
+			testServer, err := newServer(http.NotFoundHandler())
+			So(err, ShouldNotBeNil)
+			So(testServer, ShouldBeNil)
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	mock_auth0_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/auth0"
+	mock_claimHeaders_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/claimHeaders"
 	mock_cors_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/cors"
 	mock_rateLimit_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/rateLimit"
 	mock_requestLogger_middleware "github.com/greencoda/auth0-api-gateway/internal/mocks/middleware/requestLogger"
@@ -21,6 +22,8 @@ func Test_NewServer(t *testing.T) {
 			mockAuth0ValidatorFactory mock_auth0_middleware.IAuth0ValidatorFactory
 			mockAuth0TokenValidator   mock_auth0_middleware.IAuth0TokenValidator
 			mockAuth0ScopeValidator   mock_auth0_middleware.IAuth0ScopeValidator
+			mockClaimHeadersFactory   mock_claimHeaders_middleware.IClaimHeadersFactory
+			mockClaimHeaders          mock_claimHeaders_middleware.IClaimHeaders
 			mockCORSFactory           mock_cors_middleware.ICORSFactory
 			mockICORS                 mock_cors_middleware.ICORS
 			mockRateLimitFactory      mock_rateLimit_middleware.IRateLimitFactory
@@ -30,26 +33,29 @@ func Test_NewServer(t *testing.T) {
 
 		Convey("With fully valid config", func() {
 			Convey("When Auth0 Token and Scope validator can be set up", func() {
-				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config).Return(&mockAuth0TokenValidator, nil)
-				mockAuth0ValidatorFactory.On("NewAuth0ScopeValidator", *validSubrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0ScopeValidator)
+				mockAuth0ValidatorFactory.On("NewAuth0TokenValidator", validAuth0Config, *validSubrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0TokenValidator, nil)
+				mockAuth0ValidatorFactory.On("NewAuth0ScopeValidator", *validSubrouterConfigs[0].AuthorizationConfig).Return(&mockAuth0ScopeValidator, nil)
 				mockRateLimitFactory.On("NewRateLimit", *(validSubrouterConfigs[0].RateLimitConfig)).Return(&mockRateLimit, nil)
 				mockCORSFactory.On("NewCORS", *validSubrouterConfigs[0].CORSConfig).Return(&mockICORS, nil)
+				mockClaimHeadersFactory.On("NewClaimHeaders", *validSubrouterConfigs[0].ClaimHeadersConfig).Return(&mockClaimHeaders)
 
 				mockAuth0TokenValidator.On("Handler").Return(noopMiddlewareFunc)
 				mockAuth0ScopeValidator.On("Handler").Return(noopMiddlewareFunc)
 				mockRateLimit.On("Handler").Return(noopMiddlewareFunc)
 				mockICORS.On("Handler").Return(noopMiddlewareFunc)
+				mockClaimHeaders.On("Handler").Return(noopMiddlewareFunc)
 
 				reverseProxyHandler, err := server.NewReverseProxyHandler(
 					server.ReverseProxyHandlerParams{
-						Auth0Config:                &validAuth0Config,
-						ServerConfig:               &validServerConfig,
-						SubrouterConfigs:           &validSubrouterConfigs,
-						Auth0MiddlewareFactory:     &mockAuth0ValidatorFactory,
-						CORSMiddlewareFactory:      &mockCORSFactory,
-						RateLimitMiddlewareFactory: &mockRateLimitFactory,
-						RequestLoggerMiddleware:    &mockRequestLogger,
-						Logger:                     testLogger,
+						Auth0Config:                   &validAuth0Config,
+						ServerConfig:                  &validServerConfig,
+						SubrouterConfigs:              &validSubrouterConfigs,
+						Auth0MiddlewareFactory:        &mockAuth0ValidatorFactory,
+						ClaimHeadersMiddlewareFactory: &mockClaimHeadersFactory,
+						CORSMiddlewareFactory:         &mockCORSFactory,
+						RateLimitMiddlewareFactory:    &mockRateLimitFactory,
+						RequestLoggerMiddleware:       &mockRequestLogger,
+						Logger:                        testLogger,
 					},
 				)
 				So(reverseProxyHandler, ShouldNotBeNil)
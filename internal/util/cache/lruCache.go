@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a cache holding up to a fixed number of entries, which evicts the least recently used entry to make room
+// for a new one. Every entry also expires at the time it was added with, after which it is no longer returned.
+type LRU[K comparable, V any] struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[K]*list.Element
+	order   *list.List
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewLRU creates a cache holding up to size entries.
+func NewLRU[K comparable, V any](size int) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:    max(size, 1),
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value cached for the key, unless it is missing or has expired.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var value V
+
+	element, isCached := c.entries[key]
+	if !isCached {
+		return value, false
+	}
+
+	entry := element.Value.(*lruEntry[K, V])
+	if !time.Now().Before(entry.expiresAt) {
+		c.removeElement(element)
+
+		return value, false
+	}
+
+	c.order.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// Add caches the value for the key until it expires, replacing any value cached for it before.
+func (c *LRU[K, V]) Add(key K, value V, expiresAt time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, isCached := c.entries[key]; isCached {
+		element.Value = &lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt}
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		c.removeElement(c.order.Back())
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+}
+
+// AddIfAbsent caches the value for the key until it expires, unless a value is already cached for it,
+// and tells whether it was added.
+func (c *LRU[K, V]) AddIfAbsent(key K, value V, expiresAt time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, isCached := c.entries[key]; isCached {
+		if time.Now().Before(element.Value.(*lruEntry[K, V]).expiresAt) {
+			return false
+		}
+
+		c.removeElement(element)
+	}
+
+	if c.order.Len() >= c.size {
+		c.removeElement(c.order.Back())
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	return true
+}
+
+// Len returns the number of cached entries, including the expired ones not evicted yet.
+func (c *LRU[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry[K, V]).key)
+}
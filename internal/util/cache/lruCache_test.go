@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache_util "github.com/greencoda/auth0-api-gateway/internal/util/cache"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_LRU(t *testing.T) {
+	Convey("When using an LRU cache", t, func() {
+		lru := cache_util.NewLRU[string, int](2)
+		expiresAt := time.Now().Add(time.Minute)
+
+		Convey("Should return cached values", func() {
+			lru.Add("a", 1, expiresAt)
+
+			value, isCached := lru.Get("a")
+			So(isCached, ShouldBeTrue)
+			So(value, ShouldEqual, 1)
+
+			_, isCached = lru.Get("b")
+			So(isCached, ShouldBeFalse)
+		})
+
+		Convey("Should replace the value of a cached key", func() {
+			lru.Add("a", 1, expiresAt)
+			lru.Add("a", 2, expiresAt)
+
+			value, _ := lru.Get("a")
+			So(value, ShouldEqual, 2)
+			So(lru.Len(), ShouldEqual, 1)
+		})
+
+		Convey("Should evict the least recently used entry when full", func() {
+			lru.Add("a", 1, expiresAt)
+			lru.Add("b", 2, expiresAt)
+			lru.Get("a")
+			lru.Add("c", 3, expiresAt)
+
+			_, isCached := lru.Get("b")
+			So(isCached, ShouldBeFalse)
+
+			_, isCached = lru.Get("a")
+			So(isCached, ShouldBeTrue)
+
+			_, isCached = lru.Get("c")
+			So(isCached, ShouldBeTrue)
+		})
+
+		Convey("Should only add absent entries", func() {
+			So(lru.AddIfAbsent("a", 1, expiresAt), ShouldBeTrue)
+			So(lru.AddIfAbsent("a", 2, expiresAt), ShouldBeFalse)
+
+			value, _ := lru.Get("a")
+			So(value, ShouldEqual, 1)
+		})
+
+		Convey("Should replace expired entries when adding absent ones", func() {
+			lru.Add("a", 1, time.Now().Add(-time.Second))
+
+			So(lru.AddIfAbsent("a", 2, expiresAt), ShouldBeTrue)
+
+			value, _ := lru.Get("a")
+			So(value, ShouldEqual, 2)
+		})
+
+		Convey("Should not return expired entries", func() {
+			lru.Add("a", 1, time.Now().Add(-time.Second))
+
+			_, isCached := lru.Get("a")
+			So(isCached, ShouldBeFalse)
+			So(lru.Len(), ShouldEqual, 0)
+		})
+	})
+}
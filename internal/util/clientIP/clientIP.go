@@ -0,0 +1,154 @@
+package clientIP
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+)
+
+var (
+	ErrInvalidIPRange = errors.New("invalid IP address or CIDR range")
+	ErrNoTrustedProxy = errors.New("trusting forwarded headers requires the trusted proxies of the server")
+)
+
+// Resolver finds the IP address of the client of a request, the same way for every middleware. Forwarded headers
+// are only trusted from requests sent by one of the trusted proxies of the server.
+type Resolver struct {
+	trustedProxies []netip.Prefix
+}
+
+// NewResolver creates a client IP resolver trusting the proxies of the server config.
+func NewResolver(serverConfig *server_config.Config) (*Resolver, error) {
+	trustedProxies, err := ParseRanges(serverConfig.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the trusted proxies: %w", err)
+	}
+
+	return &Resolver{
+		trustedProxies: trustedProxies,
+	}, nil
+}
+
+// CheckTrustForwardHeader fails if forwarded headers are to be trusted without any trusted proxy, as any client
+// could then claim another address in them.
+func (r *Resolver) CheckTrustForwardHeader(trustForwardHeader bool) error {
+	if trustForwardHeader && len(r.trustedProxies) == 0 {
+		return ErrNoTrustedProxy
+	}
+
+	return nil
+}
+
+// Resolve returns the IP address of the client, read from the forwarded headers with trustForwardHeader.
+func (r *Resolver) Resolve(req *http.Request, trustForwardHeader bool) (netip.Addr, bool) {
+	remoteAddr, isValid := parseRemoteAddr(req.RemoteAddr)
+	if trustForwardHeader && len(r.trustedProxies) == 0 {
+		return resolveFirstForwarded(req, remoteAddr, isValid)
+	}
+
+	if !isValid || !trustForwardHeader || !ContainsAddr(r.trustedProxies, remoteAddr) {
+		return remoteAddr, isValid
+	}
+
+	var forwardedAddrs []string
+	for _, header := range req.Header.Values("X-Forwarded-For") {
+		forwardedAddrs = append(forwardedAddrs, strings.Split(header, ",")...)
+	}
+
+	if len(forwardedAddrs) == 0 {
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); err == nil {
+			return realIP.Unmap(), true
+		}
+
+		return remoteAddr, true
+	}
+
+	// If every address in the chain is a trusted proxy, the first one is the client.
+	var addr netip.Addr
+
+	for _, forwardedAddr := range slices.Backward(forwardedAddrs) {
+		parsedAddr, err := netip.ParseAddr(strings.TrimSpace(forwardedAddr))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+
+		if addr = parsedAddr.Unmap(); !ContainsAddr(r.trustedProxies, addr) {
+			break
+		}
+	}
+
+	return addr, true
+}
+
+// resolveFirstForwarded takes the first address of X-Forwarded-For, or else X-Real-IP, like limiter.GetIP.
+func resolveFirstForwarded(req *http.Request, remoteAddr netip.Addr, isValid bool) (netip.Addr, bool) {
+	for _, header := range req.Header.Values("X-Forwarded-For") {
+		for _, forwardedAddr := range strings.Split(header, ",") {
+			if addr, err := netip.ParseAddr(strings.TrimSpace(forwardedAddr)); err == nil {
+				return addr.Unmap(), true
+			}
+		}
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap(), true
+	}
+
+	return remoteAddr, isValid
+}
+
+// ParseRanges parses CIDR ranges, taking single addresses as ranges of their own.
+func ParseRanges(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w '%s'", ErrInvalidIPRange, value)
+			}
+
+			prefixes = append(prefixes, prefix.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s'", ErrInvalidIPRange, value)
+		}
+
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// ContainsAddr tells whether the address is in any of the ranges.
+func ContainsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(prefixes, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
@@ -0,0 +1,118 @@
+package clientIP_test
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	server_config "github.com/greencoda/auth0-api-gateway/internal/config/server"
+	clientIP_util "github.com/greencoda/auth0-api-gateway/internal/util/clientIP"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Resolver(t *testing.T) {
+	Convey("When resolving the client IPs of requests", t, func() {
+		resolver, err := clientIP_util.NewResolver(&server_config.Config{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+		So(err, ShouldBeNil)
+
+		resolve := func(remoteAddr string, headers map[string]string, trustForwardHeader bool) (string, bool) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = remoteAddr
+
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+
+			addr, isKnown := resolver.Resolve(req, trustForwardHeader)
+			if !isKnown {
+				return "", false
+			}
+
+			return addr.String(), true
+		}
+
+		Convey("Should take the remote address unless forwarded headers are trusted", func() {
+			So(first(resolve("10.0.0.5:51234", map[string]string{"X-Forwarded-For": "203.0.113.10"}, false)), ShouldEqual, "10.0.0.5")
+			So(first(resolve("[::ffff:203.0.113.10]:51234", nil, false)), ShouldEqual, "203.0.113.10")
+			So(first(resolve("203.0.113.10", nil, false)), ShouldEqual, "203.0.113.10")
+		})
+
+		Convey("Should take the last address forwarded by the trusted proxies", func() {
+			So(first(resolve("10.0.0.5:51234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.10, 192.168.1.1, 10.0.0.2"}, true)), ShouldEqual, "203.0.113.10")
+		})
+
+		Convey("Should take the first address when every forwarded address is a trusted proxy", func() {
+			So(first(resolve("10.0.0.5:51234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, true)), ShouldEqual, "10.0.0.3")
+		})
+
+		Convey("Should ignore forwarded headers of requests not sent by a trusted proxy", func() {
+			So(first(resolve("198.51.100.1:51234", map[string]string{"X-Forwarded-For": "203.0.113.10"}, true)), ShouldEqual, "198.51.100.1")
+			So(first(resolve("198.51.100.1:51234", map[string]string{"X-Real-IP": "203.0.113.10"}, true)), ShouldEqual, "198.51.100.1")
+		})
+
+		Convey("Should use X-Real-IP when there is no X-Forwarded-For", func() {
+			So(first(resolve("10.0.0.5:51234", map[string]string{"X-Real-IP": "203.0.113.10"}, true)), ShouldEqual, "203.0.113.10")
+			So(first(resolve("10.0.0.5:51234", nil, true)), ShouldEqual, "10.0.0.5")
+		})
+
+		Convey("Should not know the client of malformed addresses", func() {
+			_, isKnown := resolve("10.0.0.5:51234", map[string]string{"X-Forwarded-For": "203.0.113.10, unknown"}, true)
+			So(isKnown, ShouldBeFalse)
+
+			_, isKnown = resolve("", nil, false)
+			So(isKnown, ShouldBeFalse)
+		})
+
+		Convey("Should only allow trusting forwarded headers with trusted proxies", func() {
+			So(resolver.CheckTrustForwardHeader(true), ShouldBeNil)
+
+			untrustingResolver, err := clientIP_util.NewResolver(&server_config.Config{})
+			So(err, ShouldBeNil)
+			So(untrustingResolver.CheckTrustForwardHeader(false), ShouldBeNil)
+			So(untrustingResolver.CheckTrustForwardHeader(true), ShouldWrap, clientIP_util.ErrNoTrustedProxy)
+		})
+
+		Convey("Without trusted proxies", func() {
+			resolver, err = clientIP_util.NewResolver(&server_config.Config{})
+			So(err, ShouldBeNil)
+
+			Convey("Should take the first forwarded address of any request", func() {
+				So(first(resolve("198.51.100.1:51234", map[string]string{"X-Forwarded-For": "unknown, 203.0.113.10, 10.0.0.2"}, true)), ShouldEqual, "203.0.113.10")
+				So(first(resolve("198.51.100.1:51234", map[string]string{"X-Real-IP": "203.0.113.10"}, true)), ShouldEqual, "203.0.113.10")
+				So(first(resolve("198.51.100.1:51234", nil, true)), ShouldEqual, "198.51.100.1")
+			})
+
+			Convey("Should still take the remote address unless forwarded headers are trusted", func() {
+				So(first(resolve("198.51.100.1:51234", map[string]string{"X-Forwarded-For": "203.0.113.10"}, false)), ShouldEqual, "198.51.100.1")
+			})
+		})
+	})
+}
+
+func Test_ParseRanges(t *testing.T) {
+	Convey("When parsing IP ranges", t, func() {
+		Convey("Should parse CIDR ranges and single addresses", func() {
+			prefixes, err := clientIP_util.ParseRanges([]string{"203.0.113.7/24", " 2001:db8::1 ", "::ffff:198.51.100.7"})
+			So(err, ShouldBeNil)
+			So(prefixes, ShouldResemble, []netip.Prefix{
+				netip.MustParsePrefix("203.0.113.0/24"),
+				netip.MustParsePrefix("2001:db8::1/128"),
+				netip.MustParsePrefix("198.51.100.7/32"),
+			})
+			So(clientIP_util.ContainsAddr(prefixes, netip.MustParseAddr("203.0.113.66")), ShouldBeTrue)
+			So(clientIP_util.ContainsAddr(prefixes, netip.MustParseAddr("198.51.100.8")), ShouldBeFalse)
+		})
+
+		Convey("Should fail for invalid ranges", func() {
+			_, err := clientIP_util.ParseRanges([]string{"203.0.113.0/33"})
+			So(err, ShouldWrap, clientIP_util.ErrInvalidIPRange)
+
+			_, err = clientIP_util.NewResolver(&server_config.Config{TrustedProxies: []string{"lb"}})
+			So(err, ShouldWrap, clientIP_util.ErrInvalidIPRange)
+		})
+	})
+}
+
+func first(value string, _ bool) string {
+	return value
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"github.com/greencoda/confiq"
+)
+
+const listValueKey = "list"
+
+// List is a slice config field that is left empty when its key is missing,
+// where a plain slice field would fail the whole decode.
+type List[T any] []T
+
+// Decode implements the confiq.Decoder interface.
+func (l *List[T]) Decode(value any) error {
+	if values, isSlice := value.([]any); value == nil || (isSlice && len(values) == 0) {
+		*l = nil
+
+		return nil
+	}
+
+	configSet := confiq.New()
+
+	if err := configSet.LoadRawValue([]any{map[string]any{listValueKey: value}}); err != nil {
+		return err
+	}
+
+	var decodedList []T
+
+	if err := configSet.Decode(&decodedList, confiq.AsStrict(), confiq.FromPrefix(listValueKey)); err != nil {
+		return err
+	}
+
+	*l = decodedList
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package config_test
+
+import (
+	_ "embed"
+	"testing"
+
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	"github.com/greencoda/confiq"
+	yaml_loader "github.com/greencoda/confiq/loaders/yaml"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testEndpoint struct {
+	Host  string                `cfg:"host"`
+	Ports config_util.List[int] `cfg:"ports"`
+}
+
+type testListConfig struct {
+	Name      string                         `cfg:"name"`
+	Tags      config_util.List[string]       `cfg:"tags"`
+	Aliases   config_util.List[string]       `cfg:"aliases"`
+	Defaults  config_util.List[string]       `cfg:"defaults,default=one;two"`
+	Endpoints config_util.List[testEndpoint] `cfg:"endpoints"`
+}
+
+//go:embed testdata/config_list.yaml
+var config_list string
+
+func Test_List_Decode(t *testing.T) {
+	Convey("When decoding list config fields", t, func() {
+		Convey("With present, missing and defaulted lists", func() {
+			configSet := confiq.New()
+
+			err := configSet.Load(
+				yaml_loader.Load().FromString(config_list),
+			)
+			So(err, ShouldBeNil)
+
+			config, err := config_util.LoadConfigFromSetWithPrefix[testListConfig](configSet, "test")
+			So(err, ShouldBeNil)
+			So(*config, ShouldResemble, testListConfig{
+				Name:     "list-service",
+				Tags:     config_util.List[string]{"alpha", "beta"},
+				Defaults: config_util.List[string]{"one", "two"},
+				Endpoints: config_util.List[testEndpoint]{
+					{Host: "a.example.com", Ports: config_util.List[int]{80, 443}},
+					{Host: "b.example.com"},
+				},
+			})
+		})
+
+		Convey("With an empty list", func() {
+			var list config_util.List[string]
+
+			err := list.Decode([]any{})
+			So(err, ShouldBeNil)
+			So(list, ShouldBeNil)
+		})
+
+		Convey("With a value that is not a list", func() {
+			var list config_util.List[int]
+
+			err := list.Decode(map[string]any{"key": "value"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// ReadSecret reads a secret from a file, or from an environment variable if no file is given. Whitespace around
+// the contents of the file is trimmed, so that secret files may end with a newline. Without either, it returns nil.
+func ReadSecret(secretFile, secretEnv string) ([]byte, error) {
+	switch {
+	case secretFile != "":
+		fileContents, err := os.ReadFile(secretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the secret file: %w", err)
+		}
+
+		return bytes.TrimSpace(fileContents), nil
+	case secretEnv != "":
+		return []byte(os.Getenv(secretEnv)), nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,43 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	config_util "github.com/greencoda/auth0-api-gateway/internal/util/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_ReadSecret(t *testing.T) {
+	Convey("When reading a secret", t, func() {
+		secretFile := filepath.Join(t.TempDir(), "secret")
+		So(os.WriteFile(secretFile, []byte("  file-secret\n"), 0o600), ShouldBeNil)
+
+		t.Setenv("TEST_SECRET", "env-secret")
+
+		Convey("Should read the trimmed contents of the file", func() {
+			secret, err := config_util.ReadSecret(secretFile, "TEST_SECRET")
+			So(err, ShouldBeNil)
+			So(string(secret), ShouldEqual, "file-secret")
+		})
+
+		Convey("Should read the environment variable without a file", func() {
+			secret, err := config_util.ReadSecret("", "TEST_SECRET")
+			So(err, ShouldBeNil)
+			So(string(secret), ShouldEqual, "env-secret")
+		})
+
+		Convey("Should fail when the file can't be read", func() {
+			secret, err := config_util.ReadSecret(filepath.Join(t.TempDir(), "missing"), "")
+			So(err, ShouldNotBeNil)
+			So(secret, ShouldBeNil)
+		})
+
+		Convey("Should return nil without a file or an environment variable", func() {
+			secret, err := config_util.ReadSecret("", "")
+			So(err, ShouldBeNil)
+			So(secret, ShouldBeNil)
+		})
+	})
+}
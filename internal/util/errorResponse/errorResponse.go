@@ -0,0 +1,120 @@
+package errorResponse
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Stable error codes of the error responses, which clients can rely on instead of the messages.
+const (
+	CodeMissingToken         = "missing_token"
+	CodeMalformedToken       = "malformed_token"
+	CodeInvalidToken         = "invalid_token"
+	CodeRevokedToken         = "revoked_token"
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidDPoPProof     = "invalid_dpop_proof"
+	CodeInsufficientScope    = "insufficient_scope"
+	CodeClaimMismatch        = "claim_mismatch"
+	CodeOrganizationMismatch = "organization_mismatch"
+	CodeMissingAPIKey        = "missing_api_key"
+	CodeInvalidAPIKey        = "invalid_api_key"
+	CodeClientCertRequired   = "client_certificate_required"
+	CodeClientCertNotAllowed = "client_certificate_not_allowed"
+	CodeAccessDenied         = "access_denied"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeServerError          = "server_error"
+	CodeAuthzUnavailable     = "authorization_unavailable"
+	CodeLoginFailed          = "login_failed"
+	CodeSessionRefreshFailed = "session_refresh_failed"
+	CodeMissingSignature     = "missing_signature"
+	CodeInvalidSignature     = "invalid_signature"
+	CodeRequestTooLarge      = "request_too_large"
+)
+
+// bearerErrors maps the error codes of bearer token failures to the error of their
+// RFC 6750 WWW-Authenticate challenge. Missing tokens are challenged without an error.
+var bearerErrors = map[string]string{
+	CodeMissingToken:      "",
+	CodeInvalidToken:      "invalid_token",
+	CodeRevokedToken:      "invalid_token",
+	CodeMalformedToken:    "invalid_request",
+	CodeInsufficientScope: "insufficient_scope",
+}
+
+// dpopErrors maps the error codes of DPoP proof failures to the error of their RFC 9449 WWW-Authenticate challenge.
+var dpopErrors = map[string]string{
+	CodeInvalidDPoPProof: "invalid_dpop_proof",
+}
+
+// Error describes an error response. The message is always sent, while the reason,
+// which may reveal details of the failure, is only sent if verbose reasons are enabled.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Reason     string
+
+	// Scopes are hinted in the WWW-Authenticate challenge of insufficient_scope errors.
+	Scopes []string
+}
+
+type responseBody struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type verboseReasonsContextKey struct{}
+
+// WithVerboseReasons returns the middleware enabling or disabling the reasons of the error responses
+// written while serving its requests.
+func WithVerboseReasons(verboseReasons bool) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+			handler.ServeHTTP(responseWriter, req.WithContext(context.WithValue(req.Context(), verboseReasonsContextKey{}, verboseReasons)))
+		})
+	}
+}
+
+// Write writes the error response as JSON, along with the WWW-Authenticate challenge of bearer token failures.
+func Write(responseWriter http.ResponseWriter, req *http.Request, responseError Error) {
+	body := responseBody{
+		Error:   responseError.Code,
+		Message: responseError.Message,
+	}
+
+	if verboseReasons, _ := req.Context().Value(verboseReasonsContextKey{}).(bool); verboseReasons {
+		body.Reason = responseError.Reason
+	}
+
+	if bearerError, isBearerError := bearerErrors[responseError.Code]; isBearerError {
+		responseWriter.Header().Set("WWW-Authenticate", bearerChallenge(bearerError, responseError.Scopes))
+	}
+
+	if dpopError, isDPoPError := dpopErrors[responseError.Code]; isDPoPError {
+		responseWriter.Header().Set("WWW-Authenticate", `DPoP error="`+dpopError+`"`)
+	}
+
+	encodedBody, _ := json.Marshal(body)
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(responseError.StatusCode)
+	_, _ = responseWriter.Write(encodedBody)
+}
+
+// bearerChallenge builds the WWW-Authenticate header value of a bearer token failure.
+func bearerChallenge(bearerError string, scopes []string) string {
+	if bearerError == "" {
+		return "Bearer"
+	}
+
+	challenge := `Bearer error="` + bearerError + `"`
+
+	if bearerError == "insufficient_scope" && len(scopes) > 0 {
+		challenge += `, scope="` + strings.Join(scopes, " ") + `"`
+	}
+
+	return challenge
+}
@@ -0,0 +1,125 @@
+package errorResponse_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errorResponse_util "github.com/greencoda/auth0-api-gateway/internal/util/errorResponse"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Write(t *testing.T) {
+	Convey("When writing an error response", t, func() {
+		write := func(verboseReasons bool, responseError errorResponse_util.Error) *httptest.ResponseRecorder {
+			handler := errorResponse_util.WithVerboseReasons(verboseReasons)(http.HandlerFunc(func(responseWriter http.ResponseWriter, req *http.Request) {
+				errorResponse_util.Write(responseWriter, req, responseError)
+			}))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/test", nil))
+
+			return recorder
+		}
+
+		invalidTokenError := errorResponse_util.Error{
+			StatusCode: http.StatusUnauthorized,
+			Code:       errorResponse_util.CodeInvalidToken,
+			Message:    "Failed to validate JWT.",
+			Reason:     `jwt invalid: claim "aud" is invalid`,
+		}
+
+		Convey("Should encode the body as JSON without the reason", func() {
+			recorder := write(false, invalidTokenError)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
+			So(recorder.Body.String(), ShouldEqual, `{"error":"invalid_token","message":"Failed to validate JWT."}`)
+		})
+
+		Convey("Should escape the reason when verbose reasons are enabled", func() {
+			recorder := write(true, invalidTokenError)
+
+			var body map[string]string
+			So(json.Unmarshal(recorder.Body.Bytes(), &body), ShouldBeNil)
+			So(body["reason"], ShouldEqual, `jwt invalid: claim "aud" is invalid`)
+		})
+
+		Convey("Should leave out the reason without the verbosity middleware", func() {
+			recorder := httptest.NewRecorder()
+			errorResponse_util.Write(recorder, httptest.NewRequest("GET", "/test", nil), invalidTokenError)
+			So(recorder.Body.String(), ShouldNotContainSubstring, "reason")
+		})
+
+		Convey("Should challenge invalid tokens", func() {
+			So(write(false, invalidTokenError).Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="invalid_token"`)
+		})
+
+		Convey("Should challenge missing tokens without an error", func() {
+			recorder := write(false, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeMissingToken,
+				Message:    "Missing bearer token.",
+			})
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, "Bearer")
+		})
+
+		Convey("Should hint the required scopes of insufficient scope errors", func() {
+			recorder := write(false, errorResponse_util.Error{
+				StatusCode: http.StatusForbidden,
+				Code:       errorResponse_util.CodeInsufficientScope,
+				Message:    "Insufficient access privileges.",
+				Scopes:     []string{"read:orders", "write:orders"},
+			})
+			So(recorder.Code, ShouldEqual, http.StatusForbidden)
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="insufficient_scope", scope="read:orders write:orders"`)
+		})
+
+		Convey("Should challenge invalid DPoP proofs with the DPoP scheme", func() {
+			recorder := write(false, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeInvalidDPoPProof,
+				Message:    "Invalid proof of possession.",
+			})
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `DPoP error="invalid_dpop_proof"`)
+		})
+
+		Convey("Should not challenge errors of other credentials", func() {
+			recorder := write(false, errorResponse_util.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       errorResponse_util.CodeInvalidAPIKey,
+				Message:    "Invalid API key.",
+			})
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldBeEmpty)
+		})
+
+		Convey("Should challenge malformed tokens as invalid requests", func() {
+			recorder := write(false, errorResponse_util.Error{
+				StatusCode: http.StatusBadRequest,
+				Code:       errorResponse_util.CodeMalformedToken,
+				Message:    "Malformed bearer token.",
+			})
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldEqual, `Bearer error="invalid_request"`)
+		})
+
+		Convey("Should not challenge invalid requests which aren't about a token", func() {
+			recorder := write(false, errorResponse_util.Error{
+				StatusCode: http.StatusBadRequest,
+				Code:       errorResponse_util.CodeInvalidRequest,
+				Message:    "Malformed request body.",
+			})
+			So(recorder.Header().Get("WWW-Authenticate"), ShouldBeEmpty)
+		})
+
+		Convey("Should not challenge claim and organization mismatches", func() {
+			for _, code := range []string{errorResponse_util.CodeClaimMismatch, errorResponse_util.CodeOrganizationMismatch} {
+				recorder := write(false, errorResponse_util.Error{
+					StatusCode: http.StatusForbidden,
+					Code:       code,
+					Message:    "Forbidden.",
+				})
+				So(recorder.Header().Get("WWW-Authenticate"), ShouldBeEmpty)
+			}
+		})
+	})
+}